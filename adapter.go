@@ -22,3 +22,12 @@ type Adapter interface {
 	// Be advised: this method may be called simultaneously from multiple workers.
 	SendMessage(context.Context, Output)
 }
+
+// ErrorReportingSender is an optional interface that Adapter and Bot implementations may satisfy to report the failure of sending a message.
+// Since SendMessage returns nothing, Broadcast relies on this to tell which targets failed.
+// defaultBot satisfies this and reports the error returned by its Adapter when the Adapter satisfies this; otherwise no failure is reported.
+// Bundled slack and gitter adapters satisfy this.
+type ErrorReportingSender interface {
+	// SendMessageWithError sends message in the same way as SendMessage, and returns an error when the message is not sent.
+	SendMessageWithError(context.Context, Output) error
+}
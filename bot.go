@@ -2,7 +2,9 @@ package sarah
 
 import (
 	"context"
+	"fmt"
 	"github.com/oklahomer/go-sarah/v3/log"
+	"sync"
 )
 
 // Bot provides an interface that each bot implementation must satisfy.
@@ -46,8 +48,12 @@ type defaultBot struct {
 	botType            BotType
 	runFunc            func(context.Context, func(Input) error, func(error))
 	sendMessageFunc    func(context.Context, Output)
+	sendWithErrorFunc  func(context.Context, Output) error
 	commands           *Commands
 	userContextStorage UserContextStorage
+	scopeResolver      ScopeResolver
+	scopes             []ScopeResolver
+	scopeMutex         sync.RWMutex
 }
 
 // NewBot creates and returns new defaultBot instance with given Adapter.
@@ -76,12 +82,19 @@ func NewBot(adapter Adapter, options ...DefaultBotOption) (Bot, error) {
 		sendMessageFunc:    adapter.SendMessage,
 		commands:           NewCommands(),
 		userContextStorage: nil,
+		scopeResolver:      ScopePerSender,
+	}
+
+	if sender, ok := adapter.(ErrorReportingSender); ok {
+		bot.sendWithErrorFunc = sender.SendMessageWithError
 	}
 
 	for _, opt := range options {
 		opt(bot)
 	}
 
+	bot.registerScope(bot.scopeResolver)
+
 	return bot, nil
 }
 
@@ -101,21 +114,47 @@ func BotWithStorage(storage UserContextStorage) DefaultBotOption {
 	}
 }
 
+// BotWithScopeResolver creates and returns DefaultBotOption to set the default ScopeResolver.
+// The ScopeResolver derives the key to store users' conversational contexts from the Input.
+// When this is not given, Input.SenderKey is used as is.
+//
+//  // Let everyone in the same channel share the conversation.
+//  bot, err := sarah.NewBot(myAdapter, sarah.BotWithStorage(storage), sarah.BotWithScopeResolver(sarah.ScopePerChannel))
+//
+// A Command and a UserContext may override this with CommandPropsBuilder.Scope and UserContext.Scope.
+// On an Input, stored contexts are looked up with this default ScopeResolver first, and then with other ScopeResolvers in the order they appeared.
+func BotWithScopeResolver(resolver ScopeResolver) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.scopeResolver = resolver
+	}
+}
+
 func (bot *defaultBot) BotType() BotType {
 	return bot.botType
 }
 
 func (bot *defaultBot) Respond(ctx context.Context, input Input) error {
-	senderKey := input.SenderKey()
-
 	// See if any conversational context is stored.
-	var nextFunc ContextualFunc
-	if bot.userContextStorage != nil {
-		var storageErr error
-		nextFunc, storageErr = bot.userContextStorage.Get(senderKey)
-		if storageErr != nil {
-			return storageErr
+	senderKey, scope, userContext, storageErr := bot.findUserContext(input)
+	if storageErr != nil {
+		return storageErr
+	}
+
+	// A stored context may be a plain function or a serialized argument stored by this or another process.
+	// In the latter case, the function registered via RegisterContextualFunc is resolved with the decoded argument.
+	nextFunc, resolveErr := resolveContextualFunc(userContext)
+	if resolveErr != nil {
+		e := bot.userContextStorage.Delete(senderKey)
+		if e != nil {
+			log.Warnf("Failed to delete UserContext: BotType: %s. SenderKey: %s. Error: %+v", bot.BotType(), senderKey, e)
 		}
+		return fmt.Errorf("failed to resume UserContext: %w", resolveErr)
+	}
+
+	// When contexts are stacked, the parent context is resumed or stored after the current one finishes.
+	var parent *UserContext
+	if userContext != nil {
+		parent = userContext.Parent
 	}
 
 	var res *CommandResponse
@@ -137,30 +176,65 @@ func (bot *defaultBot) Respond(ctx context.Context, input Input) error {
 			log.Warnf("Failed to delete UserContext: BotType: %s. SenderKey: %s. Error: %+v", bot.BotType(), senderKey, e)
 		}
 
-		switch input.(type) {
+		switch in := input.(type) {
 		case *AbortInput:
-			return nil
+			if !in.CurrentOnly() || parent == nil {
+				return nil
+			}
+
+			// Only the current level is aborted, so let the parent context know.
+			res = &CommandResponse{
+				UserContext: &UserContext{
+					returned: &ReturnedValue{Aborted: true},
+				},
+			}
 		default:
 			res, err = nextFunc(ctx, input)
 		}
 	}
 
 	if err != nil {
+		bot.storeUserContext(input, parent, scope)
 		return err
 	}
 
+	// The current context finished with ReturnToParent, so resume the parent context with the returned value.
+	for res != nil && res.UserContext != nil && res.UserContext.returned != nil && parent != nil {
+		if res.Content != nil {
+			bot.SendMessage(ctx, NewOutputMessage(input.ReplyTo(), res.Content))
+		}
+
+		returned := res.UserContext.returned
+		parentFunc, e := resolveContextualFunc(parent)
+		if e != nil {
+			return fmt.Errorf("failed to resume parent UserContext: %w", e)
+		}
+
+		if parent.Scope != nil {
+			scope = parent.Scope
+		}
+		parent = parent.Parent
+		res, err = parentFunc(WithReturnedValue(ctx, returned), input)
+		if err != nil {
+			bot.storeUserContext(input, parent, scope)
+			return err
+		}
+	}
+
 	if res == nil {
+		bot.storeUserContext(input, parent, scope)
 		return nil
 	}
 
 	// https://github.com/oklahomer/go-sarah/issues/7
 	// Bot may return no message to client and still keep the client in the middle of conversational context.
 	// This may damage user experience since user is left in conversational context set by CommandResponse without any sort of notification.
-	if res.UserContext != nil && bot.userContextStorage != nil {
-		if err := bot.userContextStorage.Set(senderKey, res.UserContext); err != nil {
-			log.Errorf("Failed to store UserContext. BotType: %s. SenderKey: %s. UserContext: %#v. Error: %+v", bot.BotType(), senderKey, res.UserContext, err)
-		}
+	next := parent
+	if res.UserContext != nil && res.UserContext.returned == nil {
+		next = stackUserContext(res.UserContext, parent)
 	}
+	bot.storeUserContext(input, next, scope)
+
 	if res.Content != nil {
 		message := NewOutputMessage(input.ReplyTo(), res.Content)
 		bot.SendMessage(ctx, message)
@@ -169,18 +243,171 @@ func (bot *defaultBot) Respond(ctx context.Context, input Input) error {
 	return nil
 }
 
+// storeUserContext stores the given UserContext so the user's next input is fed to it.
+func (bot *defaultBot) storeUserContext(input Input, userContext *UserContext, scope ScopeResolver) {
+	if userContext == nil || bot.userContextStorage == nil {
+		return
+	}
+
+	// The conversation stays in the scope it started unless the UserContext explicitly specifies one.
+	if userContext.Scope == nil {
+		userContext.Scope = scope
+	}
+	senderKey := bot.contextKey(userContext.Scope, input)
+	userContext.replyTo = input.ReplyTo()
+	if err := bot.userContextStorage.Set(senderKey, userContext); err != nil {
+		log.Errorf("Failed to store UserContext. BotType: %s. SenderKey: %s. UserContext: %#v. Error: %+v", bot.BotType(), senderKey, userContext, err)
+	}
+}
+
+func (bot *defaultBot) findUserContext(input Input) (string, ScopeResolver, *UserContext, error) {
+	if bot.userContextStorage == nil {
+		return "", nil, nil, nil
+	}
+
+	for _, scope := range bot.lookupScopes() {
+		key := scopedKey(scope, input)
+		if key == "" && scope.ID() != ScopePerSender.ID() {
+			// Not applicable to this input.
+			continue
+		}
+
+		userContext, err := bot.userContextStorage.Get(key)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		if userContext != nil {
+			return key, scope, userContext, nil
+		}
+	}
+
+	return "", nil, nil, nil
+}
+
+// contextKey derives the key to store UserContext with the given ScopeResolver.
+// When the ScopeResolver is not given or is not applicable to the Input, Input.SenderKey is used.
+func (bot *defaultBot) contextKey(scope ScopeResolver, input Input) string {
+	if scope == nil {
+		scope = bot.scopeResolver
+	}
+
+	if scope != nil {
+		bot.registerScope(scope)
+		if key := scopedKey(scope, input); key != "" {
+			return key
+		}
+	}
+
+	return input.SenderKey()
+}
+
+// registerScope stashes the given ScopeResolver so contexts stored with this are looked up on the following inputs.
+func (bot *defaultBot) registerScope(scope ScopeResolver) {
+	if scope == nil {
+		return
+	}
+
+	bot.scopeMutex.Lock()
+	defer bot.scopeMutex.Unlock()
+
+	for _, registered := range bot.scopes {
+		if registered.ID() == scope.ID() {
+			return
+		}
+	}
+	bot.scopes = append(bot.scopes, scope)
+}
+
+func (bot *defaultBot) lookupScopes() []ScopeResolver {
+	bot.scopeMutex.RLock()
+	defer bot.scopeMutex.RUnlock()
+
+	scopes := make([]ScopeResolver, 0, len(bot.scopes)+1)
+	hasFallback := false
+	for _, scope := range bot.scopes {
+		if scope.ID() == ScopePerSender.ID() {
+			hasFallback = true
+		}
+		scopes = append(scopes, scope)
+	}
+
+	// Input.SenderKey is used when other resolvers are not applicable, so always look it up.
+	if !hasFallback {
+		scopes = append(scopes, ScopePerSender)
+	}
+
+	return scopes
+}
+
 func (bot *defaultBot) SendMessage(ctx context.Context, output Output) {
 	bot.sendMessageFunc(ctx, output)
 }
 
+// SendMessageWithError sends given message and returns the error reported by the Adapter.
+// When the Adapter does not satisfy ErrorReportingSender, the message is sent via Adapter.SendMessage and nil is returned.
+func (bot *defaultBot) SendMessageWithError(ctx context.Context, output Output) error {
+	if bot.sendWithErrorFunc == nil {
+		bot.sendMessageFunc(ctx, output)
+		return nil
+	}
+	return bot.sendWithErrorFunc(ctx, output)
+}
+
+// prioritized tells if the given input should jump the queue of ordinary messages to be handled by any registered command.
+func (bot *defaultBot) prioritized(input Input) bool {
+	return bot.commands.prioritized(input)
+}
+
 func (bot *defaultBot) AppendCommand(command Command) {
+	// Register the command's ScopeResolver beforehand so the contexts stored by the previous process can be found.
+	if scoped, ok := command.(interface{ scope() ScopeResolver }); ok {
+		bot.registerScope(scoped.scope())
+	}
 	bot.commands.Append(command)
 }
 
 func (bot *defaultBot) Run(ctx context.Context, enqueueInput func(Input) error, notifyErr func(error)) {
+	if notifier, ok := bot.userContextStorage.(ExpirationNotifier); ok {
+		notifier.NotifyExpiration(func(key string, userContext *UserContext) {
+			bot.expireUserContext(ctx, key, userContext)
+		})
+	}
+
 	bot.runFunc(ctx, enqueueInput, notifyErr)
 }
 
+// expireUserContext lets the user know that the conversation timed out.
+func (bot *defaultBot) expireUserContext(ctx context.Context, key string, userContext *UserContext) {
+	select {
+	case <-ctx.Done():
+		// The Bot is no longer running.
+		return
+
+	default:
+		// O.K.
+
+	}
+
+	log.Debugf("UserContext expired. BotType: %s. SenderKey: %s.", bot.BotType(), key)
+
+	if userContext.OnExpire != nil {
+		// This is called on a storage's goroutine, so be panic-proof.
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("Panic on UserContext.OnExpire. BotType: %s. SenderKey: %s. Error: %+v", bot.BotType(), key, r)
+				}
+			}()
+			userContext.OnExpire(ctx)
+		}()
+	}
+
+	if userContext.ExpirationMessage != nil && userContext.replyTo != nil {
+		bot.SendMessage(ctx, NewOutputMessage(userContext.replyTo, userContext.ExpirationMessage))
+	}
+}
+
 // NewSuppressedResponseWithNext creates new sarah.CommandResponse instance with no message and next function to continue
 func NewSuppressedResponseWithNext(next ContextualFunc) *CommandResponse {
 	return &CommandResponse{
@@ -83,7 +83,7 @@ func TestDefaultBot_AppendCommand(t *testing.T) {
 func TestDefaultBot_Respond_StorageAcquisitionError(t *testing.T) {
 	storageError := errors.New("storage error")
 	dummyStorage := &DummyUserContextStorage{
-		GetFunc: func(_ string) (ContextualFunc, error) {
+		GetFunc: func(_ string) (*UserContext, error) {
 			return nil, storageError
 		},
 	}
@@ -133,7 +133,7 @@ func TestDefaultBot_Respond_WithCommandError(t *testing.T) {
 
 func TestDefaultBot_Respond_WithoutContext(t *testing.T) {
 	dummyStorage := &DummyUserContextStorage{
-		GetFunc: func(_ string) (ContextualFunc, error) {
+		GetFunc: func(_ string) (*UserContext, error) {
 			return nil, nil
 		},
 	}
@@ -157,7 +157,7 @@ func TestDefaultBot_Respond_WithoutContext(t *testing.T) {
 func TestDefaultBot_Respond_WithContextButMessage(t *testing.T) {
 	var givenNext ContextualFunc
 	dummyStorage := &DummyUserContextStorage{
-		GetFunc: func(_ string) (ContextualFunc, error) {
+		GetFunc: func(_ string) (*UserContext, error) {
 			return nil, nil
 		},
 		SetFunc: func(_ string, userContext *UserContext) error {
@@ -214,13 +214,13 @@ func TestDefaultBot_Respond_WithContext(t *testing.T) {
 		DeleteFunc: func(_ string) error {
 			return nil
 		},
-		GetFunc: func(_ string) (ContextualFunc, error) {
-			return func(_ context.Context, input Input) (*CommandResponse, error) {
+		GetFunc: func(_ string) (*UserContext, error) {
+			return NewUserContext(func(_ context.Context, input Input) (*CommandResponse, error) {
 				return &CommandResponse{
 					Content:     responseContent,
 					UserContext: NewUserContext(nextFunc),
 				}, nil
-			}, nil
+			}), nil
 		},
 		SetFunc: func(_ string, userContext *UserContext) error {
 			givenNext = userContext.Next
@@ -272,7 +272,7 @@ func TestDefaultBot_Respond_WithContextStorageSetError(t *testing.T) {
 		DeleteFunc: func(_ string) error {
 			return nil
 		},
-		GetFunc: func(_ string) (ContextualFunc, error) {
+		GetFunc: func(_ string) (*UserContext, error) {
 			return nil, nil
 		},
 		SetFunc: func(_ string, userContext *UserContext) error {
@@ -330,8 +330,8 @@ func TestDefaultBot_Respond_WithContextStorageDeleteError(t *testing.T) {
 		DeleteFunc: func(_ string) error {
 			return nil
 		},
-		GetFunc: func(_ string) (ContextualFunc, error) {
-			return nextFunc, nil
+		GetFunc: func(_ string) (*UserContext, error) {
+			return NewUserContext(nextFunc), nil
 		},
 	}
 
@@ -394,6 +394,78 @@ func TestDefaultBot_Respond_UserContextWithoutStorage(t *testing.T) {
 	}
 }
 
+func TestDefaultBot_Respond_WithSerializableContext(t *testing.T) {
+	type arg struct {
+		Answer int `json:"answer"`
+	}
+	var givenArg *arg
+	RegisterContextualFunc("test.guess", &arg{}, func(_ context.Context, _ Input, a interface{}) (*CommandResponse, error) {
+		givenArg = a.(*arg)
+		return &CommandResponse{Content: "Correct!"}, nil
+	})
+
+	dummyStorage := &DummyUserContextStorage{
+		GetFunc: func(_ string) (*UserContext, error) {
+			encoded, _ := EncodeSerializableArgument(&SerializableArgument{
+				FuncIdentifier: "test.guess",
+				Argument:       &arg{Answer: 7},
+			})
+			decoded, _ := DecodeSerializableArgument(encoded)
+			return &UserContext{Serializable: decoded}, nil
+		},
+		DeleteFunc: func(_ string) error {
+			return nil
+		},
+	}
+
+	var passedContent interface{}
+	myBot := &defaultBot{
+		userContextStorage: dummyStorage,
+		sendMessageFunc: func(_ context.Context, output Output) {
+			passedContent = output.Content()
+		},
+	}
+
+	err := myBot.Respond(context.TODO(), &DummyInput{MessageValue: "7"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %#v.", err)
+	}
+
+	if givenArg == nil || givenArg.Answer != 7 {
+		t.Errorf("Expected argument is not passed: %#v.", givenArg)
+	}
+
+	if passedContent != "Correct!" {
+		t.Errorf("Unexpected content is sent: %#v.", passedContent)
+	}
+}
+
+func TestDefaultBot_Respond_WithUnregisteredSerializableContext(t *testing.T) {
+	isStorageDeleted := false
+	dummyStorage := &DummyUserContextStorage{
+		GetFunc: func(_ string) (*UserContext, error) {
+			return NewSerializableUserContext("test.unregistered", nil), nil
+		},
+		DeleteFunc: func(_ string) error {
+			isStorageDeleted = true
+			return nil
+		},
+	}
+
+	myBot := &defaultBot{
+		userContextStorage: dummyStorage,
+	}
+
+	err := myBot.Respond(context.TODO(), &DummyInput{})
+	if !errors.Is(err, ErrContextualFuncNotRegistered) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if !isStorageDeleted {
+		t.Error("Unresolvable context should be deleted.")
+	}
+}
+
 func TestDefaultBot_Respond_Abort(t *testing.T) {
 	isStorageDeleted := false
 	dummyStorage := &DummyUserContextStorage{
@@ -401,10 +473,10 @@ func TestDefaultBot_Respond_Abort(t *testing.T) {
 			isStorageDeleted = true
 			return nil
 		},
-		GetFunc: func(_ string) (ContextualFunc, error) {
-			return func(_ context.Context, input Input) (*CommandResponse, error) {
+		GetFunc: func(_ string) (*UserContext, error) {
+			return NewUserContext(func(_ context.Context, input Input) (*CommandResponse, error) {
 				panic("Don't call me!!!")
-			}, nil
+			}), nil
 		},
 	}
 
@@ -433,7 +505,7 @@ func TestDefaultBot_Respond_Help(t *testing.T) {
 
 	var givenOutput Output
 	dummyStorage := &DummyUserContextStorage{
-		GetFunc: func(_ string) (ContextualFunc, error) {
+		GetFunc: func(_ string) (*UserContext, error) {
 			return nil, nil
 		},
 	}
@@ -491,6 +563,86 @@ func TestDefaultBot_Run(t *testing.T) {
 	}
 }
 
+func TestDefaultBot_Run_WithExpirationNotifier(t *testing.T) {
+	storage := NewUserContextStorage(NewCacheConfig())
+	sent := make(chan Output, 1)
+	onExpire := make(chan struct{}, 1)
+	bot := &defaultBot{
+		runFunc: func(_ context.Context, _ func(Input) error, _ func(error)) {},
+		sendMessageFunc: func(_ context.Context, output Output) {
+			sent <- output
+		},
+		commands: &Commands{
+			collection: []Command{
+				&DummyCommand{
+					MatchFunc: func(_ Input) bool {
+						return true
+					},
+					ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+						userContext := NewUserContext(func(_ context.Context, _ Input) (*CommandResponse, error) { return nil, nil })
+						userContext.ExpiresIn = 10 * time.Millisecond
+						userContext.ExpirationMessage = "timed out"
+						userContext.OnExpire = func(_ context.Context) {
+							onExpire <- struct{}{}
+						}
+						return &CommandResponse{
+							UserContext: userContext,
+						}, nil
+					},
+				},
+			},
+		},
+		userContextStorage: storage,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bot.Run(ctx, func(_ Input) error { return nil }, func(_ error) {})
+
+	input := &DummyInput{SenderKeyValue: "sender", ReplyToValue: "destination"}
+	err := bot.Respond(ctx, input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	select {
+	case output := <-sent:
+		if output.Destination() != "destination" {
+			t.Errorf("Unexpected destination is given: %#v.", output.Destination())
+		}
+		if output.Content() != "timed out" {
+			t.Errorf("Unexpected content is given: %#v.", output.Content())
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Expiration message is not sent.")
+
+	}
+
+	select {
+	case <-onExpire:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Error("UserContext.OnExpire is not called.")
+
+	}
+}
+
+func TestDefaultBot_expireUserContext_WithPanic(t *testing.T) {
+	bot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, _ Output) {},
+	}
+	userContext := &UserContext{
+		OnExpire: func(_ context.Context) {
+			panic("panic!")
+		},
+	}
+
+	// Should not panic.
+	bot.expireUserContext(context.TODO(), "key", userContext)
+}
+
 func TestDefaultBot_SendMessage(t *testing.T) {
 	adapterProcessed := false
 	bot := &defaultBot{
@@ -507,6 +659,43 @@ func TestDefaultBot_SendMessage(t *testing.T) {
 	}
 }
 
+type DummyErrorReportingAdapter struct {
+	*DummyAdapter
+	SendMessageWithErrorFunc func(context.Context, Output) error
+}
+
+func (adapter *DummyErrorReportingAdapter) SendMessageWithError(ctx context.Context, output Output) error {
+	return adapter.SendMessageWithErrorFunc(ctx, output)
+}
+
+func TestDefaultBot_SendMessageWithError(t *testing.T) {
+	output := NewOutputMessage(struct{}{}, struct{}{})
+
+	// Adapter.SendMessage is used when the Adapter does not report the error.
+	sent := false
+	bot, _ := NewBot(&DummyAdapter{
+		SendMessageFunc: func(_ context.Context, _ Output) {
+			sent = true
+		},
+	})
+	err := bot.(ErrorReportingSender).SendMessageWithError(context.TODO(), output)
+	if err != nil || !sent {
+		t.Errorf("Adapter.SendMessage is not called: %#v.", err)
+	}
+
+	sendErr := errors.New("dummy")
+	bot, _ = NewBot(&DummyErrorReportingAdapter{
+		DummyAdapter: &DummyAdapter{},
+		SendMessageWithErrorFunc: func(_ context.Context, _ Output) error {
+			return sendErr
+		},
+	})
+	err = bot.(ErrorReportingSender).SendMessageWithError(context.TODO(), output)
+	if err != sendErr {
+		t.Errorf("Error reported by the Adapter is not returned: %#v.", err)
+	}
+}
+
 func TestNewSuppressedResponseWithNext(t *testing.T) {
 	nextFunc := func(_ context.Context, input Input) (*CommandResponse, error) {
 		return nil, nil
@@ -0,0 +1,329 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBrainValueNotInteger is returned when Brain.Increment is called against a value that is not an integer.
+var ErrBrainValueNotInteger = errors.New("stored value is not an integer")
+
+// Brain defines an interface of a key-value storage that plugins can use to keep their state.
+// Keys are grouped by namespace so plugins never overwrite each other's values.
+// Use PluginBrain to work with a namespace tied to a BotType and a plugin identifier.
+//
+// NewMemoryBrain provides an in-memory implementation, and the storages package provides a local-file implementation.
+// Register preferred implementation with RegisterBrain.
+type Brain interface {
+	// Get returns the value stored with the given namespace and key.
+	// A nil value is returned when no value is stored or the value is expired.
+	Get(ctx context.Context, namespace string, key string) ([]byte, error)
+
+	// Set stores the value with the given namespace and key.
+	// The value expires after ttl, or never expires when ttl is zero.
+	Set(ctx context.Context, namespace string, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes the value stored with the given namespace and key.
+	// This does nothing if no value is stored.
+	Delete(ctx context.Context, namespace string, key string) error
+
+	// List returns the sorted keys stored under the given namespace.
+	List(ctx context.Context, namespace string) ([]string, error)
+
+	// Increment atomically adds delta to the integer value stored with the given namespace and key, and returns the result.
+	// A missing value is considered zero, and the stored value's expiration is kept as is.
+	// ErrBrainValueNotInteger is returned when the stored value is not an integer.
+	Increment(ctx context.Context, namespace string, key string, delta int64) (int64, error)
+}
+
+// BrainNamespace returns the namespace for the given BotType and plugin identifier.
+func BrainNamespace(botType BotType, pluginID string) string {
+	return fmt.Sprintf("%s:%s", botType.String(), pluginID)
+}
+
+// PluginBrain is a Brain bound to the namespace of a BotType and a plugin identifier.
+// A Command and a ScheduledTask can receive this via BrainFromContext.
+type PluginBrain struct {
+	brain     Brain
+	namespace string
+}
+
+// NewPluginBrain creates and returns new PluginBrain that works with the namespace of the given BotType and plugin identifier.
+func NewPluginBrain(brain Brain, botType BotType, pluginID string) *PluginBrain {
+	return &PluginBrain{
+		brain:     brain,
+		namespace: BrainNamespace(botType, pluginID),
+	}
+}
+
+// Namespace returns the namespace this PluginBrain works with.
+func (b *PluginBrain) Namespace() string {
+	return b.namespace
+}
+
+// Get returns the value stored with the given key.
+// A nil value is returned when no value is stored.
+func (b *PluginBrain) Get(ctx context.Context, key string) ([]byte, error) {
+	return b.brain.Get(ctx, b.namespace, key)
+}
+
+// Set stores the value with the given key.
+// The value expires after ttl, or never expires when ttl is zero.
+func (b *PluginBrain) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.brain.Set(ctx, b.namespace, key, value, ttl)
+}
+
+// Delete removes the value stored with the given key.
+func (b *PluginBrain) Delete(ctx context.Context, key string) error {
+	return b.brain.Delete(ctx, b.namespace, key)
+}
+
+// List returns the sorted keys stored in this namespace.
+func (b *PluginBrain) List(ctx context.Context) ([]string, error) {
+	return b.brain.List(ctx, b.namespace)
+}
+
+// Increment atomically adds delta to the integer value stored with the given key, and returns the result.
+func (b *PluginBrain) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	return b.brain.Increment(ctx, b.namespace, key, delta)
+}
+
+// GetJSON decodes the value stored with the given key into v.
+// This returns false when no value is stored.
+func (b *PluginBrain) GetJSON(ctx context.Context, key string, v interface{}) (bool, error) {
+	value, err := b.Get(ctx, key)
+	if err != nil || value == nil {
+		return false, err
+	}
+
+	err = json.Unmarshal(value, v)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode value of %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// SetJSON encodes v and stores it with the given key.
+func (b *PluginBrain) SetJSON(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode value of %s: %w", key, err)
+	}
+	return b.Set(ctx, key, value, ttl)
+}
+
+type botBrain struct {
+	brain   Brain
+	botType BotType
+}
+
+type botBrainKey struct{}
+
+type pluginBrainKey struct{}
+
+// withBotBrain returns a copy of the given context that carries the Brain for the given BotType.
+// The runner sets this to the Bot's context, and each Command or ScheduledTask receives its own PluginBrain on execution.
+func withBotBrain(ctx context.Context, brain Brain, botType BotType) context.Context {
+	return context.WithValue(ctx, botBrainKey{}, &botBrain{
+		brain:   brain,
+		botType: botType,
+	})
+}
+
+// withPluginBrainFor returns a copy of the given context that carries PluginBrain for the given plugin identifier.
+// The given context is returned as is when no Brain is set to the context.
+func withPluginBrainFor(ctx context.Context, pluginID string) context.Context {
+	b, ok := ctx.Value(botBrainKey{}).(*botBrain)
+	if !ok {
+		return ctx
+	}
+	return WithBrain(ctx, NewPluginBrain(b.brain, b.botType, pluginID))
+}
+
+// WithBrain returns a copy of the given context that carries the PluginBrain.
+// go-sarah's core sets this to the context of each Command and ScheduledTask execution,
+// so this is mainly used to test plugins.
+func WithBrain(ctx context.Context, brain *PluginBrain) context.Context {
+	return context.WithValue(ctx, pluginBrainKey{}, brain)
+}
+
+// BrainFromContext returns the PluginBrain for the running Command or ScheduledTask.
+//
+//  func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//    brain, ok := sarah.BrainFromContext(ctx)
+//    if !ok {
+//      return nil, errors.New("brain is not available")
+//    }
+//    cnt, err := brain.Increment(ctx, "count", 1)
+//    ...
+//  }
+//
+// Be aware that the context passed to a ContextualFunc does not carry the PluginBrain.
+// Capture the PluginBrain in the closure when the conversation continues.
+func BrainFromContext(ctx context.Context) (*PluginBrain, bool) {
+	brain, ok := ctx.Value(pluginBrainKey{}).(*PluginBrain)
+	return brain, ok
+}
+
+type memoryBrainEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e *memoryBrainEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// memoryBrain is the default Brain implementation that stores values in memory.
+type memoryBrain struct {
+	entries map[string]map[string]*memoryBrainEntry
+	now     func() time.Time
+	mutex   sync.Mutex
+}
+
+var _ Brain = (*memoryBrain)(nil)
+
+// NewMemoryBrain creates and returns new Brain implementation that stores values in memory.
+// This is used when no Brain is registered with RegisterBrain.
+// Stored values are lost when the process stops; use a persistent implementation such as storages.NewFileBrain to keep them.
+func NewMemoryBrain() Brain {
+	return &memoryBrain{
+		entries: map[string]map[string]*memoryBrainEntry{},
+		now:     time.Now,
+	}
+}
+
+func (b *memoryBrain) entry(namespace string, key string) *memoryBrainEntry {
+	entries, ok := b.entries[namespace]
+	if !ok {
+		return nil
+	}
+
+	entry, ok := entries[key]
+	if !ok {
+		return nil
+	}
+
+	if entry.expired(b.now()) {
+		delete(entries, key)
+		return nil
+	}
+
+	return entry
+}
+
+// Get returns the value stored with the given namespace and key.
+func (b *memoryBrain) Get(_ context.Context, namespace string, key string) ([]byte, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	entry := b.entry(namespace, key)
+	if entry == nil {
+		return nil, nil
+	}
+
+	value := make([]byte, len(entry.value))
+	copy(value, entry.value)
+	return value, nil
+}
+
+// Set stores the value with the given namespace and key.
+func (b *memoryBrain) Set(_ context.Context, namespace string, key string, value []byte, ttl time.Duration) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = b.now().Add(ttl)
+	}
+
+	entries, ok := b.entries[namespace]
+	if !ok {
+		entries = map[string]*memoryBrainEntry{}
+		b.entries[namespace] = entries
+	}
+
+	stored := make([]byte, len(value))
+	copy(stored, value)
+	entries[key] = &memoryBrainEntry{
+		value:     stored,
+		expiresAt: expiresAt,
+	}
+	return nil
+}
+
+// Delete removes the value stored with the given namespace and key.
+func (b *memoryBrain) Delete(_ context.Context, namespace string, key string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if entries, ok := b.entries[namespace]; ok {
+		delete(entries, key)
+	}
+	return nil
+}
+
+// List returns the sorted keys stored under the given namespace.
+func (b *memoryBrain) List(_ context.Context, namespace string) ([]string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	keys := []string{}
+	for key, entry := range b.entries[namespace] {
+		if entry.expired(now) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Increment atomically adds delta to the integer value stored with the given namespace and key.
+func (b *memoryBrain) Increment(_ context.Context, namespace string, key string, delta int64) (int64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	entry := b.entry(namespace, key)
+	if entry == nil {
+		entry = &memoryBrainEntry{}
+		entries, ok := b.entries[namespace]
+		if !ok {
+			entries = map[string]*memoryBrainEntry{}
+			b.entries[namespace] = entries
+		}
+		entries[key] = entry
+	}
+
+	current, err := ParseBrainInteger(entry.value)
+	if err != nil {
+		return 0, err
+	}
+
+	current += delta
+	entry.value = []byte(strconv.FormatInt(current, 10))
+	return current, nil
+}
+
+// ParseBrainInteger parses the value stored by Brain.Increment.
+// An empty value is considered zero, and ErrBrainValueNotInteger is returned when the value is not an integer.
+// Brain implementations may use this to implement Increment.
+func ParseBrainInteger(value []byte) (int64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	i, err := strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+	if err != nil {
+		return 0, ErrBrainValueNotInteger
+	}
+	return i, nil
+}
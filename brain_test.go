@@ -0,0 +1,264 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBrainNamespace(t *testing.T) {
+	namespace := BrainNamespace("slack", "todo")
+	if namespace != "slack:todo" {
+		t.Errorf("Unexpected namespace is returned: %s.", namespace)
+	}
+}
+
+func TestBrainFromContext(t *testing.T) {
+	if _, ok := BrainFromContext(context.Background()); ok {
+		t.Error("PluginBrain should not be returned from an empty context.")
+	}
+
+	brain := NewPluginBrain(NewMemoryBrain(), "dummy", "myPlugin")
+	given, ok := BrainFromContext(WithBrain(context.Background(), brain))
+	if !ok {
+		t.Fatal("PluginBrain is not returned.")
+	}
+
+	if given != brain {
+		t.Errorf("Unexpected PluginBrain is returned: %#v.", given)
+	}
+}
+
+func Test_withPluginBrainFor(t *testing.T) {
+	ctx := withPluginBrainFor(context.Background(), "myPlugin")
+	if _, ok := BrainFromContext(ctx); ok {
+		t.Error("PluginBrain should not be set when no Brain is given.")
+	}
+
+	brain := NewMemoryBrain()
+	ctx = withPluginBrainFor(withBotBrain(context.Background(), brain, "dummy"), "myPlugin")
+	given, ok := BrainFromContext(ctx)
+	if !ok {
+		t.Fatal("PluginBrain is not set.")
+	}
+
+	if given.brain != brain {
+		t.Errorf("Unexpected Brain is set: %#v.", given.brain)
+	}
+
+	if given.Namespace() != "dummy:myPlugin" {
+		t.Errorf("Unexpected namespace is set: %s.", given.Namespace())
+	}
+}
+
+func TestPluginBrain_Namespace(t *testing.T) {
+	ctx := context.Background()
+	brain := NewMemoryBrain()
+	slackBrain := NewPluginBrain(brain, "slack", "counter")
+	gitterBrain := NewPluginBrain(brain, "gitter", "counter")
+
+	_, _ = slackBrain.Increment(ctx, "count", 1)
+	_, _ = slackBrain.Increment(ctx, "count", 1)
+	cnt, _ := gitterBrain.Increment(ctx, "count", 1)
+
+	if cnt != 1 {
+		t.Errorf("Values must be isolated by namespace: %d.", cnt)
+	}
+
+	keys, _ := slackBrain.List(ctx)
+	if !reflect.DeepEqual(keys, []string{"count"}) {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+
+	err := slackBrain.Delete(ctx, "count")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	value, _ := gitterBrain.Get(ctx, "count")
+	if string(value) != "1" {
+		t.Errorf("Value in other namespace must not be deleted: %s.", value)
+	}
+}
+
+func TestPluginBrain_JSON(t *testing.T) {
+	type state struct {
+		Items []string `json:"items"`
+	}
+
+	ctx := context.Background()
+	brain := NewPluginBrain(NewMemoryBrain(), "dummy", "todo")
+
+	s := &state{}
+	found, err := brain.GetJSON(ctx, "state", s)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if found {
+		t.Error("Value should not be found.")
+	}
+
+	err = brain.SetJSON(ctx, "state", &state{Items: []string{"foo", "bar"}}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	found, err = brain.GetJSON(ctx, "state", s)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if !found {
+		t.Fatal("Value should be found.")
+	}
+	if !reflect.DeepEqual(s.Items, []string{"foo", "bar"}) {
+		t.Errorf("Unexpected value is decoded: %#v.", s)
+	}
+
+	_ = brain.Set(ctx, "broken", []byte("{"), 0)
+	_, err = brain.GetJSON(ctx, "broken", s)
+	if err == nil {
+		t.Error("Expected error is not returned for broken value.")
+	}
+}
+
+func TestMemoryBrain_CRUD(t *testing.T) {
+	ctx := context.Background()
+	brain := NewMemoryBrain()
+
+	value, err := brain.Get(ctx, "ns", "key")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if value != nil {
+		t.Errorf("Nil should be returned for absent key: %#v.", value)
+	}
+
+	given := []byte("value")
+	err = brain.Set(ctx, "ns", "key", given, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	given[0] = 'V'
+
+	value, _ = brain.Get(ctx, "ns", "key")
+	if string(value) != "value" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	_ = brain.Set(ctx, "ns", "another", []byte("value"), 0)
+	keys, _ := brain.List(ctx, "ns")
+	if !reflect.DeepEqual(keys, []string{"another", "key"}) {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+
+	err = brain.Delete(ctx, "ns", "key")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	value, _ = brain.Get(ctx, "ns", "key")
+	if value != nil {
+		t.Errorf("Deleted value is returned: %s.", value)
+	}
+
+	err = brain.Delete(ctx, "unknown", "key")
+	if err != nil {
+		t.Errorf("Unexpected error is returned on deleting absent key: %+v.", err)
+	}
+}
+
+func TestMemoryBrain_Expiration(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	brain := NewMemoryBrain().(*memoryBrain)
+	brain.now = func() time.Time {
+		return now
+	}
+
+	_ = brain.Set(ctx, "ns", "key", []byte("value"), time.Minute)
+	_ = brain.Set(ctx, "ns", "permanent", []byte("value"), 0)
+
+	if value, _ := brain.Get(ctx, "ns", "key"); value == nil {
+		t.Fatal("Value should be returned before expiration.")
+	}
+
+	cnt, err := brain.Increment(ctx, "ns", "counter", 1)
+	if err != nil || cnt != 1 {
+		t.Fatalf("Unexpected result is returned: %d, %+v.", cnt, err)
+	}
+
+	now = now.Add(time.Minute)
+
+	if value, _ := brain.Get(ctx, "ns", "key"); value != nil {
+		t.Errorf("Expired value is returned: %s.", value)
+	}
+
+	keys, _ := brain.List(ctx, "ns")
+	if !reflect.DeepEqual(keys, []string{"counter", "permanent"}) {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+}
+
+func TestMemoryBrain_Increment(t *testing.T) {
+	ctx := context.Background()
+	brain := NewMemoryBrain()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = brain.Increment(ctx, "ns", "counter", 1)
+		}()
+	}
+	wg.Wait()
+
+	cnt, err := brain.Increment(ctx, "ns", "counter", -10)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if cnt != 90 {
+		t.Errorf("Unexpected count is returned: %d.", cnt)
+	}
+
+	value, _ := brain.Get(ctx, "ns", "counter")
+	if string(value) != "90" {
+		t.Errorf("Unexpected value is stored: %s.", value)
+	}
+
+	_ = brain.Set(ctx, "ns", "string", []byte("foo"), 0)
+	_, err = brain.Increment(ctx, "ns", "string", 1)
+	if !errors.Is(err, ErrBrainValueNotInteger) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestParseBrainInteger(t *testing.T) {
+	testSets := []struct {
+		value    []byte
+		expected int64
+		err      error
+	}{
+		{value: nil, expected: 0},
+		{value: []byte("10"), expected: 10},
+		{value: []byte("-3"), expected: -3},
+		{value: []byte("foo"), err: ErrBrainValueNotInteger},
+	}
+
+	for i, testSet := range testSets {
+		parsed, err := ParseBrainInteger(testSet.value)
+		if testSet.err != nil {
+			if !errors.Is(err, testSet.err) {
+				t.Errorf("Expected error is not returned on test %d: %#v.", i, err)
+			}
+			continue
+		}
+
+		if parsed != testSet.expected {
+			t.Errorf("Unexpected value is returned on test %d: %d.", i, parsed)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"strings"
+	"sync"
+)
+
+// ErrBotNotRunning is returned when a message is addressed to a Bot that is not running.
+var ErrBotNotRunning = errors.New("bot is not running")
+
+var runningBots = &botRegistry{}
+
+// BroadcastTarget represents a pair of BotType and the destination of the corresponding Bot.
+type BroadcastTarget struct {
+	BotType     BotType
+	Destination OutputDestination
+}
+
+// Announcement is a bot-agnostic content to be broadcast to multiple Bots.
+// Each bundled adapter formats this in its own way; e.g. Slack adapter sends this as an attachment.
+// A plain string can also be broadcast as long as every destination Bot accepts string content.
+type Announcement struct {
+	Title string
+	Text  string
+}
+
+// BroadcastFailure represents a failure to send the content to one BroadcastTarget.
+type BroadcastFailure struct {
+	Target *BroadcastTarget
+	Err    error
+}
+
+// BroadcastError is returned by Broadcast when the content is not sent to one or more targets.
+// The content is still sent to the other targets.
+// A failure reported by the Bot is contained only when the Bot satisfies ErrorReportingSender; see Broadcast.
+type BroadcastError struct {
+	Failures []*BroadcastFailure
+}
+
+var _ error = (*BroadcastError)(nil)
+
+// Error returns the description of all failures.
+func (e *BroadcastError) Error() string {
+	var errs []string
+	for _, failure := range e.Failures {
+		errs = append(errs, fmt.Sprintf("%s: %s", failure.Target.BotType, failure.Err.Error()))
+	}
+	return strings.Join(errs, "\n")
+}
+
+// Broadcast sends the given content to all of the given targets.
+// Messages to different Bots are sent concurrently so a slow or failing Bot does not block the others.
+// This returns *BroadcastError when the content is not sent to one or more targets;
+// e.g. the target Bot is not running, the Bot panics on sending, or the Bot reports an error.
+// Since Bot.SendMessage returns nothing, the Bot's failure such as an API error or an unsupported content is reported only when the Bot satisfies ErrorReportingSender.
+// The Bot created by NewBot satisfies this, and reports the failure when its Adapter satisfies ErrorReportingSender as bundled slack and gitter adapters do.
+//
+//  err := sarah.Broadcast(&sarah.Announcement{Title: "Maintenance", Text: "The service stops at 10 PM."},
+//    &sarah.BroadcastTarget{BotType: slack.SLACK, Destination: event.ChannelID("C12345")},
+//    &sarah.BroadcastTarget{BotType: gitter.GITTER, Destination: &gitter.Room{ID: "abcde"}})
+func Broadcast(content interface{}, targets ...*BroadcastTarget) error {
+	grouped := map[BotType][]*BroadcastTarget{}
+	var order []BotType
+	for _, target := range targets {
+		if _, ok := grouped[target.BotType]; !ok {
+			order = append(order, target.BotType)
+		}
+		grouped[target.BotType] = append(grouped[target.BotType], target)
+	}
+
+	failures := make(chan *BroadcastFailure, len(targets))
+	wg := &sync.WaitGroup{}
+	for _, botType := range order {
+		wg.Add(1)
+		go func(targets []*BroadcastTarget) {
+			defer wg.Done()
+			for _, target := range targets {
+				err := runningBots.send(target, content)
+				if err != nil {
+					log.Errorf("Failed to broadcast to %s: %+v", target.BotType, err)
+					failures <- &BroadcastFailure{Target: target, Err: err}
+				}
+			}
+		}(grouped[botType])
+	}
+	wg.Wait()
+	close(failures)
+
+	var errs []*BroadcastFailure
+	for failure := range failures {
+		errs = append(errs, failure)
+	}
+	if len(errs) > 0 {
+		return &BroadcastError{Failures: errs}
+	}
+	return nil
+}
+
+type runningBot struct {
+	ctx context.Context
+	bot Bot
+}
+
+// botRegistry holds the running Bots so a message can be addressed to a Bot other than the one in charge.
+type botRegistry struct {
+	bots  map[BotType]*runningBot
+	mutex sync.RWMutex
+}
+
+func (r *botRegistry) add(botCtx context.Context, bot Bot) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.bots == nil {
+		r.bots = map[BotType]*runningBot{}
+	}
+	r.bots[bot.BotType()] = &runningBot{
+		ctx: botCtx,
+		bot: bot,
+	}
+}
+
+func (r *botRegistry) remove(botType BotType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.bots, botType)
+}
+
+func (r *botRegistry) get(botType BotType) (*runningBot, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	running, ok := r.bots[botType]
+	return running, ok
+}
+
+// send sends the content to the given target with the Bot's context.
+// A panic on sending is recovered and returned as an error so the other targets are not affected.
+func (r *botRegistry) send(target *BroadcastTarget, content interface{}) (err error) {
+	running, ok := r.get(target.BotType)
+	if !ok || running.ctx.Err() != nil {
+		return ErrBotNotRunning
+	}
+
+	if target.Destination == nil {
+		return errors.New("destination is not set")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic on sending message: %#v", r)
+		}
+	}()
+	output := NewOutputMessage(target.Destination, content)
+	if sender, ok := running.bot.(ErrorReportingSender); ok {
+		return sender.SendMessageWithError(running.ctx, output)
+	}
+	running.bot.SendMessage(running.ctx, output)
+	return nil
+}
@@ -0,0 +1,182 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcast(t *testing.T) {
+	SetupAndRun(func() {
+		mutex := &sync.Mutex{}
+		sent := map[BotType][]Output{}
+		newBot := func(botType BotType) *DummyBot {
+			return &DummyBot{
+				BotTypeValue: botType,
+				SendMessageFunc: func(_ context.Context, output Output) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					sent[botType] = append(sent[botType], output)
+				},
+			}
+		}
+		runningBots.add(context.TODO(), newBot("slack"))
+		runningBots.add(context.TODO(), newBot("gitter"))
+		runningBots.add(context.TODO(), &DummyBot{
+			BotTypeValue: "panicking",
+			SendMessageFunc: func(_ context.Context, _ Output) {
+				panic("dummy")
+			},
+		})
+
+		content := &Announcement{Title: "Maintenance", Text: "Starts at 10 PM."}
+		err := Broadcast(content,
+			&BroadcastTarget{BotType: "slack", Destination: "#general"},
+			&BroadcastTarget{BotType: "slack", Destination: "#random"},
+			&BroadcastTarget{BotType: "gitter", Destination: "room"},
+			&BroadcastTarget{BotType: "panicking", Destination: "dummy"},
+			&BroadcastTarget{BotType: "unknown", Destination: "dummy"},
+			&BroadcastTarget{BotType: "gitter", Destination: nil})
+
+		broadcastErr, ok := err.(*BroadcastError)
+		if !ok {
+			t.Fatalf("Expected error is not returned: %#v.", err)
+		}
+		if len(broadcastErr.Failures) != 3 {
+			t.Errorf("Unexpected number of failures: %#v.", broadcastErr.Failures)
+		}
+		for _, failure := range broadcastErr.Failures {
+			if failure.Target.BotType == "unknown" && failure.Err != ErrBotNotRunning {
+				t.Errorf("Unexpected error is returned: %#v.", failure.Err)
+			}
+		}
+
+		if len(sent["slack"]) != 2 {
+			t.Errorf("Unexpected number of messages are sent to slack: %#v.", sent["slack"])
+		}
+		if len(sent["gitter"]) != 1 {
+			t.Fatalf("Unexpected number of messages are sent to gitter: %#v.", sent["gitter"])
+		}
+		if sent["gitter"][0].Content() != content || sent["gitter"][0].Destination() != "room" {
+			t.Errorf("Unexpected message is sent: %#v.", sent["gitter"][0])
+		}
+
+		if err := Broadcast(content, &BroadcastTarget{BotType: "slack", Destination: "#general"}); err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+	})
+}
+
+func TestBroadcast_StoppedBot(t *testing.T) {
+	SetupAndRun(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		runningBots.add(ctx, &DummyBot{BotTypeValue: "slack"})
+
+		err := Broadcast("text", &BroadcastTarget{BotType: "slack", Destination: "#general"})
+		broadcastErr, ok := err.(*BroadcastError)
+		if !ok || broadcastErr.Failures[0].Err != ErrBotNotRunning {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		runningBots.remove("slack")
+		if _, ok := runningBots.get("slack"); ok {
+			t.Error("Removed Bot is still registered.")
+		}
+	})
+}
+
+type DummyErrorReportingBot struct {
+	*DummyBot
+	SendMessageWithErrorFunc func(context.Context, Output) error
+}
+
+func (bot *DummyErrorReportingBot) SendMessageWithError(ctx context.Context, output Output) error {
+	return bot.SendMessageWithErrorFunc(ctx, output)
+}
+
+func TestBroadcast_WithErrorReportingSender(t *testing.T) {
+	SetupAndRun(func() {
+		sendErr := errors.New("api error")
+		runningBots.add(context.TODO(), &DummyErrorReportingBot{
+			DummyBot: &DummyBot{BotTypeValue: "slack"},
+			SendMessageWithErrorFunc: func(_ context.Context, _ Output) error {
+				return sendErr
+			},
+		})
+		sent := false
+		runningBots.add(context.TODO(), &DummyErrorReportingBot{
+			DummyBot: &DummyBot{BotTypeValue: "gitter"},
+			SendMessageWithErrorFunc: func(_ context.Context, _ Output) error {
+				sent = true
+				return nil
+			},
+		})
+
+		err := Broadcast("text",
+			&BroadcastTarget{BotType: "slack", Destination: "#general"},
+			&BroadcastTarget{BotType: "gitter", Destination: "room"})
+
+		var broadcastErr *BroadcastError
+		if !errors.As(err, &broadcastErr) {
+			t.Fatalf("Expected error is not returned: %#v.", err)
+		}
+		if len(broadcastErr.Failures) != 1 || broadcastErr.Failures[0].Err != sendErr {
+			t.Errorf("Reported failure is not returned: %#v.", broadcastErr.Failures)
+		}
+		if !sent {
+			t.Error("Content is not sent to the other Bot.")
+		}
+	})
+}
+
+func Test_executeScheduledTask_WithBroadcast(t *testing.T) {
+	SetupAndRun(func() {
+		var sent []Output
+		bot := &DummyBot{
+			BotTypeValue: "slack",
+			SendMessageFunc: func(_ context.Context, output Output) {
+				sent = append(sent, output)
+			},
+		}
+		runningBots.add(context.TODO(), bot)
+
+		targets := []*BroadcastTarget{
+			{BotType: "slack", Destination: "#general"},
+			{BotType: "gitter", Destination: "room"},
+		}
+		task := &DummyScheduledTask{
+			IdentifierValue: "announcement",
+			ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				return []*ScheduledTaskResult{{Content: "text", Broadcast: targets}}, nil
+			},
+		}
+
+		// The result is returned but not sent on dry run.
+		results, err := executeScheduledTask(context.TODO(), bot, task, time.Time{}, true)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(results) != 1 || len(results[0].Broadcast) != 2 {
+			t.Errorf("Unexpected results are returned: %#v.", results)
+		}
+		if len(sent) != 0 {
+			t.Fatalf("Message should not be sent on dry run: %#v.", sent)
+		}
+
+		// Failure to the stopped Bot does not prevent the delivery to the running Bot.
+		results, err = executeScheduledTask(context.TODO(), bot, task, time.Time{}, false)
+		var broadcastErr *BroadcastError
+		if !errors.As(err, &broadcastErr) || len(broadcastErr.Failures) != 1 || broadcastErr.Failures[0].Target.BotType != "gitter" {
+			t.Fatalf("Expected error is not returned: %#v.", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("Delivered results should be returned: %#v.", results)
+		}
+		if len(sent) != 1 || sent[0].Destination() != "#general" {
+			t.Errorf("Unexpected messages are sent: %#v.", sent)
+		}
+	})
+}
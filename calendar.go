@@ -0,0 +1,389 @@
+package sarah
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"strings"
+	"sync"
+	"time"
+)
+
+var holidayCalendars = &calendarRegistry{}
+
+// holidayDateFormat is the format of the dates in calendar files.
+const holidayDateFormat = "2006-01-02"
+
+// maxBusinessDaySearch is the maximum number of days to look ahead for the next business day.
+const maxBusinessDaySearch = 366
+
+// HolidayPolicy defines how a scheduled execution behaves when its occurrence lands on a holiday of the task's calendar.
+type HolidayPolicy int
+
+const (
+	// HolidaySkip skips the execution on a holiday.
+	// The skipped execution is counted in TaskStatus.Skipped.
+	// This is the default policy.
+	HolidaySkip HolidayPolicy = iota
+
+	// HolidayShift postpones the execution to the same time of the next business day.
+	// The postponed execution is dropped when the regular schedule already has an occurrence at that time,
+	// or when the Bot stops before that time comes.
+	HolidayShift
+)
+
+// String returns the stringified form of the policy.
+func (p HolidayPolicy) String() string {
+	switch p {
+	case HolidaySkip:
+		return "skip"
+
+	case HolidayShift:
+		return "shift"
+
+	default:
+		return "unknown"
+
+	}
+}
+
+// CalendarTask is an optional interface that ScheduledTask implementation may satisfy to be aware of holidays.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the values given to ScheduledTaskPropsBuilder.Calendar and OnHoliday.
+//
+// The calendar is read via ConfigWatcher with the calendar name as its ID, and is reloaded whenever ConfigWatcher notifies its update.
+// With the file-based ConfigWatcher provided by the watchers package, the holidays can be listed in a YAML or JSON file such as below,
+// or an iCalendar file can be placed as {calendar name}.ics.
+//
+//  weekends:
+//    - saturday
+//    - sunday
+//  holidays:
+//    - date: 2027-01-01
+//      name: New Year's Day
+//
+// Only the executions fired by the schedule are affected; executions by events, TriggerTask, and catch-up are not.
+type CalendarTask interface {
+	// Calendar returns the name of the holiday calendar.
+	// An empty string means the task is not aware of holidays.
+	Calendar() string
+
+	// HolidayPolicy returns how the execution behaves on a holiday.
+	HolidayPolicy() HolidayPolicy
+}
+
+// taskCalendar returns the calendar name and the holiday policy for the given task.
+func taskCalendar(task ScheduledTask) (string, HolidayPolicy) {
+	if calendarTask, ok := task.(CalendarTask); ok {
+		return calendarTask.Calendar(), calendarTask.HolidayPolicy()
+	}
+	return "", HolidaySkip
+}
+
+// ICalendarUnmarshaler is the interface implemented by a configuration struct that can be read from an iCalendar file.
+// ConfigWatcher implementation may refer to this to support .ics files.
+type ICalendarUnmarshaler interface {
+	UnmarshalICalendar([]byte) error
+}
+
+// Holiday represents a date listed in a calendar file.
+type Holiday struct {
+	// Date is the date in the form of "2006-01-02."
+	Date string `json:"date" yaml:"date"`
+
+	// Name is the optional description such as "New Year's Day."
+	Name string `json:"name" yaml:"name"`
+}
+
+// calendarFile is the content of a calendar file read via ConfigWatcher.
+type calendarFile struct {
+	Weekends []string   `json:"weekends" yaml:"weekends"`
+	Holidays []*Holiday `json:"holidays" yaml:"holidays"`
+}
+
+var _ ICalendarUnmarshaler = (*calendarFile)(nil)
+
+// UnmarshalICalendar extracts the all-day events of the given iCalendar data as holidays.
+// Events spanning multiple days are expanded to each date, while recurrence rules are not supported.
+func (f *calendarFile) UnmarshalICalendar(data []byte) error {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			// Unfold the continuation line.
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read iCalendar data: %w", err)
+	}
+
+	var inEvent bool
+	var summary string
+	var start, end time.Time
+	for _, line := range lines {
+		sep := strings.Index(line, ":")
+		if sep < 0 {
+			continue
+		}
+		name := strings.ToUpper(strings.SplitN(line[:sep], ";", 2)[0])
+		value := line[sep+1:]
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent = true
+			summary = ""
+			start = time.Time{}
+			end = time.Time{}
+
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			inEvent = false
+			if start.IsZero() {
+				return errors.New("event without DTSTART is found in iCalendar data")
+			}
+			if !end.After(start) {
+				// DTEND is exclusive; a single-day event may omit it.
+				end = start.AddDate(0, 0, 1)
+			}
+			for d, i := start, 0; d.Before(end) && i < maxBusinessDaySearch; d, i = d.AddDate(0, 0, 1), i+1 {
+				f.Holidays = append(f.Holidays, &Holiday{Date: d.Format(holidayDateFormat), Name: summary})
+			}
+
+		case !inEvent:
+			continue
+
+		case name == "SUMMARY":
+			summary = value
+
+		case name == "DTSTART" || name == "DTEND":
+			if len(value) < 8 {
+				return fmt.Errorf("invalid %s is found in iCalendar data: %s", name, value)
+			}
+			date, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return fmt.Errorf("invalid %s is found in iCalendar data: %w", name, err)
+			}
+			if name == "DTSTART" {
+				start = date
+			} else {
+				end = date
+			}
+
+		}
+	}
+
+	return nil
+}
+
+// HolidayCalendar holds the holidays and the weekends loaded from a calendar file.
+// The content is replaced when the calendar file is updated.
+type HolidayCalendar struct {
+	holidays map[string]string
+	weekends map[time.Weekday]struct{}
+	mutex    sync.RWMutex
+}
+
+// NewHolidayCalendar creates and returns HolidayCalendar with the given holidays.
+// Saturday and Sunday are treated as weekends.
+func NewHolidayCalendar(holidays ...*Holiday) (*HolidayCalendar, error) {
+	c := &HolidayCalendar{}
+	err := c.update(&calendarFile{Holidays: holidays})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// update replaces the content with the given calendar file.
+// Saturday and Sunday are treated as weekends when the file lists no weekend.
+func (c *HolidayCalendar) update(file *calendarFile) error {
+	holidays := map[string]string{}
+	for _, holiday := range file.Holidays {
+		date, err := time.Parse(holidayDateFormat, holiday.Date)
+		if err != nil {
+			return fmt.Errorf("invalid holiday date is given: %w", err)
+		}
+		holidays[date.Format(holidayDateFormat)] = holiday.Name
+	}
+
+	weekends := map[time.Weekday]struct{}{}
+	for _, name := range file.Weekends {
+		weekday, err := parseWeekday(name)
+		if err != nil {
+			return err
+		}
+		weekends[weekday] = struct{}{}
+	}
+	if len(file.Weekends) == 0 {
+		weekends[time.Saturday] = struct{}{}
+		weekends[time.Sunday] = struct{}{}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.holidays = holidays
+	c.weekends = weekends
+	return nil
+}
+
+// IsHoliday tells if the date of the given time is listed as a holiday.
+// The date is determined in the time's location.
+func (c *HolidayCalendar) IsHoliday(t time.Time) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	_, ok := c.holidays[t.Format(holidayDateFormat)]
+	return ok
+}
+
+// IsBusinessDay tells if the date of the given time is neither a holiday nor a weekend.
+func (c *HolidayCalendar) IsBusinessDay(t time.Time) bool {
+	c.mutex.RLock()
+	_, weekend := c.weekends[t.Weekday()]
+	c.mutex.RUnlock()
+
+	return !weekend && !c.IsHoliday(t)
+}
+
+// NextBusinessDay returns the same time of the first business day after the given time.
+// Zero time is returned when no business day is found within a year.
+func (c *HolidayCalendar) NextBusinessDay(t time.Time) time.Time {
+	for i := 1; i <= maxBusinessDaySearch; i++ {
+		next := t.AddDate(0, 0, i)
+		if c.IsBusinessDay(next) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid weekday is given: %s", name)
+}
+
+// calendarRegistry holds the holiday calendars loaded for each Bot.
+type calendarRegistry struct {
+	calendars map[BotType]map[string]*HolidayCalendar
+	mutex     sync.Mutex
+}
+
+// load returns the calendar of the given name for the Bot.
+// On the first call for the name, the calendar is read via the ConfigWatcher and is reloaded on its update.
+// When the calendar is not found, an empty calendar is returned so it can be provided later.
+func (r *calendarRegistry) load(botCtx context.Context, botType BotType, name string, watcher ConfigWatcher) *HolidayCalendar {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if c, ok := r.calendars[botType][name]; ok {
+		return c
+	}
+
+	c, _ := NewHolidayCalendar()
+	read := func() {
+		file := &calendarFile{}
+		err := watcher.Read(botCtx, botType, name, file)
+		var notFoundErr *ConfigNotFoundError
+		if errors.As(err, &notFoundErr) {
+			log.Warnf("Holiday calendar %s is not found for %s.", name, botType)
+			return
+		} else if err != nil {
+			log.Errorf("Failed to read holiday calendar %s for %s: %+v", name, botType, err)
+			return
+		}
+
+		err = c.update(file)
+		if err != nil {
+			log.Errorf("Failed to load holiday calendar %s for %s: %+v", name, botType, err)
+		}
+	}
+	read()
+
+	err := watcher.Watch(botCtx, botType, name, func() {
+		log.Infof("Updating holiday calendar: %s", name)
+		read()
+	})
+	if err != nil {
+		log.Errorf("Failed to subscribe holiday calendar %s: %+v", name, err)
+	}
+
+	if r.calendars == nil {
+		r.calendars = map[BotType]map[string]*HolidayCalendar{}
+	}
+	if _, ok := r.calendars[botType]; !ok {
+		r.calendars[botType] = map[string]*HolidayCalendar{}
+	}
+	r.calendars[botType][name] = c
+	return c
+}
+
+// removeBot removes the calendars loaded for the given Bot.
+func (r *calendarRegistry) removeBot(botType BotType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.calendars, botType)
+}
+
+// dispatchScheduledOccurrence dispatches the execution of the given task's occurrence scheduled at firedAt.
+// firedAt is the occurrence computed from the schedule rather than the time the scheduler actually fired.
+// When the occurrence lands on a holiday of the task's calendar, the execution is skipped or shifted in accordance with the task's HolidayPolicy.
+func (r *runner) dispatchScheduledOccurrence(ctx context.Context, bot Bot, task ScheduledTask, defaultLocation *time.Location, firedAt time.Time) {
+	name, policy := taskCalendar(task)
+	if name == "" {
+		r.dispatchScheduledTask(ctx, bot, task, firedAt)
+		return
+	}
+
+	calendar := holidayCalendars.load(ctx, bot.BotType(), name, r.configWatcher)
+	occurrence := firedAt.In(taskLocation(task, defaultLocation)).Truncate(time.Minute)
+	if !calendar.IsHoliday(occurrence) {
+		r.dispatchScheduledTask(ctx, bot, task, firedAt)
+		return
+	}
+
+	if policy != HolidayShift {
+		log.Infof("Skipping scheduled task %s since %s is a holiday.", task.Identifier(), occurrence.Format(holidayDateFormat))
+		runnerStatus.taskHistory(bot.BotType(), task.Identifier(), r.config.TaskHistorySize).skip()
+		return
+	}
+
+	shifted := calendar.NextBusinessDay(occurrence)
+	if shifted.IsZero() {
+		log.Warnf("Skipping scheduled task %s since no business day is found.", task.Identifier())
+		runnerStatus.taskHistory(bot.BotType(), task.Identifier(), r.config.TaskHistorySize).skip()
+		return
+	}
+
+	schedule, err := parseTaskSchedule(task, defaultLocation)
+	if err == nil && schedule.Next(shifted.Add(-time.Second)).Equal(shifted) {
+		log.Infof("Skipping scheduled task %s on a holiday since it is scheduled at %s anyway.", task.Identifier(), shifted)
+		runnerStatus.taskHistory(bot.BotType(), task.Identifier(), r.config.TaskHistorySize).skip()
+		return
+	}
+
+	log.Infof("Shifting scheduled task %s to %s since %s is a holiday.", task.Identifier(), shifted, occurrence.Format(holidayDateFormat))
+	go func() {
+		timer := time.NewTimer(time.Until(shifted))
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			log.Infof("Drop shifted execution of scheduled task %s due to context cancellation.", task.Identifier())
+
+		case <-timer.C:
+			r.dispatchScheduledTask(ctx, bot, task, shifted)
+
+		}
+	}()
+}
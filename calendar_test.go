@@ -0,0 +1,237 @@
+package sarah
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHolidayPolicy_String(t *testing.T) {
+	tests := []struct {
+		policy   HolidayPolicy
+		expected string
+	}{
+		{policy: HolidaySkip, expected: "skip"},
+		{policy: HolidayShift, expected: "shift"},
+		{policy: 100, expected: "unknown"},
+	}
+
+	for _, tt := range tests {
+		if str := tt.policy.String(); str != tt.expected {
+			t.Errorf("Unexpected string is returned: %s.", str)
+		}
+	}
+}
+
+func TestNewHolidayCalendar(t *testing.T) {
+	calendar, err := NewHolidayCalendar(&Holiday{Date: "2027-01-01", Name: "New Year's Day"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	newYear := time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC)
+	if !calendar.IsHoliday(newYear) {
+		t.Error("Listed date is not treated as a holiday.")
+	}
+	if calendar.IsBusinessDay(newYear) {
+		t.Error("Holiday is treated as a business day.")
+	}
+
+	// 2027-01-02 is Saturday.
+	if calendar.IsHoliday(newYear.AddDate(0, 0, 1)) || calendar.IsBusinessDay(newYear.AddDate(0, 0, 1)) {
+		t.Error("Weekend is not treated as expected.")
+	}
+
+	next := calendar.NextBusinessDay(newYear)
+	if !next.Equal(time.Date(2027, 1, 4, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next business day is returned: %s.", next)
+	}
+
+	_, err = NewHolidayCalendar(&Holiday{Date: "01/01/2027"})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestHolidayCalendar_update(t *testing.T) {
+	calendar, _ := NewHolidayCalendar()
+	err := calendar.update(&calendarFile{Weekends: []string{"friday", "Saturday"}})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// 2027-01-01 is Friday.
+	if calendar.IsBusinessDay(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Given weekend is treated as a business day.")
+	}
+	if !calendar.IsBusinessDay(time.Date(2027, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Sunday should be a business day.")
+	}
+
+	err = calendar.update(&calendarFile{Weekends: []string{"someday"}})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestCalendarFile_UnmarshalICalendar(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20270101\r\n" +
+		"DTEND;VALUE=DATE:20270102\r\n" +
+		"SUMMARY:New Year's\r\n" +
+		"  Day\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20270503\r\n" +
+		"DTEND;VALUE=DATE:20270506\r\n" +
+		"SUMMARY:Golden Week\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART:20270720T000000Z\r\n" +
+		"SUMMARY:Marine Day\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	file := &calendarFile{}
+	err := file.UnmarshalICalendar([]byte(data))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := []*Holiday{
+		{Date: "2027-01-01", Name: "New Year's Day"},
+		{Date: "2027-05-03", Name: "Golden Week"},
+		{Date: "2027-05-04", Name: "Golden Week"},
+		{Date: "2027-05-05", Name: "Golden Week"},
+		{Date: "2027-07-20", Name: "Marine Day"},
+	}
+	if len(file.Holidays) != len(expected) {
+		t.Fatalf("Unexpected holidays are returned: %#v.", file.Holidays)
+	}
+	for i, holiday := range file.Holidays {
+		if *holiday != *expected[i] {
+			t.Errorf("Unexpected holiday is returned: %#v.", holiday)
+		}
+	}
+
+	err = (&calendarFile{}).UnmarshalICalendar([]byte("BEGIN:VEVENT\r\nSUMMARY:Broken\r\nEND:VEVENT\r\n"))
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func Test_calendarRegistry_load(t *testing.T) {
+	var callback func()
+	read := 0
+	watcher := &DummyConfigWatcher{
+		ReadFunc: func(_ context.Context, _ BotType, id string, configPtr interface{}) error {
+			read++
+			if read == 1 {
+				return &ConfigNotFoundError{BotType: "dummy", ID: id}
+			}
+			configPtr.(*calendarFile).Holidays = []*Holiday{{Date: "2027-01-01"}}
+			return nil
+		},
+		WatchFunc: func(_ context.Context, _ BotType, id string, fn func()) error {
+			if id != "holidays" {
+				t.Errorf("Unexpected id is given: %s.", id)
+			}
+			callback = fn
+			return nil
+		},
+	}
+
+	registry := &calendarRegistry{}
+	calendar := registry.load(context.TODO(), "dummy", "holidays", watcher)
+	newYear := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	if calendar.IsHoliday(newYear) {
+		t.Error("Calendar should be empty when not found.")
+	}
+
+	if registry.load(context.TODO(), "dummy", "holidays", watcher) != calendar || read != 1 {
+		t.Error("Loaded calendar should be reused.")
+	}
+
+	callback()
+	if !calendar.IsHoliday(newYear) {
+		t.Error("Updated calendar is not reflected.")
+	}
+
+	registry.removeBot("dummy")
+	if registry.load(context.TODO(), "dummy", "holidays", watcher) == calendar {
+		t.Error("Calendar should be loaded again after the Bot is removed.")
+	}
+}
+
+func Test_runner_dispatchScheduledOccurrence(t *testing.T) {
+	SetupAndRun(func() {
+		executed := 0
+		task := &scheduledTask{
+			identifier: "standup",
+			schedule:   "0 9 * * 1-5",
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				executed++
+				return nil, nil
+			},
+			calendar: "holidays",
+		}
+		r := &runner{
+			config: &Config{TaskHistorySize: 3},
+			configWatcher: &DummyConfigWatcher{
+				ReadFunc: func(_ context.Context, _ BotType, _ string, configPtr interface{}) error {
+					configPtr.(*calendarFile).Holidays = []*Holiday{{Date: "2027-01-01"}, {Date: "2027-01-04"}}
+					return nil
+				},
+				WatchFunc: func(_ context.Context, _ BotType, _ string, _ func()) error {
+					return nil
+				},
+			},
+			alerters: &alerters{},
+		}
+		bot := &DummyBot{BotTypeValue: "dummy"}
+
+		r.dispatchScheduledOccurrence(context.TODO(), bot, task, time.UTC, time.Date(2027, 1, 5, 9, 0, 0, 0, time.UTC))
+		if executed != 1 {
+			t.Fatal("Task should be executed on a business day.")
+		}
+
+		r.dispatchScheduledOccurrence(context.TODO(), bot, task, time.UTC, time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC))
+		if executed != 1 {
+			t.Fatal("Task should not be executed on a holiday.")
+		}
+
+		// The next business day already has the scheduled execution.
+		task.holidayPolicy = HolidayShift
+		r.dispatchScheduledOccurrence(context.TODO(), bot, task, time.UTC, time.Date(2027, 1, 4, 9, 0, 0, 0, time.UTC))
+		if executed != 1 {
+			t.Fatal("Task should not be executed on a holiday.")
+		}
+
+		status := runnerStatus.taskHistory("dummy", "standup", 3).snapshot()
+		if status.Skipped != 2 {
+			t.Errorf("Unexpected number of skips is recorded: %d.", status.Skipped)
+		}
+
+		// The postponed execution runs on the next business day.
+		task.schedule = "0 9 1 1 *"
+		firedAt := time.Now().UTC().Truncate(time.Minute)
+		r.configWatcher = &DummyConfigWatcher{
+			ReadFunc: func(_ context.Context, _ BotType, _ string, configPtr interface{}) error {
+				configPtr.(*calendarFile).Holidays = []*Holiday{{Date: firedAt.Format(holidayDateFormat)}}
+				return nil
+			},
+			WatchFunc: func(_ context.Context, _ BotType, _ string, _ func()) error {
+				return nil
+			},
+		}
+		holidayCalendars = &calendarRegistry{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r.dispatchScheduledOccurrence(ctx, bot, task, time.UTC, firedAt)
+		time.Sleep(10 * time.Millisecond)
+		if executed != 1 {
+			t.Error("Postponed execution should be dropped when the Bot stops.")
+		}
+	})
+}
@@ -46,10 +46,13 @@ type commandConfigWrapper struct {
 
 type defaultCommand struct {
 	identifier      string
+	matchPattern    *regexp.Regexp
 	matchFunc       func(Input) bool
 	instructionFunc func(*HelpInput) string
 	commandFunc     commandFunc
 	configWrapper   *commandConfigWrapper
+	scopeResolver   ScopeResolver
+	priorityFunc    func(Input) bool
 }
 
 func (command *defaultCommand) Identifier() string {
@@ -65,6 +68,34 @@ func (command *defaultCommand) Match(input Input) bool {
 }
 
 func (command *defaultCommand) Execute(ctx context.Context, input Input) (*CommandResponse, error) {
+	res, err := command.execute(ctx, input)
+
+	// Let the conversation started by this command be stored in the command's scope.
+	if command.scopeResolver != nil && res != nil && res.UserContext != nil && res.UserContext.Scope == nil {
+		res.UserContext.Scope = command.scopeResolver
+	}
+
+	return res, err
+}
+
+func (command *defaultCommand) scope() ScopeResolver {
+	return command.scopeResolver
+}
+
+// prioritized tells if the given input should jump the queue of ordinary messages to be handled by this command.
+func (command *defaultCommand) prioritized(input Input) bool {
+	return command.priorityFunc != nil && command.priorityFunc(input)
+}
+
+func (command *defaultCommand) execute(ctx context.Context, input Input) (*CommandResponse, error) {
+	// When the command is matched by a regular expression, pass the matched result to the command function
+	// so the function does not have to apply the same pattern again.
+	if command.matchPattern != nil {
+		if match := NewPatternMatch(command.matchPattern, input.Message()); match != nil {
+			ctx = WithPatternMatch(ctx, match)
+		}
+	}
+
 	wrapper := command.configWrapper
 	if wrapper == nil {
 		return command.commandFunc(ctx, input)
@@ -81,10 +112,13 @@ func buildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatche
 	if props.config == nil {
 		return &defaultCommand{
 			identifier:      props.identifier,
+			matchPattern:    props.matchPattern,
 			matchFunc:       props.matchFunc,
 			instructionFunc: props.instructionFunc,
 			commandFunc:     props.commandFunc,
 			configWrapper:   nil,
+			scopeResolver:   props.scopeResolver,
+			priorityFunc:    props.priorityFunc,
 		}, nil
 	}
 
@@ -126,6 +160,7 @@ func buildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatche
 
 	return &defaultCommand{
 		identifier:      props.identifier,
+		matchPattern:    props.matchPattern,
 		matchFunc:       props.matchFunc,
 		instructionFunc: props.instructionFunc,
 		commandFunc:     props.commandFunc,
@@ -133,6 +168,8 @@ func buildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatche
 			value: cfg,
 			mutex: locker,
 		},
+		scopeResolver: props.scopeResolver,
+		priorityFunc:  props.priorityFunc,
 	}, nil
 }
 
@@ -195,6 +232,20 @@ func (commands *Commands) FindFirstMatched(input Input) Command {
 	return nil
 }
 
+// prioritized tells if the given input should jump the queue of ordinary messages to be handled by any of the commands.
+func (commands *Commands) prioritized(input Input) bool {
+	commands.mutex.RLock()
+	defer commands.mutex.RUnlock()
+
+	for _, command := range commands.collection {
+		if p, ok := command.(interface{ prioritized(Input) bool }); ok && p.prioritized(input) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ExecuteFirstMatched tries find matching command with the given input, and execute it if one is available.
 func (commands *Commands) ExecuteFirstMatched(ctx context.Context, input Input) (*CommandResponse, error) {
 	command := commands.FindFirstMatched(input)
@@ -202,7 +253,7 @@ func (commands *Commands) ExecuteFirstMatched(ctx context.Context, input Input)
 		return nil, nil
 	}
 
-	return command.Execute(ctx, input)
+	return command.Execute(withPluginBrainFor(ctx, command.Identifier()), input)
 }
 
 // Helps returns underlying commands help messages in a form of *CommandHelps.
@@ -254,8 +305,14 @@ type CommandProps struct {
 	identifier      string
 	config          CommandConfig
 	commandFunc     commandFunc
+	matchPattern    *regexp.Regexp
 	matchFunc       func(Input) bool
 	instructionFunc func(*HelpInput) string
+	scopeResolver   ScopeResolver
+
+	// priorityFunc tells if the input should jump the queue of ordinary messages.
+	// This is set only by the administrative commands of go-sarah's core such as NewTriggerTaskCommandProps.
+	priorityFunc func(Input) bool
 }
 
 // CommandPropsBuilder helps to construct CommandProps.
@@ -280,8 +337,12 @@ func (builder *CommandPropsBuilder) Identifier(id string) *CommandPropsBuilder {
 // MatchPattern is a setter to provide command match pattern.
 // This regular expression is used to find matching command with given Input.
 //
+// The matched submatches and named captures are passed to the command function on execution.
+// Use FuncWithMatch to receive them as an argument, or call PatternMatchFromContext with the given context.
+//
 // Use MatchFunc to set more customizable matching logic.
 func (builder *CommandPropsBuilder) MatchPattern(pattern *regexp.Regexp) *CommandPropsBuilder {
+	builder.props.matchPattern = pattern
 	builder.props.matchFunc = func(input Input) bool {
 		return pattern.MatchString(input.Message())
 	}
@@ -294,6 +355,7 @@ func (builder *CommandPropsBuilder) MatchPattern(pattern *regexp.Regexp) *Comman
 // MatchPattern may be used to specify a regular expression that is checked against user input, Input.Message();
 // MatchFunc can specify more customizable matching logic. e.g. only return true on specific sender's specific message on specific time range.
 func (builder *CommandPropsBuilder) MatchFunc(matchFunc func(Input) bool) *CommandPropsBuilder {
+	builder.props.matchPattern = nil
 	builder.props.matchFunc = matchFunc
 	return builder
 }
@@ -308,6 +370,27 @@ func (builder *CommandPropsBuilder) Func(fn func(context.Context, Input) (*Comma
 	return builder
 }
 
+// FuncWithMatch is a setter to provide command function that receives the result of MatchPattern.
+// The regular expression given to MatchPattern is applied to the user input on execution,
+// and its submatches and named captures are passed to fn as *PatternMatch.
+//
+//  sarah.NewCommandPropsBuilder().
+//    MatchPattern(regexp.MustCompile(`^\.echo\s+(?P<text>.+)`)).
+//    FuncWithMatch(func(ctx context.Context, input sarah.Input, match *sarah.PatternMatch) (*sarah.CommandResponse, error) {
+//      return slack.NewResponse(input, match.Get("text"))
+//    })
+//
+// When the match is judged by MatchFunc instead of MatchPattern, fn receives nil.
+// If ConfigurableFunc, Func and FuncWithMatch are called, later call overrides the previous one.
+func (builder *CommandPropsBuilder) FuncWithMatch(fn func(context.Context, Input, *PatternMatch) (*CommandResponse, error)) *CommandPropsBuilder {
+	builder.props.config = nil
+	builder.props.commandFunc = func(ctx context.Context, input Input, cfg ...CommandConfig) (*CommandResponse, error) {
+		match, _ := PatternMatchFromContext(ctx)
+		return fn(ctx, input, match)
+	}
+	return builder
+}
+
 // ConfigurableFunc is a setter to provide command function.
 // While Func let developers set simple function, this allows them to provide function that requires some sort of configuration struct.
 // On Runner.Run configuration is read from YAML/JSON file located at /path/to/config/dir/{commandIdentifier}.(yaml|yml|json) and mapped to given CommandConfig struct.
@@ -343,6 +426,14 @@ func (builder *CommandPropsBuilder) InstructionFunc(fnc func(input *HelpInput) s
 	return builder
 }
 
+// Scope is a setter to provide ScopeResolver that derives the key to store the conversational context started by this command.
+// e.g. Pass ScopePerChannel so everyone in the channel can join the conversation.
+// When this is not set, the Bot's default ScopeResolver is used.
+func (builder *CommandPropsBuilder) Scope(resolver ScopeResolver) *CommandPropsBuilder {
+	builder.props.scopeResolver = resolver
+	return builder
+}
+
 // Build builds new CommandProps instance with provided values.
 func (builder *CommandPropsBuilder) Build() (*CommandProps, error) {
 	if builder.props.botType == "" ||
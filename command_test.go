@@ -92,6 +92,26 @@ func TestCommandPropsBuilder_Func(t *testing.T) {
 	}
 }
 
+func TestCommandPropsBuilder_FuncWithMatch(t *testing.T) {
+	var given *PatternMatch
+	builder := &CommandPropsBuilder{props: &CommandProps{config: &struct{}{}}}
+	fnc := func(_ context.Context, _ Input, match *PatternMatch) (*CommandResponse, error) {
+		given = match
+		return nil, nil
+	}
+
+	builder.FuncWithMatch(fnc)
+	if builder.props.config != nil {
+		t.Error("Config should be cleared.")
+	}
+
+	match := &PatternMatch{}
+	_, _ = builder.props.commandFunc(WithPatternMatch(context.TODO(), match), &DummyInput{})
+	if given != match {
+		t.Errorf("Stored match is not passed: %#v.", given)
+	}
+}
+
 func TestCommandPropsBuilder_Identifier(t *testing.T) {
 	builder := &CommandPropsBuilder{props: &CommandProps{}}
 	id := "FOO"
@@ -132,6 +152,10 @@ func TestCommandPropsBuilder_MatchPattern(t *testing.T) {
 	if !builder.props.matchFunc(&DummyInput{MessageValue: ".echo"}) {
 		t.Error("Expected true to return, but did not.")
 	}
+
+	if builder.props.matchPattern == nil {
+		t.Error("Given pattern is not stored.")
+	}
 }
 
 func TestCommandPropsBuilder_MatchFunc(t *testing.T) {
@@ -307,6 +331,38 @@ func TestCommands_ExecuteFirstMatched(t *testing.T) {
 	}
 }
 
+func TestCommands_ExecuteFirstMatched_WithBrain(t *testing.T) {
+	var botType BotType = "dummy"
+	ctx := withBotBrain(context.Background(), NewMemoryBrain(), botType)
+
+	var given *PluginBrain
+	command := &DummyCommand{
+		IdentifierValue: "commandID",
+		MatchFunc: func(_ Input) bool {
+			return true
+		},
+		ExecuteFunc: func(ctx context.Context, _ Input) (*CommandResponse, error) {
+			given, _ = BrainFromContext(ctx)
+			return nil, nil
+		},
+	}
+	commands := NewCommands()
+	commands.Append(command)
+
+	_, err := commands.ExecuteFirstMatched(ctx, &DummyInput{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if given == nil {
+		t.Fatal("PluginBrain is not passed.")
+	}
+
+	if given.Namespace() != BrainNamespace(botType, command.IdentifierValue) {
+		t.Errorf("Unexpected namespace is given: %s.", given.Namespace())
+	}
+}
+
 func TestCommands_Append(t *testing.T) {
 	commands := &Commands{}
 
@@ -422,6 +478,28 @@ func TestSimpleCommand_Execute(t *testing.T) {
 	}
 }
 
+func TestSimpleCommand_Execute_WithMatchPattern(t *testing.T) {
+	var given *PatternMatch
+	command := defaultCommand{
+		matchPattern: regexp.MustCompile(`^\.echo\s+(?P<text>.+)`),
+		commandFunc: func(ctx context.Context, input Input, cfg ...CommandConfig) (*CommandResponse, error) {
+			given, _ = PatternMatchFromContext(ctx)
+			return nil, nil
+		},
+	}
+
+	_, err := command.Execute(context.TODO(), &DummyInput{MessageValue: ".echo foo bar"})
+	if err != nil {
+		t.Fatalf("Error is returned: %s", err.Error())
+	}
+	if given == nil {
+		t.Fatal("Matched result is not stored in the context.")
+	}
+	if given.Get("text") != "foo bar" {
+		t.Errorf("Unexpected capture is stored: %s.", given.Get("text"))
+	}
+}
+
 func TestStripMessage(t *testing.T) {
 	pattern := regexp.MustCompile(`^\.echo`)
 	stripped := StripMessage(pattern, ".echo foo bar")
@@ -628,3 +706,32 @@ func Test_buildCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandPropsBuilder_Scope(t *testing.T) {
+	builder := &CommandPropsBuilder{props: &CommandProps{}}
+	builder.Scope(ScopePerChannel)
+
+	if builder.props.scopeResolver != ScopePerChannel {
+		t.Error("Provided ScopeResolver is not set.")
+	}
+}
+
+func TestDefaultCommand_Execute_WithScope(t *testing.T) {
+	command := &defaultCommand{
+		commandFunc: func(_ context.Context, _ Input, _ ...CommandConfig) (*CommandResponse, error) {
+			return &CommandResponse{
+				UserContext: NewUserContext(func(_ context.Context, _ Input) (*CommandResponse, error) { return nil, nil }),
+			}, nil
+		},
+		scopeResolver: ScopePerChannel,
+	}
+
+	res, err := command.Execute(context.TODO(), &DummyInput{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if res.UserContext.Scope != ScopePerChannel {
+		t.Errorf("Command's ScopeResolver is not set to UserContext: %#v.", res.UserContext.Scope)
+	}
+}
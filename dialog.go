@@ -0,0 +1,508 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDialogInsufficientArgument is returned when required parameters are not set to DialogBuilder.
+	ErrDialogInsufficientArgument = errors.New("one or more slots and Handler must be set")
+
+	// ErrDialogDuplicatedSlot is returned when two or more slots share the same identifier.
+	ErrDialogDuplicatedSlot = errors.New("slot identifier must be unique")
+)
+
+// DialogValues stashes the values collected by Dialog.
+// Each value is stored with the identifier of the corresponding DialogSlot in the order of user input.
+type DialogValues struct {
+	values map[string]interface{}
+	order  []string
+}
+
+// NewDialogValues creates and returns new DialogValues instance.
+// Pass this with some pre-filled values to Dialog.StartWith so the user can skip the corresponding slots.
+func NewDialogValues() *DialogValues {
+	return &DialogValues{
+		values: map[string]interface{}{},
+		order:  []string{},
+	}
+}
+
+// Set stores the given value with the given slot identifier.
+func (v *DialogValues) Set(id string, value interface{}) {
+	if _, ok := v.values[id]; !ok {
+		v.order = append(v.order, id)
+	}
+	v.values[id] = value
+}
+
+// Get returns the value stored with the given slot identifier.
+// The second returning value tells if the value is stored.
+func (v *DialogValues) Get(id string) (interface{}, bool) {
+	value, ok := v.values[id]
+	return value, ok
+}
+
+// String returns the stringified form of the value stored with the given slot identifier.
+// Empty string is returned when no value is stored.
+func (v *DialogValues) String(id string) string {
+	value, ok := v.values[id]
+	if !ok || value == nil {
+		return ""
+	}
+
+	if str, ok := value.(string); ok {
+		return str
+	}
+	return fmt.Sprint(value)
+}
+
+// Has tells if a value is stored with the given slot identifier.
+func (v *DialogValues) Has(id string) bool {
+	_, ok := v.values[id]
+	return ok
+}
+
+// Keys returns the slot identifiers in the order of user input.
+func (v *DialogValues) Keys() []string {
+	keys := make([]string, len(v.order))
+	copy(keys, v.order)
+	return keys
+}
+
+func (v *DialogValues) remove(id string) {
+	if _, ok := v.values[id]; !ok {
+		return
+	}
+
+	delete(v.values, id)
+	for i, key := range v.order {
+		if key == id {
+			v.order = append(v.order[:i], v.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (v *DialogValues) copy() *DialogValues {
+	c := NewDialogValues()
+	for _, id := range v.order {
+		c.Set(id, v.values[id])
+	}
+	return c
+}
+
+// DialogSlot represents one step of Dialog that asks the user to input a value.
+// Use NewDialogSlot to create new instance and chain the setters to customize its behavior.
+type DialogSlot struct {
+	id             string
+	promptFunc     func(*DialogValues) string
+	validator      func(string) error
+	parser         func(string) (interface{}, error)
+	invalidMessage string
+	skipFunc       func(*DialogValues) bool
+	branchFunc     func(*DialogValues) string
+}
+
+// NewDialogSlot creates and returns new DialogSlot with the given identifier and prompt message.
+// The identifier is used to store and retrieve the user input with DialogValues.
+func NewDialogSlot(id string, prompt string) *DialogSlot {
+	return &DialogSlot{
+		id: id,
+		promptFunc: func(_ *DialogValues) string {
+			return prompt
+		},
+	}
+}
+
+// PromptFunc sets a function that returns the prompt message.
+// Use this when the message depends on the previously collected values.
+func (slot *DialogSlot) PromptFunc(fnc func(*DialogValues) string) *DialogSlot {
+	slot.promptFunc = fnc
+	return slot
+}
+
+// Validator sets a function that validates the user input.
+// When the function returns an error, the user is asked to input again.
+func (slot *DialogSlot) Validator(fnc func(string) error) *DialogSlot {
+	slot.validator = fnc
+	return slot
+}
+
+// Parser sets a function that converts the user input to a value to be stored in DialogValues.
+// When the function returns an error, the user is asked to input again.
+// When no parser is set, the trimmed user input is stored as is.
+func (slot *DialogSlot) Parser(fnc func(string) (interface{}, error)) *DialogSlot {
+	slot.parser = fnc
+	return slot
+}
+
+// InvalidMessage sets the message to be sent when the user input is rejected by the Validator or Parser.
+// When this is not set, the returned error's message is sent instead.
+func (slot *DialogSlot) InvalidMessage(message string) *DialogSlot {
+	slot.invalidMessage = message
+	return slot
+}
+
+// SkipIf sets a function that judges if this slot can be skipped.
+// This is called with the values collected so far when this slot is about to be prompted.
+func (slot *DialogSlot) SkipIf(fnc func(*DialogValues) bool) *DialogSlot {
+	slot.skipFunc = fnc
+	return slot
+}
+
+// Branch sets a function that decides the next slot.
+// This is called right after this slot's value is stored, and the returned identifier is prompted next.
+// Returning empty string proceeds to the next slot in the declared order.
+// When the returned slot already has a value, the value is cleared and the slot is prompted again,
+// so a slot may return to the previous slot or to itself to let the user correct the input.
+// The slots after the returned one are still skipped when they have values.
+func (slot *DialogSlot) Branch(fnc func(*DialogValues) string) *DialogSlot {
+	slot.branchFunc = fnc
+	return slot
+}
+
+func (slot *DialogSlot) accept(message string) (interface{}, error) {
+	if slot.validator != nil {
+		if err := slot.validator(message); err != nil {
+			return nil, err
+		}
+	}
+
+	if slot.parser == nil {
+		return message, nil
+	}
+	return slot.parser(message)
+}
+
+// DialogResponseFunc defines a function that builds *CommandResponse from the given message and UserContext.
+// The default implementation returns the message as a plain string content,
+// while each Bot/Adapter developer may provide a function that builds adapter-specific content.
+//
+//  func(input sarah.Input, message string, userContext *sarah.UserContext) (*sarah.CommandResponse, error) {
+//    if userContext == nil {
+//      return slack.NewResponse(input, message)
+//    }
+//    return slack.NewResponse(input, message, slack.RespWithNext(userContext.Next))
+//  }
+type DialogResponseFunc func(input Input, message string, userContext *UserContext) (*CommandResponse, error)
+
+func defaultDialogResponse(_ Input, message string, userContext *UserContext) (*CommandResponse, error) {
+	return &CommandResponse{
+		Content:     message,
+		UserContext: userContext,
+	}, nil
+}
+
+// Dialog provides a declarative way to build a multi-step conversation.
+// Ordered DialogSlots are prompted one by one, and the given handler receives all collected values at the end.
+// Each step is stored as UserContext, so the UserContextStorage must be set to the Bot.
+//
+// The collected values live in the closure set to UserContext.Next instead of UserContext.Serializable,
+// so the conversation can not be serialized like the context created by NewSerializableUserContext.
+// Use the default storage created by NewUserContextStorage;
+// a storage that only persists serializable contexts such as those in the storages package rejects the conversation with ErrNonSerializableUserContext,
+// and the conversation does not survive process restarts.
+//
+// Use DialogBuilder to construct Dialog, and call Dialog.Start in a command function to start the conversation.
+//
+//  dialog := sarah.NewDialogBuilder().
+//    Slot(sarah.NewDialogSlot("description", "Please input a thing to do.")).
+//    Slot(sarah.NewDialogSlot("due", "Input the due date in YYYY-MM-DD format.").
+//      Parser(func(s string) (interface{}, error) { return time.Parse("2006-01-02", s) }).
+//      InvalidMessage("Please input a valid date in YYYY-MM-DD format.")).
+//    Confirm(func(values *sarah.DialogValues) string {
+//      return fmt.Sprintf("TODO: %s. Is this O.K.? Y/N", values.String("description"))
+//    }).
+//    Handler(func(ctx context.Context, input sarah.Input, values *sarah.DialogValues) (*sarah.CommandResponse, error) {
+//      return slack.NewResponse(input, "Saved.")
+//    }).
+//    MustBuild()
+type Dialog struct {
+	slots               []*DialogSlot
+	slotIndex           map[string]int
+	handler             func(context.Context, Input, *DialogValues) (*CommandResponse, error)
+	confirmFunc         func(*DialogValues) string
+	confirmWords        []string
+	declineWords        []string
+	abortWords          []string
+	abortMessage        string
+	timeout             time.Duration
+	timeoutMessage      string
+	responseFunc        DialogResponseFunc
+	confirmRetryMessage string
+}
+
+type dialogState struct {
+	values     *DialogValues
+	slot       int
+	confirming bool
+	deadline   time.Time
+}
+
+// Start starts the conversation and returns the response that prompts the first slot.
+func (dialog *Dialog) Start(ctx context.Context, input Input) (*CommandResponse, error) {
+	return dialog.StartWith(ctx, input, NewDialogValues())
+}
+
+// StartWith starts the conversation with pre-filled values.
+// Slots that already have corresponding values are skipped.
+// e.g. ".todo buy milk" may pre-fill the description and start the conversation from the due date.
+func (dialog *Dialog) StartWith(ctx context.Context, input Input, values *DialogValues) (*CommandResponse, error) {
+	state := &dialogState{
+		values: values.copy(),
+		slot:   0,
+	}
+	return dialog.proceed(ctx, input, state, 0)
+}
+
+func (dialog *Dialog) proceed(ctx context.Context, input Input, state *dialogState, from int) (*CommandResponse, error) {
+	for i := from; i < len(dialog.slots); i++ {
+		slot := dialog.slots[i]
+		if state.values.Has(slot.id) {
+			continue
+		}
+
+		if slot.skipFunc != nil && slot.skipFunc(state.values) {
+			continue
+		}
+
+		state.slot = i
+		return dialog.prompt(input, state, slot.promptFunc(state.values))
+	}
+
+	// All slots are filled.
+	if dialog.confirmFunc != nil {
+		state.confirming = true
+		return dialog.prompt(input, state, dialog.confirmFunc(state.values))
+	}
+
+	return dialog.handler(ctx, input, state.values.copy())
+}
+
+func (dialog *Dialog) prompt(input Input, state *dialogState, message string) (*CommandResponse, error) {
+	if dialog.timeout > 0 {
+		state.deadline = time.Now().Add(dialog.timeout)
+	}
+
+	next := func(c context.Context, i Input) (*CommandResponse, error) {
+		return dialog.receive(c, i, state)
+	}
+	userContext := NewUserContext(next)
+	if dialog.timeout > 0 {
+		userContext.ExpiresIn = dialog.timeout
+		if dialog.timeoutMessage != "" {
+			userContext.ExpirationMessage = dialog.timeoutMessage
+		}
+	}
+	return dialog.responseFunc(input, message, userContext)
+}
+
+func (dialog *Dialog) finish(input Input, message string) (*CommandResponse, error) {
+	if message == "" {
+		return nil, nil
+	}
+	return dialog.responseFunc(input, message, nil)
+}
+
+func (dialog *Dialog) receive(ctx context.Context, input Input, state *dialogState) (*CommandResponse, error) {
+	if _, ok := input.(*AbortInput); ok {
+		return dialog.finish(input, dialog.abortMessage)
+	}
+
+	if !state.deadline.IsZero() {
+		receivedAt := input.SentAt()
+		if receivedAt.IsZero() {
+			receivedAt = time.Now()
+		}
+		if receivedAt.After(state.deadline) {
+			return dialog.finish(input, dialog.timeoutMessage)
+		}
+	}
+
+	message := strings.TrimSpace(input.Message())
+	if containsWord(dialog.abortWords, message) {
+		return dialog.finish(input, dialog.abortMessage)
+	}
+
+	if state.confirming {
+		switch {
+		case containsWord(dialog.confirmWords, message):
+			return dialog.handler(ctx, input, state.values.copy())
+
+		case containsWord(dialog.declineWords, message):
+			return dialog.finish(input, dialog.abortMessage)
+
+		default:
+			return dialog.prompt(input, state, dialog.confirmRetryMessage)
+
+		}
+	}
+
+	slot := dialog.slots[state.slot]
+	value, err := slot.accept(message)
+	if err != nil {
+		invalid := slot.invalidMessage
+		if invalid == "" {
+			invalid = err.Error()
+		}
+		return dialog.prompt(input, state, invalid)
+	}
+	state.values.Set(slot.id, value)
+
+	next := state.slot + 1
+	if slot.branchFunc != nil {
+		if id := slot.branchFunc(state.values); id != "" {
+			i, ok := dialog.slotIndex[id]
+			if !ok {
+				return nil, fmt.Errorf("unknown slot %s is given by branch of %s", id, slot.id)
+			}
+			next = i
+			// Let the user input the value again.
+			state.values.remove(id)
+		}
+	}
+
+	return dialog.proceed(ctx, input, state, next)
+}
+
+func containsWord(words []string, message string) bool {
+	for _, w := range words {
+		if strings.EqualFold(w, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// DialogBuilder helps to construct Dialog.
+// Developer may set desired property as she goes and call DialogBuilder.Build or DialogBuilder.MustBuild to construct Dialog at the end.
+type DialogBuilder struct {
+	dialog *Dialog
+}
+
+// NewDialogBuilder creates and returns new DialogBuilder instance with default settings.
+func NewDialogBuilder() *DialogBuilder {
+	return &DialogBuilder{
+		dialog: &Dialog{
+			slots:               []*DialogSlot{},
+			slotIndex:           map[string]int{},
+			confirmWords:        []string{"y", "yes"},
+			declineWords:        []string{"n", "no"},
+			abortWords:          []string{},
+			abortMessage:        "Aborted.",
+			timeoutMessage:      "Timed out. Please start over.",
+			responseFunc:        defaultDialogResponse,
+			confirmRetryMessage: "Please input Y or N.",
+		},
+	}
+}
+
+// Slot appends the given DialogSlot.
+// Slots are prompted in the order of this method call unless DialogSlot.Branch or DialogSlot.SkipIf tells otherwise.
+func (builder *DialogBuilder) Slot(slot *DialogSlot) *DialogBuilder {
+	builder.dialog.slots = append(builder.dialog.slots, slot)
+	return builder
+}
+
+// Handler sets a function that is called with all collected values at the end of the conversation.
+func (builder *DialogBuilder) Handler(fnc func(context.Context, Input, *DialogValues) (*CommandResponse, error)) *DialogBuilder {
+	builder.dialog.handler = fnc
+	return builder
+}
+
+// Confirm sets a function that returns a summary message to be confirmed by the user after all slots are filled.
+// When the user accepts, the handler is called; when the user declines, the conversation is aborted.
+func (builder *DialogBuilder) Confirm(fnc func(*DialogValues) string) *DialogBuilder {
+	builder.dialog.confirmFunc = fnc
+	return builder
+}
+
+// ConfirmWords sets the words that accept and decline the confirmation.
+// Comparison is case-insensitive. The default values are "y"/"yes" and "n"/"no".
+func (builder *DialogBuilder) ConfirmWords(accept []string, decline []string) *DialogBuilder {
+	builder.dialog.confirmWords = accept
+	builder.dialog.declineWords = decline
+	return builder
+}
+
+// ConfirmRetryMessage sets the message to be sent when the user input is neither accepting nor declining the confirmation.
+func (builder *DialogBuilder) ConfirmRetryMessage(message string) *DialogBuilder {
+	builder.dialog.confirmRetryMessage = message
+	return builder
+}
+
+// AbortWords sets the words that abort the conversation in the middle.
+// Comparison is case-insensitive.
+// Bot/Adapter's AbortInput is also handled when it is passed to the dialog's UserContext.
+func (builder *DialogBuilder) AbortWords(words ...string) *DialogBuilder {
+	builder.dialog.abortWords = words
+	return builder
+}
+
+// AbortMessage sets the message to be sent when the conversation is aborted.
+// Set empty string to abort silently.
+func (builder *DialogBuilder) AbortMessage(message string) *DialogBuilder {
+	builder.dialog.abortMessage = message
+	return builder
+}
+
+// Timeout sets the duration the user is allowed to take for each input.
+// The stored UserContext expires after this duration, and the timeout message is sent to the user if the storage implements ExpirationNotifier.
+// When the user input still comes after the timeout, the conversation finishes with the timeout message.
+func (builder *DialogBuilder) Timeout(timeout time.Duration) *DialogBuilder {
+	builder.dialog.timeout = timeout
+	return builder
+}
+
+// TimeoutMessage sets the message to be sent when the conversation times out.
+// Set empty string to finish silently.
+func (builder *DialogBuilder) TimeoutMessage(message string) *DialogBuilder {
+	builder.dialog.timeoutMessage = message
+	return builder
+}
+
+// ResponseFunc sets a function that builds *CommandResponse for each prompt.
+// Use this to build adapter-specific response content. See DialogResponseFunc.
+func (builder *DialogBuilder) ResponseFunc(fnc DialogResponseFunc) *DialogBuilder {
+	builder.dialog.responseFunc = fnc
+	return builder
+}
+
+// Build builds new Dialog instance with provided values.
+func (builder *DialogBuilder) Build() (*Dialog, error) {
+	dialog := builder.dialog
+	if len(dialog.slots) == 0 || dialog.handler == nil || dialog.responseFunc == nil {
+		return nil, ErrDialogInsufficientArgument
+	}
+
+	index := map[string]int{}
+	for i, slot := range dialog.slots {
+		if slot == nil || slot.id == "" || slot.promptFunc == nil {
+			return nil, ErrDialogInsufficientArgument
+		}
+
+		if _, ok := index[slot.id]; ok {
+			return nil, ErrDialogDuplicatedSlot
+		}
+		index[slot.id] = i
+	}
+	dialog.slotIndex = index
+
+	return dialog, nil
+}
+
+// MustBuild is like Build, but panics if any error occurs on Build.
+// It simplifies safe initialization of global variables holding built Dialog instances.
+func (builder *DialogBuilder) MustBuild() *Dialog {
+	dialog, err := builder.Build()
+	if err != nil {
+		panic(fmt.Errorf("error on building Dialog: %w", err))
+	}
+
+	return dialog
+}
@@ -0,0 +1,375 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func buildTestDialog(t *testing.T, builder *DialogBuilder) *Dialog {
+	dialog, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	return dialog
+}
+
+func respondDialog(t *testing.T, res *CommandResponse, message string) *CommandResponse {
+	if res == nil || res.UserContext == nil {
+		t.Fatalf("UserContext is not returned: %#v.", res)
+	}
+
+	next, err := res.UserContext.Next(context.TODO(), &DummyInput{MessageValue: message})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	return next
+}
+
+func TestDialogValues(t *testing.T) {
+	values := NewDialogValues()
+	values.Set("foo", "bar")
+	values.Set("num", 1)
+	values.Set("foo", "baz")
+
+	if !values.Has("foo") {
+		t.Error("Stored value is not found.")
+	}
+
+	if v, ok := values.Get("num"); !ok || v != 1 {
+		t.Errorf("Unexpected value is returned: %#v.", v)
+	}
+
+	if values.String("foo") != "baz" {
+		t.Errorf("Unexpected string is returned: %s.", values.String("foo"))
+	}
+
+	if values.String("num") != "1" {
+		t.Errorf("Unexpected string is returned: %s.", values.String("num"))
+	}
+
+	if values.String("unknown") != "" {
+		t.Errorf("Empty string should return: %s.", values.String("unknown"))
+	}
+
+	keys := values.Keys()
+	if len(keys) != 2 || keys[0] != "foo" || keys[1] != "num" {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+}
+
+func TestDialogBuilder_Build(t *testing.T) {
+	handler := func(_ context.Context, _ Input, _ *DialogValues) (*CommandResponse, error) {
+		return nil, nil
+	}
+
+	tests := []struct {
+		builder *DialogBuilder
+		err     error
+	}{
+		{
+			builder: NewDialogBuilder().Handler(handler),
+			err:     ErrDialogInsufficientArgument,
+		},
+		{
+			builder: NewDialogBuilder().Slot(NewDialogSlot("foo", "Input foo.")),
+			err:     ErrDialogInsufficientArgument,
+		},
+		{
+			builder: NewDialogBuilder().Slot(NewDialogSlot("", "Input foo.")).Handler(handler),
+			err:     ErrDialogInsufficientArgument,
+		},
+		{
+			builder: NewDialogBuilder().
+				Slot(NewDialogSlot("foo", "Input foo.")).
+				Slot(NewDialogSlot("foo", "Input foo again.")).
+				Handler(handler),
+			err: ErrDialogDuplicatedSlot,
+		},
+		{
+			builder: NewDialogBuilder().Slot(NewDialogSlot("foo", "Input foo.")).Handler(handler),
+			err:     nil,
+		},
+	}
+
+	for i, tt := range tests {
+		_, err := tt.builder.Build()
+		if err != tt.err {
+			t.Errorf("Unexpected error is returned on test #%d: %#v.", i, err)
+		}
+	}
+}
+
+func TestDialogBuilder_MustBuild(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic did not occur.")
+		}
+	}()
+
+	NewDialogBuilder().MustBuild()
+}
+
+func TestDialog_Conversation(t *testing.T) {
+	var collected *DialogValues
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("name", "Input name.")).
+		Slot(NewDialogSlot("age", "Input age.").
+			Parser(func(s string) (interface{}, error) {
+				return strconv.Atoi(s)
+			}).
+			InvalidMessage("Input a number.")).
+		Confirm(func(values *DialogValues) string {
+			return "O.K.?"
+		}).
+		Handler(func(_ context.Context, _ Input, values *DialogValues) (*CommandResponse, error) {
+			collected = values
+			return &CommandResponse{Content: "Saved."}, nil
+		}))
+
+	res, err := dialog.Start(context.TODO(), &DummyInput{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if res.Content != "Input name." {
+		t.Errorf("Unexpected prompt is returned: %#v.", res.Content)
+	}
+
+	res = respondDialog(t, res, " Oklahomer ")
+	if res.Content != "Input age." {
+		t.Errorf("Unexpected prompt is returned: %#v.", res.Content)
+	}
+
+	res = respondDialog(t, res, "old")
+	if res.Content != "Input a number." {
+		t.Errorf("Unexpected re-prompt is returned: %#v.", res.Content)
+	}
+
+	res = respondDialog(t, res, "20")
+	if res.Content != "O.K.?" {
+		t.Errorf("Unexpected confirmation is returned: %#v.", res.Content)
+	}
+
+	res = respondDialog(t, res, "maybe")
+	if res.Content != "Please input Y or N." {
+		t.Errorf("Unexpected re-confirmation is returned: %#v.", res.Content)
+	}
+
+	res = respondDialog(t, res, "Y")
+	if res.Content != "Saved." {
+		t.Errorf("Unexpected final response is returned: %#v.", res.Content)
+	}
+	if res.UserContext != nil {
+		t.Error("UserContext should not be returned on finish.")
+	}
+
+	if collected.String("name") != "Oklahomer" {
+		t.Errorf("Unexpected name is stored: %s.", collected.String("name"))
+	}
+	if age, _ := collected.Get("age"); age != 20 {
+		t.Errorf("Unexpected age is stored: %#v.", age)
+	}
+}
+
+func TestDialog_StartWith(t *testing.T) {
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("description", "Input description.")).
+		Slot(NewDialogSlot("due", "Input due.")).
+		Handler(func(_ context.Context, _ Input, _ *DialogValues) (*CommandResponse, error) {
+			return nil, nil
+		}))
+
+	values := NewDialogValues()
+	values.Set("description", "buy milk")
+	res, err := dialog.StartWith(context.TODO(), &DummyInput{}, values)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if res.Content != "Input due." {
+		t.Errorf("Pre-filled slot is not skipped: %#v.", res.Content)
+	}
+}
+
+func TestDialog_BranchAndSkip(t *testing.T) {
+	var collected *DialogValues
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("kind", "Input kind.").
+			Branch(func(values *DialogValues) string {
+				if values.String("kind") == "private" {
+					return "reason"
+				}
+				return ""
+			})).
+		Slot(NewDialogSlot("channel", "Input channel.")).
+		Slot(NewDialogSlot("reason", "Input reason.").
+			SkipIf(func(values *DialogValues) bool {
+				return values.Has("channel")
+			})).
+		Handler(func(_ context.Context, _ Input, values *DialogValues) (*CommandResponse, error) {
+			collected = values
+			return nil, nil
+		}))
+
+	res, _ := dialog.Start(context.TODO(), &DummyInput{})
+	res = respondDialog(t, res, "private")
+	if res.Content != "Input reason." {
+		t.Fatalf("Branch is not applied: %#v.", res.Content)
+	}
+	_ = respondDialog(t, res, "secret")
+	if collected.Has("channel") {
+		t.Error("Channel should not be asked.")
+	}
+
+	res, _ = dialog.Start(context.TODO(), &DummyInput{})
+	res = respondDialog(t, res, "public")
+	if res.Content != "Input channel." {
+		t.Fatalf("Unexpected prompt is returned: %#v.", res.Content)
+	}
+	_ = respondDialog(t, res, "#general")
+	if collected.Has("reason") {
+		t.Error("Reason should be skipped.")
+	}
+}
+
+func TestDialog_BranchToFilledSlot(t *testing.T) {
+	var collected *DialogValues
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("date", "Input date.")).
+		Slot(NewDialogSlot("correct", "Is this correct?").
+			Branch(func(values *DialogValues) string {
+				if values.String("correct") == "no" {
+					return "date"
+				}
+				return ""
+			})).
+		Handler(func(_ context.Context, _ Input, values *DialogValues) (*CommandResponse, error) {
+			collected = values
+			return nil, nil
+		}))
+
+	res, _ := dialog.Start(context.TODO(), &DummyInput{})
+	res = respondDialog(t, res, "2020-01-01")
+	res = respondDialog(t, res, "no")
+	if res == nil || res.Content != "Input date." {
+		t.Fatalf("Filled slot is not prompted again: %#v.", res)
+	}
+
+	// The slot after the branched one is already filled, so the conversation ends.
+	_ = respondDialog(t, res, "2020-01-02")
+	if collected == nil {
+		t.Fatal("Handler is not called.")
+	}
+	if collected.String("date") != "2020-01-02" {
+		t.Errorf("Value is not updated: %s.", collected.String("date"))
+	}
+	keys := collected.Keys()
+	if len(keys) != 2 || keys[0] != "correct" || keys[1] != "date" {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+}
+
+func TestDialog_UnknownBranch(t *testing.T) {
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("foo", "Input foo.").
+			Branch(func(_ *DialogValues) string {
+				return "unknown"
+			})).
+		Handler(func(_ context.Context, _ Input, _ *DialogValues) (*CommandResponse, error) {
+			return nil, nil
+		}))
+
+	res, _ := dialog.Start(context.TODO(), &DummyInput{})
+	_, err := res.UserContext.Next(context.TODO(), &DummyInput{MessageValue: "foo"})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestDialog_Abort(t *testing.T) {
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("foo", "Input foo.").
+			Validator(func(_ string) error {
+				return errors.New("invalid")
+			})).
+		AbortWords("cancel").
+		AbortMessage("Canceled.").
+		Handler(func(_ context.Context, _ Input, _ *DialogValues) (*CommandResponse, error) {
+			t.Error("Handler should not be called.")
+			return nil, nil
+		}))
+
+	res, _ := dialog.Start(context.TODO(), &DummyInput{})
+	res = respondDialog(t, res, "foo")
+	if res.Content != "invalid" {
+		t.Errorf("Validation error message is not returned: %#v.", res.Content)
+	}
+
+	aborted := respondDialog(t, res, "CANCEL")
+	if aborted.Content != "Canceled." || aborted.UserContext != nil {
+		t.Errorf("Unexpected response is returned: %#v.", aborted)
+	}
+
+	aborted, _ = res.UserContext.Next(context.TODO(), NewAbortInput(&DummyInput{}))
+	if aborted.Content != "Canceled." || aborted.UserContext != nil {
+		t.Errorf("Unexpected response is returned: %#v.", aborted)
+	}
+}
+
+func TestDialog_Timeout(t *testing.T) {
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("foo", "Input foo.")).
+		Timeout(time.Minute).
+		TimeoutMessage("Timed out.").
+		Handler(func(_ context.Context, _ Input, _ *DialogValues) (*CommandResponse, error) {
+			t.Error("Handler should not be called.")
+			return nil, nil
+		}))
+
+	res, _ := dialog.Start(context.TODO(), &DummyInput{})
+	if res.UserContext.ExpiresIn != time.Minute {
+		t.Errorf("Timeout is not set as UserContext.ExpiresIn: %s.", res.UserContext.ExpiresIn)
+	}
+	if res.UserContext.ExpirationMessage != "Timed out." {
+		t.Errorf("Timeout message is not set as UserContext.ExpirationMessage: %#v.", res.UserContext.ExpirationMessage)
+	}
+
+	input := &DummyInput{
+		MessageValue: "foo",
+		SentAtValue:  time.Now().Add(2 * time.Minute),
+	}
+	timedOut, err := res.UserContext.Next(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if timedOut.Content != "Timed out." || timedOut.UserContext != nil {
+		t.Errorf("Unexpected response is returned: %#v.", timedOut)
+	}
+}
+
+func TestDialog_ResponseFunc(t *testing.T) {
+	type customContent struct {
+		text string
+	}
+	dialog := buildTestDialog(t, NewDialogBuilder().
+		Slot(NewDialogSlot("foo", "Input foo.")).
+		ResponseFunc(func(_ Input, message string, userContext *UserContext) (*CommandResponse, error) {
+			return &CommandResponse{
+				Content:     &customContent{text: message},
+				UserContext: userContext,
+			}, nil
+		}).
+		Handler(func(_ context.Context, _ Input, _ *DialogValues) (*CommandResponse, error) {
+			return nil, nil
+		}))
+
+	res, _ := dialog.Start(context.TODO(), &DummyInput{})
+	content, ok := res.Content.(*customContent)
+	if !ok {
+		t.Fatalf("Unexpected content is returned: %#v.", res.Content)
+	}
+	if content.text != "Input foo." {
+		t.Errorf("Unexpected text is set: %s.", content.text)
+	}
+}
@@ -0,0 +1,213 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var taskEvents = &eventRegistry{}
+
+// Event represents a named internal event that triggers the subscribing tasks.
+// Subscribe to an event with ScheduledTaskPropsBuilder.OnEvent, and publish the event with PublishEvent.
+type Event struct {
+	// Name is the name of the event such as "deploy_finished."
+	Name string
+
+	// Payload is the arbitrary value given by the publisher.
+	Payload interface{}
+
+	// PublishedAt is the time the event is published.
+	PublishedAt time.Time
+}
+
+type eventKey struct{}
+
+// WithEvent returns a copy of the given context that carries the Event.
+// go-sarah's core sets this to the context passed to ScheduledTask.Execute when the execution is triggered by an event.
+func WithEvent(ctx context.Context, event *Event) context.Context {
+	return context.WithValue(ctx, eventKey{}, event)
+}
+
+// EventFromContext returns the Event that triggered the current execution.
+// The second returned value is false when the execution is triggered by the schedule or by TriggerTask.
+func EventFromContext(ctx context.Context) (*Event, bool) {
+	event, ok := ctx.Value(eventKey{}).(*Event)
+	return event, ok
+}
+
+// EventTriggeredTask is an optional interface that ScheduledTask implementation may satisfy to be executed on named events.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the values given to ScheduledTaskPropsBuilder.OnEvent.
+// Such a task may omit its schedule to be executed only on the events.
+type EventTriggeredTask interface {
+	// Events returns the names of the events that trigger the task.
+	Events() []string
+}
+
+func taskEventNames(task ScheduledTask) []string {
+	if triggered, ok := task.(EventTriggeredTask); ok {
+		return triggered.Events()
+	}
+	return nil
+}
+
+// PublishEvent publishes the named event with the given payload to the subscribing tasks of all running Bots.
+// Each subscribing task is executed asynchronously in the same way as the scheduled execution except that TaskLocker is not involved,
+// and the task receives the event via EventFromContext.
+// This returns the number of the tasks that the event is delivered to.
+//
+//  sarah.PublishEvent("deploy_finished", &DeploySummary{Version: "v1.2.3"})
+func PublishEvent(name string, payload interface{}) int {
+	event := &Event{
+		Name:        name,
+		Payload:     payload,
+		PublishedAt: time.Now(),
+	}
+
+	subscribers := taskEvents.find(name)
+	for _, fn := range subscribers {
+		go fn(event)
+	}
+	return len(subscribers)
+}
+
+// NewEventHandler creates and returns http.Handler that publishes an event via PublishEvent.
+// The event name is given by the last path segment and the request body is passed as the payload of json.RawMessage,
+// so an external system such as a CI pipeline can publish an event as below:
+//
+//  curl -XPOST -d '{"version": "v1.2.3"}' http://localhost:8080/events/deploy_finished
+//
+// Since this handler affects the bot's behavior, authorize must judge if the request is allowed to publish events.
+func NewEventHandler(authorize func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if authorize == nil || !authorize(request) {
+			writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		name := request.URL.Path[strings.LastIndex(request.URL.Path, "/")+1:]
+		if name == "" {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		body, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			log.Errorf("Failed to read event payload: %+v", err)
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var payload json.RawMessage
+		if len(body) > 0 {
+			if !json.Valid(body) {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			payload = body
+		}
+
+		delivered := PublishEvent(name, payload)
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(writer).Encode(map[string]int{"delivered": delivered})
+	})
+}
+
+type eventSubscription struct {
+	botType BotType
+	taskID  string
+	fn      func(*Event)
+}
+
+// eventRegistry holds the tasks that subscribe to events.
+type eventRegistry struct {
+	subscriptions map[string][]*eventSubscription
+	mutex         sync.RWMutex
+}
+
+// subscribe registers the given function for the events.
+// The subscriptions previously registered for the same task are replaced.
+func (r *eventRegistry) subscribe(botType BotType, taskID string, names []string, fn func(*Event)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.unsubscribeTask(botType, taskID)
+
+	if r.subscriptions == nil {
+		r.subscriptions = map[string][]*eventSubscription{}
+	}
+	for _, name := range names {
+		r.subscriptions[name] = append(r.subscriptions[name], &eventSubscription{
+			botType: botType,
+			taskID:  taskID,
+			fn:      fn,
+		})
+	}
+}
+
+// unsubscribe removes the subscriptions of the given task.
+func (r *eventRegistry) unsubscribe(botType BotType, taskID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.unsubscribeTask(botType, taskID)
+}
+
+// removeBot removes the subscriptions of the given Bot's tasks.
+func (r *eventRegistry) removeBot(botType BotType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.filter(func(s *eventSubscription) bool {
+		return s.botType != botType
+	})
+}
+
+// unsubscribeTask removes the subscriptions of the given task.
+// The caller must hold the lock.
+func (r *eventRegistry) unsubscribeTask(botType BotType, taskID string) {
+	r.filter(func(s *eventSubscription) bool {
+		return s.botType != botType || s.taskID != taskID
+	})
+}
+
+// filter keeps the subscriptions that satisfy the given condition.
+// The caller must hold the lock.
+func (r *eventRegistry) filter(keep func(*eventSubscription) bool) {
+	for name, subscriptions := range r.subscriptions {
+		var kept []*eventSubscription
+		for _, s := range subscriptions {
+			if keep(s) {
+				kept = append(kept, s)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(r.subscriptions, name)
+			continue
+		}
+		r.subscriptions[name] = kept
+	}
+}
+
+func (r *eventRegistry) find(name string) []func(*Event) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var fns []func(*Event)
+	for _, s := range r.subscriptions[name] {
+		fns = append(fns, s.fn)
+	}
+	return fns
+}
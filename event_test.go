@@ -0,0 +1,239 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventFromContext(t *testing.T) {
+	if _, ok := EventFromContext(context.TODO()); ok {
+		t.Error("Event should not be found.")
+	}
+
+	event := &Event{Name: "deployed"}
+	given, ok := EventFromContext(WithEvent(context.TODO(), event))
+	if !ok {
+		t.Fatal("Event is not found.")
+	}
+	if given != event {
+		t.Errorf("Unexpected event is returned: %#v.", given)
+	}
+}
+
+func Test_eventRegistry(t *testing.T) {
+	registry := &eventRegistry{}
+	fn := func(_ *Event) {}
+	registry.subscribe("dummy", "a", []string{"deployed", "released"}, fn)
+	registry.subscribe("dummy", "b", []string{"deployed"}, fn)
+	registry.subscribe("other", "a", []string{"deployed"}, fn)
+
+	testSets := []struct {
+		remove   func()
+		name     string
+		expected int
+	}{
+		{
+			remove:   func() {},
+			name:     "deployed",
+			expected: 3,
+		},
+		{
+			// Subscribing again replaces the previous subscriptions.
+			remove: func() {
+				registry.subscribe("dummy", "a", []string{"deployed"}, fn)
+			},
+			name:     "released",
+			expected: 0,
+		},
+		{
+			remove: func() {
+				registry.unsubscribe("dummy", "b")
+			},
+			name:     "deployed",
+			expected: 2,
+		},
+		{
+			remove: func() {
+				registry.removeBot("dummy")
+			},
+			name:     "deployed",
+			expected: 1,
+		},
+	}
+
+	for i, testSet := range testSets {
+		testSet.remove()
+		if found := len(registry.find(testSet.name)); found != testSet.expected {
+			t.Errorf("Unexpected number of subscriptions are found on test #%d: %d.", i, found)
+		}
+	}
+}
+
+func TestPublishEvent(t *testing.T) {
+	SetupAndRun(func() {
+		received := make(chan *Event, 1)
+		taskEvents.subscribe("dummy", "task", []string{"deployed"}, func(event *Event) {
+			received <- event
+		})
+
+		if delivered := PublishEvent("unknown", nil); delivered != 0 {
+			t.Errorf("Unexpected number of deliveries: %d.", delivered)
+		}
+
+		if delivered := PublishEvent("deployed", "v1.2.3"); delivered != 1 {
+			t.Errorf("Unexpected number of deliveries: %d.", delivered)
+		}
+
+		select {
+		case event := <-received:
+			if event.Name != "deployed" || event.Payload != "v1.2.3" || event.PublishedAt.IsZero() {
+				t.Errorf("Unexpected event is delivered: %#v.", event)
+			}
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Error("Event is not delivered.")
+
+		}
+	})
+}
+
+func Test_runner_scheduleTask_WithEvent(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		received := make(chan *Event, 1)
+		task := &scheduledTask{
+			identifier: "deploy_report",
+			taskFunc: func(ctx context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				event, _ := EventFromContext(ctx)
+				received <- event
+				return nil, nil
+			},
+			events: []string{"deployed"},
+		}
+		r := &runner{
+			config:   &Config{TaskHistorySize: 3},
+			alerters: &alerters{},
+			scheduler: &DummyScheduler{
+				UpdateFunc: func(_ BotType, _ ScheduledTask, _ *time.Location, _ func()) error {
+					return errors.New("task without schedule must not be scheduled")
+				},
+			},
+			taskLocker: &DummyTaskLocker{
+				TryLockFunc: func(_ context.Context, _ string, _ time.Duration) (bool, error) {
+					return false, nil
+				},
+			},
+		}
+
+		err := r.scheduleTask(context.TODO(), &DummyBot{BotTypeValue: botType}, task)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		PublishEvent("deployed", "v1.2.3")
+
+		select {
+		case event := <-received:
+			if event == nil || event.Payload != "v1.2.3" {
+				t.Errorf("Unexpected event is given: %#v.", event)
+			}
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("Task is not executed on event.")
+
+		}
+
+		// Wait until the execution is recorded as well as the scheduled execution.
+		history := runnerStatus.taskHistory(botType, task.identifier, 3)
+		for i := 0; len(history.snapshot().Executions) == 0; i++ {
+			if i == 100 {
+				t.Fatal("Execution is not recorded.")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestNewEventHandler(t *testing.T) {
+	SetupAndRun(func() {
+		received := make(chan *Event, 1)
+		taskEvents.subscribe("dummy", "task", []string{"deployed"}, func(event *Event) {
+			received <- event
+		})
+		handler := NewEventHandler(func(req *http.Request) bool {
+			return req.Header.Get("Authorization") == "secret"
+		})
+
+		testSets := []struct {
+			method     string
+			path       string
+			authorized bool
+			body       string
+			status     int
+		}{
+			{
+				method:     http.MethodGet,
+				path:       "/events/deployed",
+				authorized: true,
+				status:     http.StatusMethodNotAllowed,
+			},
+			{
+				method:     http.MethodPost,
+				path:       "/events/deployed",
+				authorized: false,
+				status:     http.StatusForbidden,
+			},
+			{
+				method:     http.MethodPost,
+				path:       "/events/",
+				authorized: true,
+				status:     http.StatusNotFound,
+			},
+			{
+				method:     http.MethodPost,
+				path:       "/events/deployed",
+				authorized: true,
+				body:       "{invalid",
+				status:     http.StatusBadRequest,
+			},
+			{
+				method:     http.MethodPost,
+				path:       "/events/deployed",
+				authorized: true,
+				body:       `{"version":"v1.2.3"}`,
+				status:     http.StatusAccepted,
+			},
+		}
+
+		for i, testSet := range testSets {
+			req := httptest.NewRequest(testSet.method, testSet.path, strings.NewReader(testSet.body))
+			if testSet.authorized {
+				req.Header.Set("Authorization", "secret")
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != testSet.status {
+				t.Errorf("Unexpected status is returned on test #%d: %d.", i, recorder.Code)
+			}
+		}
+
+		select {
+		case event := <-received:
+			payload, ok := event.Payload.(json.RawMessage)
+			if !ok || string(payload) != `{"version":"v1.2.3"}` {
+				t.Errorf("Unexpected payload is given: %#v.", event.Payload)
+			}
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Error("Event is not published.")
+
+		}
+	})
+}
@@ -14,6 +14,7 @@ import (
 	_ "github.com/oklahomer/go-sarah/v3/examples/simple/plugins/guess"
 	_ "github.com/oklahomer/go-sarah/v3/examples/simple/plugins/hello"
 	_ "github.com/oklahomer/go-sarah/v3/examples/simple/plugins/morning"
+	_ "github.com/oklahomer/go-sarah/v3/examples/simple/plugins/remind"
 	_ "github.com/oklahomer/go-sarah/v3/examples/simple/plugins/timer"
 	"github.com/oklahomer/go-sarah/v3/examples/simple/plugins/todo"
 	"github.com/oklahomer/go-sarah/v3/log"
@@ -64,8 +65,13 @@ func main() {
 	// Setup Slack Bot.
 	setupSlack(config.Slack, storage)
 
+	// Setup Brain that plugins use to store their state.
+	// Replace this with the one given by storages.NewFileBrain to keep the state across restarts.
+	brain := sarah.NewMemoryBrain()
+	sarah.RegisterBrain(brain)
+
 	// Setup some commands.
-	todoCmd := todo.BuildCommand(&todo.DummyStorage{})
+	todoCmd := todo.BuildCommand(brain)
 	sarah.RegisterCommand(slack.SLACK, todoCmd)
 
 	// Directly add Command to Bot.
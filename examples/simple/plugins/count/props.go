@@ -1,20 +1,22 @@
 /*
 Package count provides example code to setup sarah.CommandProps.
 
-One counter instance is shared between two CommandPropsBuilder.Func,
-which means resulting Slack/Gitter Commands access to same counter instance.
-This illustrates that, when multiple Bots are registered to Runner, same memory space can be shared.
+The count is stored in sarah.Brain via sarah.PluginBrain that each Command receives from its context.
+Because sarah.PluginBrain is bound to the namespace of sarah.BotType and the command identifier,
+Slack and Gitter Commands keep their own counts even though both share the identifier.
+Register a persistent implementation such as the one given by storages.NewFileBrain with sarah.RegisterBrain
+so the counts survive process restarts.
 */
 package count
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/oklahomer/go-sarah/v3"
 	"github.com/oklahomer/go-sarah/v3/gitter"
 	"github.com/oklahomer/go-sarah/v3/slack"
 	"regexp"
-	"sync"
 )
 
 func init() {
@@ -22,22 +24,13 @@ func init() {
 	sarah.RegisterCommandProps(GitterProps)
 }
 
-type counter struct {
-	count uint
-	mutex *sync.Mutex
-}
-
-func (c *counter) increment() uint {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	c.count++
-	return c.count
-}
-
-// globalCounter is a counter instance that is shared by both Slack command and Gitter command.
-var globalCounter = &counter{
-	count: 0,
-	mutex: &sync.Mutex{},
+// increment counts up the value stored in the Brain of the running Command.
+func increment(ctx context.Context) (int64, error) {
+	brain, ok := sarah.BrainFromContext(ctx)
+	if !ok {
+		return 0, errors.New("brain is not available")
+	}
+	return brain.Increment(ctx, "count", 1)
 }
 
 // SlackProps is a pre-built count command properties for Slack.
@@ -46,18 +39,26 @@ var SlackProps = sarah.NewCommandPropsBuilder().
 	Identifier("counter").
 	Instruction("Input .count to count up").
 	MatchPattern(regexp.MustCompile(`^\.count`)).
-	Func(func(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
-		return slack.NewResponse(input, fmt.Sprint(globalCounter.increment()))
+	Func(func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+		cnt, err := increment(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count up: %w", err)
+		}
+		return slack.NewResponse(input, fmt.Sprint(cnt))
 	}).
 	MustBuild()
 
-// GitterProps is a pre-built count command properties for Slack.
+// GitterProps is a pre-built count command properties for Gitter.
 var GitterProps = sarah.NewCommandPropsBuilder().
 	BotType(gitter.GITTER).
 	Identifier("counter").
 	Instruction("Input .count to count up").
 	MatchPattern(regexp.MustCompile(`^\.count`)).
-	Func(func(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
-		return gitter.NewResponse(fmt.Sprint(globalCounter.increment()))
+	Func(func(ctx context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+		cnt, err := increment(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count up: %w", err)
+		}
+		return gitter.NewResponse(fmt.Sprint(cnt))
 	}).
 	MustBuild()
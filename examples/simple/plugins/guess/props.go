@@ -2,8 +2,9 @@
 Package guess provides example code to setup stateful command.
 
 This command returns sarah.UserContext as part of sarah.CommandResponse until user inputs correct number.
-As long as sarah.UserContext is returned, the next input from the same user is fed to the function defined in sarah.UserContext.
-When user guesses right number or input .abort, the context is removed and user is free to input next desired command.
+As long as sarah.UserContext is returned, the next input in the same channel is fed to the function defined in sarah.UserContext.
+Because the command is scoped with sarah.ScopePerChannel, everyone in the channel can join the game.
+When someone guesses right number or input .abort, the context is removed and users are free to input next desired command.
 
 This example uses in-memory storage to store user context.
 See https://github.com/oklahomer/go-sarah-rediscontext to use external storage.
@@ -29,6 +30,7 @@ var SlackProps = sarah.NewCommandPropsBuilder().
 	BotType(slack.SLACK).
 	Identifier("guess").
 	Instruction("Input .guess to start a game.").
+	Scope(sarah.ScopePerChannel).
 	MatchFunc(func(input sarah.Input) bool {
 		return strings.HasPrefix(strings.TrimSpace(input.Message()), ".guess")
 	}).
@@ -0,0 +1,101 @@
+/*
+Package remind provides example code to schedule one-time jobs from inside a command.
+
+Reminders are stored with the Brain registered via sarah.RegisterBrain.
+Register a persistent Brain such as storages.NewFileBrain so reminders survive restarts.
+*/
+package remind
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3"
+	"github.com/oklahomer/go-sarah/v3/slack"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func init() {
+	sarah.RegisterCommandProps(SlackProps)
+}
+
+// pattern matches the bare remind command or one of its sub-commands, and each alternative captures the arguments of the sub-command.
+var pattern = regexp.MustCompile(`^\.remind(?:\s+(?:me\s+in\s+(?P<delay>\S+)\s+to\s+(?P<content>.+)|(?P<list>list)|cancel\s+(?P<id>\S+)))?\s*$`)
+
+// SlackProps is a pre-built remind command properties for Slack.
+var SlackProps = sarah.NewCommandPropsBuilder().
+	BotType(slack.SLACK).
+	Identifier("remind").
+	Instruction(`Input ".remind me in 2h to check the deploy", ".remind list" or ".remind cancel {id}"`).
+	MatchPattern(pattern).
+	FuncWithMatch(func(ctx context.Context, input sarah.Input, match *sarah.PatternMatch) (*sarah.CommandResponse, error) {
+		switch {
+		case match.Get("list") != "":
+			return list(ctx, input)
+
+		case match.Get("id") != "":
+			return cancel(ctx, input, match.Get("id"))
+
+		case match.Get("delay") != "":
+			return remind(ctx, input, match.Get("delay"), strings.TrimSpace(match.Get("content")))
+
+		default:
+			return slack.NewResponse(input, `Input ".remind me in 2h to check the deploy", ".remind list" or ".remind cancel {id}".`)
+
+		}
+	}).
+	MustBuild()
+
+func remind(ctx context.Context, input sarah.Input, delay string, content string) (*sarah.CommandResponse, error) {
+	d, err := time.ParseDuration(delay)
+	if err != nil || d <= 0 {
+		return slack.NewResponse(input, fmt.Sprintf("%s is not a valid duration. e.g. 30m, 2h.", delay))
+	}
+
+	job := sarah.NewDelayedJob(input, d, fmt.Sprintf("Reminder: %s", content))
+	err = sarah.ScheduleJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return slack.NewResponse(input, fmt.Sprintf("I will remind you at %s. ID: %s.", job.RunAt.Format(time.RFC1123), job.ID))
+}
+
+func list(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	jobs, err := sarah.ListJobs(ctx, input.SenderKey())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return slack.NewResponse(input, "No reminder is scheduled.")
+	}
+
+	var lines []string
+	for _, job := range jobs {
+		lines = append(lines, fmt.Sprintf("%s: %s at %s", job.ID, job.Content, job.RunAt.Format(time.RFC1123)))
+	}
+	return slack.NewResponse(input, strings.Join(lines, "\n"))
+}
+
+func cancel(ctx context.Context, input sarah.Input, id string) (*sarah.CommandResponse, error) {
+	jobs, err := sarah.ListJobs(ctx, input.SenderKey())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		if job.ID != id {
+			continue
+		}
+
+		err := sarah.CancelJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return slack.NewResponse(input, fmt.Sprintf("Canceled %s.", id))
+	}
+
+	return slack.NewResponse(input, fmt.Sprintf("No reminder is found with ID %s.", id))
+}
@@ -1,16 +1,18 @@
 /*
 Package todo is an example of stateful command that let users input required arguments step by step in a conversational manner.
 
-On each valid input, given argument is stashed to *args.
-*args is passed around until all required arguments are filled.
+The conversation is declared with sarah.Dialog.
+Each slot is prompted in order, re-prompted on invalid input, and all collected values are passed to the final handler
+after the user confirms the summary.
+Confirmed TODOs are stored in sarah.Brain.
 */
 package todo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/oklahomer/go-sarah/v3"
-	"github.com/oklahomer/go-sarah/v3/log"
 	"github.com/oklahomer/go-sarah/v3/slack"
 	"regexp"
 	"strings"
@@ -19,29 +21,66 @@ import (
 
 var matchPattern = regexp.MustCompile(`^\.todo`)
 
-// DummyStorage is an empty struct that represents a permanent storage.
-type DummyStorage struct {
+// todo represents a thing to do that is stored in sarah.Brain.
+type todo struct {
+	Description string    `json:"description"`
+	Due         time.Time `json:"due"`
 }
 
-// Save saves given todo settings to permanent storage.
-func (s *DummyStorage) Save(senderKey string, description string, due time.Time) {
-	// Write to storage
-}
-
-type args struct {
-	description string
-	due         time.Time
-}
-
-// BuildCommand builds todo command with given storage.
-func BuildCommand(storage *DummyStorage) sarah.Command {
-	return &command{
-		storage: storage,
-	}
+// BuildCommand builds todo command with given brain.
+// Pass the same sarah.Brain that is registered with sarah.RegisterBrain so the TODOs are stored in the same namespace
+// that sarah.BrainFromContext provides to this command.
+//
+// The final handler of the conversation receives the context of the sarah.ContextualFunc instead of the command's,
+// which does not carry sarah.PluginBrain. This is why the command holds its own sarah.PluginBrain.
+func BuildCommand(brain sarah.Brain) sarah.Command {
+	cmd := &command{
+		brain: sarah.NewPluginBrain(brain, slack.SLACK, "todo"),
+	}
+	cmd.dialog = sarah.NewDialogBuilder().
+		Slot(sarah.NewDialogSlot("description", "Please input a thing to do.").
+			Validator(func(s string) error {
+				if s == "" {
+					return errors.New("empty description")
+				}
+				return nil
+			}).
+			InvalidMessage("Please input a thing to do.")).
+		Slot(sarah.NewDialogSlot("date", "Input the due date in YYYY-MM-DD format.").
+			Validator(func(s string) error {
+				_, err := time.Parse("2006-01-02", s)
+				return err
+			}).
+			InvalidMessage("Please input valid date in YYYY-MM-DD format.")).
+		Slot(sarah.NewDialogSlot("due", "Input the due time in HH:MM format. N if not specified.").
+			Parser(func(s string) (interface{}, error) {
+				// Actual parsing is done with the date in the handler, so only validate the format here.
+				if strings.ToLower(s) == "n" {
+					// If there is no due time, consider the last minute is the due time.
+					return "23:59", nil
+				}
+				if _, err := time.Parse("15:04", s); err != nil {
+					return nil, err
+				}
+				return s, nil
+			}).
+			InvalidMessage("Please input a valid due time in HH:MM format.")).
+		Confirm(func(values *sarah.DialogValues) string {
+			return fmt.Sprintf("TODO: %s. Due is %s %s\nIs this O.K.? Y/N", values.String("description"), values.String("date"), values.String("due"))
+		}).
+		AbortMessage("Aborted.").
+		Timeout(3 * time.Minute).
+		TimeoutMessage("Your .todo session timed out.").
+		ResponseFunc(respond).
+		Handler(cmd.save).
+		MustBuild()
+
+	return cmd
 }
 
 type command struct {
-	storage *DummyStorage
+	brain  *sarah.PluginBrain
+	dialog *sarah.Dialog
 }
 
 var _ sarah.Command = (*command)(nil)
@@ -50,21 +89,14 @@ func (cmd *command) Identifier() string {
 	return "todo"
 }
 
-func (cmd *command) Execute(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
-	stripped := sarah.StripMessage(matchPattern, input.Message())
-	if stripped == "" {
-		// If description is not given, let user proceed to input one.
-		return slack.NewResponse(input, "Please input a thing to do.", slack.RespWithNext(cmd.inputDesc))
+func (cmd *command) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	values := sarah.NewDialogValues()
+	if stripped := sarah.StripMessage(matchPattern, input.Message()); stripped != "" {
+		// If description is given along with the command, let user proceed to input the due date.
+		values.Set("description", stripped)
 	}
 
-	args := &args{
-		description: stripped,
-	}
-	next := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-		return cmd.inputDate(c, i, args)
-	}
-
-	return slack.NewResponse(input, "Please input the due date in YYYY-MM-DD format.", slack.RespWithNext(next))
+	return cmd.dialog.StartWith(ctx, input, values)
 }
 
 func (cmd *command) Instruction(_ *sarah.HelpInput) string {
@@ -75,100 +107,35 @@ func (cmd *command) Match(input sarah.Input) bool {
 	return strings.HasPrefix(strings.TrimSpace(input.Message()), ".todo")
 }
 
-func (cmd *command) inputDesc(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
-	description := strings.TrimSpace(input.Message())
-	if description == "" {
-		// If no description is provided, let user input.
-		next := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-			return cmd.inputDesc(c, i)
-		}
-		return slack.NewResponse(input, "Please input a thing to do.", slack.RespWithNext(next))
-	}
-
-	// Let user proceed to next step to input due date.
-	next := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-		args := &args{
-			description: description,
-		}
-		return cmd.inputDate(c, i, args)
-	}
-	return slack.NewResponse(input, "Input the due date in YYYY-MM-DD format.", slack.RespWithNext(next))
-}
-
-func (cmd *command) inputDate(_ context.Context, input sarah.Input, args *args) (*sarah.CommandResponse, error) {
-	date := strings.TrimSpace(input.Message())
-
-	reinput := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-		return cmd.inputDate(c, i, args)
-	}
-	if date == "" {
-		// If no due date is provided, let user input.
-		return slack.NewResponse(input, "Please input the due date in YYYY-MM-DD format.", slack.RespWithNext(reinput))
-	}
-
-	_, err := time.Parse("2006-01-02", date)
+func (cmd *command) save(ctx context.Context, input sarah.Input, values *sarah.DialogValues) (*sarah.CommandResponse, error) {
+	due, err := time.Parse("2006-01-02 15:04", fmt.Sprintf("%s %s", values.String("date"), values.String("due")))
 	if err != nil {
-		return slack.NewResponse(input, "Please input valid date in YYYY-MM-DD format.", slack.RespWithNext(reinput))
+		// Should not reach here since each input is validated.
+		return nil, fmt.Errorf("failed to parse due date: %w", err)
 	}
 
-	next := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-		return cmd.inputTime(c, i, date, args)
-	}
-	return slack.NewResponse(input, "Input the due time in HH:MM format. N if not specified.", slack.RespWithNext(next))
-}
-
-func (cmd *command) inputTime(_ context.Context, input sarah.Input, validDate string, args *args) (*sarah.CommandResponse, error) {
-	t := strings.TrimSpace(input.Message())
-
-	reinput := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-		return cmd.inputTime(c, i, validDate, args)
-	}
-	if t == "" {
-		return slack.NewResponse(input, "Please input the due time in HH:MM format.")
-	}
-
-	if strings.ToLower(t) == "n" {
-		// If there is no due time, consider the last minute is the due time.
-		t = "23:59"
-	}
-
-	_, err := time.Parse("15:04", t)
+	// Each user's TODOs are stored as a list with the user's key.
+	var todos []*todo
+	_, err = cmd.brain.GetJSON(ctx, input.SenderKey(), &todos)
 	if err != nil {
-		return slack.NewResponse(input, "Please input a valid due time in HH:MM format.", slack.RespWithNext(reinput))
+		return nil, fmt.Errorf("failed to read TODOs: %w", err)
 	}
 
-	due, err := time.Parse("2006-01-02 15:04", fmt.Sprintf("%s %s", validDate, t))
+	todos = append(todos, &todo{
+		Description: values.String("description"),
+		Due:         due,
+	})
+	err = cmd.brain.SetJSON(ctx, input.SenderKey(), todos, 0)
 	if err != nil {
-		// Should not reach here since previous time parse succeeded.
-		log.Error("Failed to parse due date: %+v", err)
-		return slack.NewResponse(input, "Fatal error occurred")
+		return nil, fmt.Errorf("failed to save TODO: %w", err)
 	}
 
-	args.due = due
-	next := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-		return cmd.confirm(c, i, args)
-	}
-	confirmMessage := fmt.Sprintf("TODO: %s. Due is %s\nIs this O.K.? Y/N", args.description, args.due.Format("2006-01-02 15:04"))
-	return slack.NewResponse(input, confirmMessage, slack.RespWithNext(next))
+	return slack.NewResponse(input, "Saved.")
 }
 
-func (cmd *command) confirm(_ context.Context, input sarah.Input, args *args) (*sarah.CommandResponse, error) {
-	msg := strings.TrimSpace(input.Message())
-	if msg != "" {
-		msg = strings.ToLower(msg)
-	}
-
-	if msg == "y" {
-		cmd.storage.Save(input.SenderKey(), args.description, args.due)
-		return slack.NewResponse(input, "Saved.")
-	}
-
-	if msg == "n" {
-		return slack.NewResponse(input, "Aborted.")
-	}
-
-	reinput := func(c context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
-		return cmd.confirm(c, i, args)
+func respond(input sarah.Input, message string, userContext *sarah.UserContext) (*sarah.CommandResponse, error) {
+	if userContext == nil {
+		return slack.NewResponse(input, message)
 	}
-	return slack.NewResponse(input, "Please input Y or N.", slack.RespWithNext(reinput))
+	return slack.NewResponse(input, message, slack.RespWithUserContext(userContext))
 }
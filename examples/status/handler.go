@@ -6,6 +6,7 @@ import (
 	"github.com/oklahomer/go-sarah/v3/log"
 	"net/http"
 	"runtime"
+	"time"
 )
 
 // setStatusHandler sets an endpoint that returns current status of go-sarah, its belonging sarah.Bot implementations and sarah.Worker.
@@ -41,6 +42,7 @@ import (
 //	  },
 //	  "bot_system": {
 //	    "running": true,
+//	    "task_queue_size": 0,
 //	    "bots": [
 //	      {
 //	        "type": "nullBot",
@@ -58,11 +60,30 @@ func setStatusHandler(mux *http.ServeMux, ws *workerStats) {
 		runnerStatus := sarah.CurrentStatus()
 		systemStatus := &botSystemStatus{}
 		systemStatus.Running = runnerStatus.Running
+		systemStatus.TaskQueueSize = runnerStatus.TaskQueueSize
 		for _, b := range runnerStatus.Bots {
 			bs := &botStatus{
 				BotType: b.Type,
 				Running: b.Running,
 			}
+			for _, t := range b.Tasks {
+				ts := &taskStatus{
+					Identifier:          t.Identifier,
+					ConsecutiveFailures: t.ConsecutiveFailures,
+					Skipped:             t.Skipped,
+					NextRun:             t.NextRun,
+					Paused:              t.Paused,
+				}
+				if len(t.Executions) > 0 {
+					last := t.Executions[len(t.Executions)-1]
+					ts.LastStartedAt = last.StartedAt
+					ts.LastDuration = last.Duration.String()
+					if last.Err != nil {
+						ts.LastError = last.Err.Error()
+					}
+				}
+				bs.Tasks = append(bs.Tasks, ts)
+			}
 			systemStatus.Bots = append(systemStatus.Bots, bs)
 		}
 
@@ -103,9 +124,22 @@ type runtimeStatus struct {
 type botStatus struct {
 	BotType sarah.BotType `json:"type"`
 	Running bool          `json:"running"`
+	Tasks   []*taskStatus `json:"tasks,omitempty"`
+}
+
+type taskStatus struct {
+	Identifier          string    `json:"id"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Skipped             int       `json:"skipped"`
+	NextRun             time.Time `json:"next_run,omitempty"`
+	Paused              bool      `json:"paused"`
+	LastStartedAt       time.Time `json:"last_started_at,omitempty"`
+	LastDuration        string    `json:"last_duration,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
 }
 
 type botSystemStatus struct {
-	Running bool         `json:"running"`
-	Bots    []*botStatus `json:"bots"`
+	Running       bool         `json:"running"`
+	TaskQueueSize int          `json:"task_queue_size"`
+	Bots          []*botStatus `json:"bots"`
 }
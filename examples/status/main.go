@@ -49,7 +49,7 @@ func main() {
 	// Setup worker
 	workerReporter := &workerStats{}
 	reporterOpt := workers.WithReporter(workerReporter)
-	worker, err := workers.Run(ctx, cfg.Worker, reporterOpt)
+	worker, err := workers.Run(ctx, cfg.Worker, reporterOpt, sarah.WithTaskQueueGauge())
 	if err != nil {
 		panic(err)
 	}
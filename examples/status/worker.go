@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"github.com/oklahomer/go-sarah/v3"
 	"github.com/oklahomer/go-sarah/v3/workers"
 	"sync"
 	"time"
@@ -20,8 +21,10 @@ func (ws *workerStats) Report(_ context.Context, s *workers.Stats) {
 	}
 
 	val := workerStatsElem{
-		ReportTime: time.Now(),
-		QueueSize:  s.QueueSize,
+		ReportTime:    time.Now(),
+		QueueSize:     s.QueueSize,
+		WorkerNum:     s.WorkerNum,
+		TaskQueueSize: s.QueueGauges[sarah.TaskQueueGaugeName],
 	}
 
 	*ws = append(*ws, val)
@@ -34,6 +37,8 @@ func (ws *workerStats) history() []workerStatsElem {
 }
 
 type workerStatsElem struct {
-	ReportTime time.Time `json:"report_time"`
-	QueueSize  int       `json:"queue_size"`
+	ReportTime    time.Time `json:"report_time"`
+	QueueSize     int       `json:"queue_size"`
+	WorkerNum     int       `json:"worker_num"`
+	TaskQueueSize int       `json:"task_queue_size"`
 }
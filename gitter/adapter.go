@@ -7,6 +7,7 @@ import (
 	"github.com/oklahomer/go-sarah/v3"
 	"github.com/oklahomer/go-sarah/v3/log"
 	"github.com/oklahomer/go-sarah/v3/retry"
+	"strings"
 )
 
 const (
@@ -14,6 +15,11 @@ const (
 	GITTER sarah.BotType = "gitter"
 )
 
+func init() {
+	// Let go-sarah's core restore the destinations of the stored jobs.
+	sarah.RegisterDestinationType(GITTER, &Room{})
+}
+
 // AdapterOption defines function signature that Adapter's functional option must satisfy.
 type AdapterOption func(adapter *Adapter)
 
@@ -63,22 +69,46 @@ func (adapter *Adapter) Run(ctx context.Context, enqueueInput func(sarah.Input)
 	}
 }
 
+var _ sarah.ErrorReportingSender = (*Adapter)(nil)
+
 // SendMessage let Bot send message to gitter.
+// The failure is logged; use SendMessageWithError to receive it.
 func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) {
+	err := adapter.SendMessageWithError(ctx, output)
+	if err != nil {
+		log.Errorf("Failed to send message: %+v", err)
+	}
+}
+
+// SendMessageWithError let Bot send message to gitter, and returns an error when the message is not sent.
+// This satisfies sarah.ErrorReportingSender so sarah.Broadcast can report the failure.
+func (adapter *Adapter) SendMessageWithError(ctx context.Context, output sarah.Output) error {
+	var text string
 	switch content := output.Content().(type) {
 	case string:
-		room, ok := output.Destination().(*Room)
-		if !ok {
-			log.Errorf("Destination is not instance of Room. %#v.", output.Destination())
-			return
+		text = content
+
+	case *sarah.Announcement:
+		text = content.Text
+		if content.Title != "" {
+			text = fmt.Sprintf("**%s**\n%s", content.Title, content.Text)
 		}
-		_, err := adapter.apiClient.PostMessage(ctx, room, content)
-		log.Errorf("Failed posting message to %s: %+v", room.ID, err)
 
 	default:
-		log.Warnf("Unexpected output %#v", output)
+		return fmt.Errorf("unexpected output: %#v", output)
 
 	}
+
+	room, ok := output.Destination().(*Room)
+	if !ok {
+		return fmt.Errorf("destination is not instance of Room: %#v", output.Destination())
+	}
+
+	_, err := adapter.apiClient.PostMessage(ctx, room, text)
+	if err != nil {
+		return fmt.Errorf("failed posting message to %s: %w", room.ID, err)
+	}
+	return nil
 }
 
 func (adapter *Adapter) runEachRoom(ctx context.Context, room *Room, enqueueInput func(sarah.Input) error) {
@@ -100,7 +130,7 @@ func (adapter *Adapter) runEachRoom(ctx context.Context, room *Room, enqueueInpu
 				return
 			}
 
-			connErr := receiveMessageRecursive(conn, enqueueInput)
+			connErr := receiveMessageRecursive(conn, adapter.config, enqueueInput)
 			_ = conn.Close()
 
 			// TODO: Intentional connection close such as context.cancel also comes here.
@@ -114,7 +144,7 @@ func (adapter *Adapter) runEachRoom(ctx context.Context, room *Room, enqueueInpu
 	}
 }
 
-func receiveMessageRecursive(messageReceiver MessageReceiver, enqueueInput func(sarah.Input) error) error {
+func receiveMessageRecursive(messageReceiver MessageReceiver, config *Config, enqueueInput func(sarah.Input) error) error {
 	log.Infof("Start receiving message")
 	for {
 		message, err := messageReceiver.Receive()
@@ -138,7 +168,23 @@ func receiveMessageRecursive(messageReceiver MessageReceiver, enqueueInput func(
 
 		}
 
-		_ = enqueueInput(message)
+		trimmed := strings.TrimSpace(message.Message())
+		if config.HelpCommand != "" && trimmed == config.HelpCommand {
+			// Help command
+			help := sarah.NewHelpInput(message)
+			_ = enqueueInput(help)
+		} else if config.AbortCommand != "" && trimmed == config.AbortCommand {
+			// Abort command
+			abort := sarah.NewAbortInput(message)
+			_ = enqueueInput(abort)
+		} else if config.AbortCurrentCommand != "" && trimmed == config.AbortCurrentCommand {
+			// Abort the current level of stacked contexts
+			abort := sarah.NewAbortCurrentInput(message)
+			_ = enqueueInput(abort)
+		} else {
+			// Regular input
+			_ = enqueueInput(message)
+		}
 	}
 }
 
@@ -169,8 +215,18 @@ func RespWithNext(fnc sarah.ContextualFunc) RespOption {
 	}
 }
 
+// RespWithUserContext sets given userContext as part of the response.
+// Use this instead of RespWithNext or RespWithNextSerializable to pass UserContext.ExpiresIn, UserContext.ExpirationMessage, or UserContext.OnExpire.
+// See sarah.UserContextStorage must be present or otherwise, userContext will be ignored.
+func RespWithUserContext(userContext *sarah.UserContext) RespOption {
+	return func(options *respOptions) {
+		options.userContext = userContext
+	}
+}
+
 // RespWithNextSerializable sets given arg as part of the response's *sarah.UserContext.
 // The next input from the same user will be passed to the function defined in the arg.
+// The function must be registered with sarah.RegisterContextualFunc under the identifier of arg.FuncIdentifier.
 // See sarah.UserContextStorage must be present or otherwise, arg will be ignored.
 func RespWithNextSerializable(arg *sarah.SerializableArgument) RespOption {
 	return func(options *respOptions) {
@@ -89,7 +89,7 @@ func Test_receiveMessageRecursive(t *testing.T) {
 	}
 	values := []value{
 		{
-			message: &RoomMessage{},
+			message: &RoomMessage{Room: &Room{}, ReceivedMessage: &Message{}},
 			err:     nil,
 		},
 		{
@@ -118,13 +118,83 @@ func Test_receiveMessageRecursive(t *testing.T) {
 		enqueueCnt++
 		return nil
 	}
-	_ = receiveMessageRecursive(conn, enqueuer)
+	_ = receiveMessageRecursive(conn, NewConfig(), enqueuer)
 
 	if enqueueCnt != 1 {
 		t.Errorf("Enqueued %d times. Should enqueue only if no error is returned.", enqueueCnt)
 	}
 }
 
+func Test_receiveMessageRecursive_Command(t *testing.T) {
+	config := &Config{
+		HelpCommand:         ".help",
+		AbortCommand:        ".abort",
+		AbortCurrentCommand: ".back",
+	}
+	texts := []string{".help", " .abort ", ".back", "hello"}
+	conn := &DummyConnection{
+		ReceiveFunc: func() (*RoomMessage, error) {
+			if len(texts) == 0 {
+				return nil, errors.New("closed")
+			}
+			var text string
+			text, texts = texts[0], texts[1:]
+			return &RoomMessage{Room: &Room{}, ReceivedMessage: &Message{Text: text}}, nil
+		},
+	}
+
+	var inputs []sarah.Input
+	_ = receiveMessageRecursive(conn, config, func(input sarah.Input) error {
+		inputs = append(inputs, input)
+		return nil
+	})
+
+	if len(inputs) != 4 {
+		t.Fatalf("Unexpected number of inputs are enqueued: %d.", len(inputs))
+	}
+	if _, ok := inputs[0].(*sarah.HelpInput); !ok {
+		t.Errorf("HelpInput is expected: %T.", inputs[0])
+	}
+	if abort, ok := inputs[1].(*sarah.AbortInput); !ok || abort.CurrentOnly() {
+		t.Errorf("AbortInput is expected: %#v.", inputs[1])
+	}
+	if abort, ok := inputs[2].(*sarah.AbortInput); !ok || !abort.CurrentOnly() {
+		t.Errorf("AbortInput for the current context is expected: %#v.", inputs[2])
+	}
+	if _, ok := inputs[3].(*RoomMessage); !ok {
+		t.Errorf("RoomMessage is expected: %T.", inputs[3])
+	}
+}
+
+func Test_receiveMessageRecursive_DefaultConfig(t *testing.T) {
+	texts := []string{".help", ".abort"}
+	conn := &DummyConnection{
+		ReceiveFunc: func() (*RoomMessage, error) {
+			if len(texts) == 0 {
+				return nil, errors.New("closed")
+			}
+			var text string
+			text, texts = texts[0], texts[1:]
+			return &RoomMessage{Room: &Room{}, ReceivedMessage: &Message{Text: text}}, nil
+		},
+	}
+
+	var inputs []sarah.Input
+	_ = receiveMessageRecursive(conn, NewConfig(), func(input sarah.Input) error {
+		inputs = append(inputs, input)
+		return nil
+	})
+
+	if len(inputs) != 2 {
+		t.Fatalf("Unexpected number of inputs are enqueued: %d.", len(inputs))
+	}
+	for i, input := range inputs {
+		if _, ok := input.(*RoomMessage); !ok {
+			t.Errorf("RoomMessage is expected at %d: %T.", i, input)
+		}
+	}
+}
+
 func TestAdapter_runEachRoom(t *testing.T) {
 	var retryLimit uint = 1
 	connect := make(chan struct{}, retryLimit)
@@ -135,7 +205,7 @@ func TestAdapter_runEachRoom(t *testing.T) {
 				connect <- struct{}{}
 				return &DummyConnection{
 					ReceiveFunc: func() (*RoomMessage, error) {
-						return &RoomMessage{}, nil
+						return &RoomMessage{Room: &Room{}, ReceivedMessage: &Message{}}, nil
 					},
 					CloseFunc: func() error {
 						closed <- struct{}{}
@@ -362,6 +432,25 @@ func TestAdapter_SendMessage(t *testing.T) {
 	}
 }
 
+func TestAdapter_SendMessage_Announcement(t *testing.T) {
+	var posted string
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			PostMessageFunc: func(_ context.Context, _ *Room, text string) (*Message, error) {
+				posted = text
+				return nil, nil
+			},
+		},
+	}
+	output := sarah.NewOutputMessage(&Room{}, &sarah.Announcement{Title: "Maintenance", Text: "Starts at 10 PM."})
+
+	adapter.SendMessage(context.TODO(), output)
+
+	if posted != "**Maintenance**\nStarts at 10 PM." {
+		t.Errorf("Unexpected text is posted: %s.", posted)
+	}
+}
+
 func TestAdapter_SendMessage_InvalidDestinationError(t *testing.T) {
 	called := false
 	adapter := &Adapter{
@@ -400,6 +489,42 @@ func TestAdapter_SendMessage_InvalidContentTypeError(t *testing.T) {
 	}
 }
 
+func TestAdapter_SendMessageWithError(t *testing.T) {
+	postErr := errors.New("api error")
+	tests := []struct {
+		output sarah.Output
+		err    error
+		hasErr bool
+	}{
+		{output: sarah.NewOutputMessage(&Room{ID: "room"}, "text"), err: nil, hasErr: false},
+		{output: sarah.NewOutputMessage(&Room{ID: "room"}, "text"), err: postErr, hasErr: true},
+		{output: sarah.NewOutputMessage(&Room{ID: "room"}, 123), err: nil, hasErr: true},
+		{output: sarah.NewOutputMessage("invalid", "text"), err: nil, hasErr: true},
+	}
+
+	for i, tt := range tests {
+		adapter := &Adapter{
+			apiClient: &DummyAPIClient{
+				PostMessageFunc: func(_ context.Context, _ *Room, _ string) (*Message, error) {
+					return nil, tt.err
+				},
+			},
+		}
+
+		err := adapter.SendMessageWithError(context.TODO(), tt.output)
+
+		if tt.hasErr && err == nil {
+			t.Errorf("Expected error is not returned on test %d.", i)
+		}
+		if !tt.hasErr && err != nil {
+			t.Errorf("Unexpected error is returned on test %d: %s.", i, err.Error())
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("Error returned by APIClient is not wrapped on test %d: %#v.", i, err)
+		}
+	}
+}
+
 func TestNewResponse(t *testing.T) {
 	optCalled := false
 	tests := []struct {
@@ -452,6 +577,20 @@ func TestRespWithNext(t *testing.T) {
 	}
 }
 
+func TestRespWithUserContext(t *testing.T) {
+	options := &respOptions{}
+	userContext := &sarah.UserContext{
+		ExpirationMessage: "timed out",
+	}
+	opt := RespWithUserContext(userContext)
+
+	opt(options)
+
+	if options.userContext != userContext {
+		t.Error("Passed UserContext is not set.")
+	}
+}
+
 func TestRespWithNextSerializable(t *testing.T) {
 	options := &respOptions{}
 	arg := &sarah.SerializableArgument{}
@@ -7,16 +7,23 @@ import (
 
 // Config contains some configuration variables for gitter Adapter.
 type Config struct {
-	Token       string        `json:"token" yaml:"token"`
-	RetryPolicy *retry.Policy `json:"retry_policy" yaml:"retry_policy"`
+	Token               string        `json:"token" yaml:"token"`
+	HelpCommand         string        `json:"help_command" yaml:"help_command"`
+	AbortCommand        string        `json:"abort_command" yaml:"abort_command"`
+	AbortCurrentCommand string        `json:"abort_current_command" yaml:"abort_current_command"`
+	RetryPolicy         *retry.Policy `json:"retry_policy" yaml:"retry_policy"`
 }
 
 // NewConfig returns initialized Config struct with default settings.
 // Token is empty at this point. Token can be set by feeding this instance to json.Unmarshal/yaml.Unmarshal,
 // or direct assignment.
+// HelpCommand, AbortCommand and AbortCurrentCommand are also empty so every message is passed as is unless those are configured.
 func NewConfig() *Config {
 	return &Config{
-		Token: "",
+		Token:               "",
+		HelpCommand:         "",
+		AbortCommand:        "",
+		AbortCurrentCommand: "",
 		RetryPolicy: &retry.Policy{
 			Trial:    10,
 			Interval: 500 * time.Millisecond,
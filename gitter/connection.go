@@ -89,6 +89,8 @@ type RoomMessage struct {
 	ReceivedMessage *Message
 }
 
+var _ sarah.ScopedInput = (*RoomMessage)(nil)
+
 // NewRoomMessage creates and returns new RoomMessage instance.
 func NewRoomMessage(room *Room, message *Message) *RoomMessage {
 	return &RoomMessage{
@@ -102,6 +104,21 @@ func (message *RoomMessage) SenderKey() string {
 	return fmt.Sprintf("%s|%s", message.Room.ID, message.ReceivedMessage.FromUser.ID)
 }
 
+// UserID returns message sending user's ID.
+func (message *RoomMessage) UserID() string {
+	return message.ReceivedMessage.FromUser.ID
+}
+
+// ChannelID returns the ID of the Room that message was being delivered.
+func (message *RoomMessage) ChannelID() string {
+	return message.Room.ID
+}
+
+// ThreadID always returns an empty string since gitter has no concept of thread.
+func (message *RoomMessage) ThreadID() string {
+	return ""
+}
+
 // Message returns received text.
 func (message *RoomMessage) Message() string {
 	return message.ReceivedMessage.Text
@@ -146,3 +146,28 @@ func TestRoomMessage_SentAt(t *testing.T) {
 		t.Errorf("Expected TimeStamp is not returned: %s.", message.SentAt())
 	}
 }
+
+func TestRoomMessage_ScopedInput(t *testing.T) {
+	message := &RoomMessage{
+		Room: &Room{
+			ID: "roomID",
+		},
+		ReceivedMessage: &Message{
+			FromUser: User{
+				ID: "userID",
+			},
+		},
+	}
+
+	if message.UserID() != "userID" {
+		t.Errorf("Unexpected user ID is returned: %s.", message.UserID())
+	}
+
+	if message.ChannelID() != "roomID" {
+		t.Errorf("Unexpected channel ID is returned: %s.", message.ChannelID())
+	}
+
+	if message.ThreadID() != "" {
+		t.Errorf("Unexpected thread ID is returned: %s.", message.ThreadID())
+	}
+}
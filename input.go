@@ -82,6 +82,7 @@ func (hi *HelpInput) ReplyTo() OutputDestination {
 
 // NewAbortInput creates a new AbortInput instance with given input.
 // When this type is given, each Bot/Adapter implementation should cancel the user's conversational context.
+// When the contexts are stacked with PushUserContext, all of them are canceled.
 func NewAbortInput(input Input) *AbortInput {
 	return &AbortInput{
 		OriginalInput: input,
@@ -92,6 +93,15 @@ func NewAbortInput(input Input) *AbortInput {
 	}
 }
 
+// NewAbortCurrentInput creates a new AbortInput instance that only cancels the current level of the stacked contexts.
+// The parent context is resumed with ReturnedValue.Aborted set to true.
+// When the current context has no parent, this is the same as the one created by NewAbortInput.
+func NewAbortCurrentInput(input Input) *AbortInput {
+	abort := NewAbortInput(input)
+	abort.currentOnly = true
+	return abort
+}
+
 // AbortInput is a common Input implementation that represents user's request for context cancellation.
 // When this type is given, each Bot/Adapter implementation should cancel and remove corresponding user's conversational context.
 type AbortInput struct {
@@ -100,10 +110,16 @@ type AbortInput struct {
 	message       string
 	sentAt        time.Time
 	replyTo       OutputDestination
+	currentOnly   bool
 }
 
 var _ Input = (*AbortInput)(nil)
 
+// CurrentOnly tells if this only cancels the current level of the stacked contexts.
+func (ai *AbortInput) CurrentOnly() bool {
+	return ai.currentOnly
+}
+
 // SenderKey returns string representing message sender.
 func (ai *AbortInput) SenderKey() string {
 	return ai.senderKey
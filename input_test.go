@@ -86,4 +86,21 @@ func TestNewAbortInput(t *testing.T) {
 	if abortInput.OriginalInput != input {
 		t.Errorf("Original Input value is not set: %#v", abortInput.OriginalInput)
 	}
+	if abortInput.CurrentOnly() {
+		t.Error("AbortInput should abort all stacked contexts by default.")
+	}
+}
+
+func TestNewAbortCurrentInput(t *testing.T) {
+	input := &DummyInput{
+		SenderKeyValue: "sender",
+	}
+	abortInput := NewAbortCurrentInput(input)
+
+	if abortInput.SenderKey() != "sender" {
+		t.Errorf("Expected sender key was not returned: %s.", abortInput.SenderKey())
+	}
+	if !abortInput.CurrentOnly() {
+		t.Error("AbortInput should only abort the current context.")
+	}
 }
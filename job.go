@@ -0,0 +1,397 @@
+package sarah
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobSchedulerNotAvailable is returned when the given context does not carry the job scheduler.
+	// The context passed to Command and ScheduledTask executions carries it while the Bot is running.
+	ErrJobSchedulerNotAvailable = errors.New("job scheduler is not available in the given context")
+
+	// ErrJobNotFound is returned by CancelJob when no job is scheduled with the given identifier.
+	ErrJobNotFound = errors.New("job is not found")
+
+	// ErrJobInsufficientArgument is returned by ScheduleJob when the given Job lacks its execution time or destination.
+	ErrJobInsufficientArgument = errors.New("Job.RunAt and Job.Destination must be set")
+)
+
+// jobPluginID is the plugin identifier to derive the Brain namespace that stores jobs.
+const jobPluginID = "sarah.jobs"
+
+// Job represents a one-time execution that sends a message to a destination at a specific time.
+// Use ScheduleJob to schedule a Job from inside a Command or a ScheduledTask.
+//
+// Scheduled jobs are stored with the Brain registered via RegisterBrain, so jobs survive process restarts when a persistent Brain is registered.
+// When no Brain is registered, jobs are stored in memory and are lost when the process stops; a warning is logged on every ScheduleJob call in that case.
+// Jobs that are due while the process is not running are executed as soon as the Bot starts.
+// To restore a job, the Destination type must be registered via RegisterDestinationType.
+type Job struct {
+	// ID is the unique identifier of the job.
+	// This is assigned by ScheduleJob when empty.
+	ID string
+
+	// SenderKey is the key of the user who scheduled the job.
+	// ListJobs uses this to list the jobs per sender.
+	SenderKey string
+
+	// RunAt is the time to execute the job.
+	RunAt time.Time
+
+	// Destination is where the Content is sent.
+	Destination OutputDestination
+
+	// Content is the message to be sent.
+	Content string
+}
+
+// NewJob creates and returns new Job that sends the content to where the given Input came from at the given time.
+func NewJob(input Input, runAt time.Time, content string) *Job {
+	return &Job{
+		SenderKey:   input.SenderKey(),
+		RunAt:       runAt,
+		Destination: input.ReplyTo(),
+		Content:     content,
+	}
+}
+
+// NewDelayedJob creates and returns new Job that sends the content to where the given Input came from after the given delay.
+func NewDelayedJob(input Input, delay time.Duration, content string) *Job {
+	return NewJob(input, time.Now().Add(delay), content)
+}
+
+var destinationTypes = &destinationTypeRegistry{
+	types: map[BotType]reflect.Type{},
+}
+
+type destinationTypeRegistry struct {
+	types map[BotType]reflect.Type
+	mutex sync.RWMutex
+}
+
+func (r *destinationTypeRegistry) register(botType BotType, prototype OutputDestination) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.types[botType] = reflect.TypeOf(prototype)
+}
+
+func (r *destinationTypeRegistry) get(botType BotType) (reflect.Type, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	destType, ok := r.types[botType]
+	return destType, ok
+}
+
+// RegisterDestinationType registers the type of OutputDestination the given BotType uses.
+// A stored Job's destination is decoded into a value with the same type as the given prototype.
+// Bundled adapters register their destination types, so this is mainly used by third-party adapters.
+//
+//  sarah.RegisterDestinationType(slack.SLACK, event.ChannelID(""))
+func RegisterDestinationType(botType BotType, prototype OutputDestination) {
+	destinationTypes.register(botType, prototype)
+}
+
+// ScheduleJob schedules the given Job with the job scheduler carried by the given context.
+// Job.ID is assigned when empty.
+//
+//  func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//    job := sarah.NewDelayedJob(input, 2*time.Hour, "Check the deploy.")
+//    err := sarah.ScheduleJob(ctx, job)
+//    ...
+//  }
+func ScheduleJob(ctx context.Context, job *Job) error {
+	scheduler, ok := ctx.Value(jobSchedulerKey{}).(*jobScheduler)
+	if !ok {
+		return ErrJobSchedulerNotAvailable
+	}
+	return scheduler.schedule(job)
+}
+
+// CancelJob cancels the job with the given identifier.
+// ErrJobNotFound is returned when no such job is scheduled.
+func CancelJob(ctx context.Context, id string) error {
+	scheduler, ok := ctx.Value(jobSchedulerKey{}).(*jobScheduler)
+	if !ok {
+		return ErrJobSchedulerNotAvailable
+	}
+	return scheduler.cancel(id)
+}
+
+// ListJobs returns the scheduled jobs of the given sender in the order of execution.
+// Pass an empty string to list all scheduled jobs.
+func ListJobs(ctx context.Context, senderKey string) ([]*Job, error) {
+	scheduler, ok := ctx.Value(jobSchedulerKey{}).(*jobScheduler)
+	if !ok {
+		return nil, ErrJobSchedulerNotAvailable
+	}
+	return scheduler.list(senderKey), nil
+}
+
+type jobSchedulerKey struct{}
+
+func withJobScheduler(ctx context.Context, scheduler *jobScheduler) context.Context {
+	return context.WithValue(ctx, jobSchedulerKey{}, scheduler)
+}
+
+type encodedJob struct {
+	ID          string            `json:"id"`
+	SenderKey   string            `json:"sender_key"`
+	RunAt       time.Time         `json:"run_at"`
+	Destination OutputDestination `json:"destination"`
+	Content     string            `json:"content"`
+}
+
+type decodedJob struct {
+	ID          string          `json:"id"`
+	SenderKey   string          `json:"sender_key"`
+	RunAt       time.Time       `json:"run_at"`
+	Destination json.RawMessage `json:"destination"`
+	Content     string          `json:"content"`
+}
+
+// jobScheduler executes one-time jobs for a Bot.
+type jobScheduler struct {
+	ctx   context.Context
+	bot   Bot
+	brain *PluginBrain
+
+	// volatile tells if the jobs are stored in memory and are lost when the process stops.
+	volatile bool
+
+	jobs   map[string]*Job
+	timers map[string]*time.Timer
+	now    func() time.Time
+	mutex  sync.Mutex
+}
+
+// runJobScheduler restores the stored jobs and starts scheduling them until the given ctx is canceled.
+func runJobScheduler(ctx context.Context, bot Bot, brain Brain) *jobScheduler {
+	_, volatile := brain.(*memoryBrain)
+	s := &jobScheduler{
+		ctx:      ctx,
+		bot:      bot,
+		brain:    NewPluginBrain(brain, bot.BotType(), jobPluginID),
+		volatile: volatile,
+		jobs:     map[string]*Job{},
+		timers:   map[string]*time.Timer{},
+		now:      time.Now,
+	}
+
+	s.restore()
+
+	go func() {
+		<-ctx.Done()
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for id, timer := range s.timers {
+			timer.Stop()
+			delete(s.timers, id)
+		}
+	}()
+
+	return s
+}
+
+// restore loads the stored jobs.
+// Jobs that were due while the process was not running are executed immediately.
+func (s *jobScheduler) restore() {
+	ids, err := s.brain.List(s.ctx)
+	if err != nil {
+		log.Errorf("Failed to list stored jobs for %s: %+v", s.bot.BotType(), err)
+		return
+	}
+
+	for _, id := range ids {
+		job, err := s.load(id)
+		if err != nil {
+			log.Errorf("Failed to restore job %s for %s: %+v", id, s.bot.BotType(), err)
+			continue
+		} else if job == nil {
+			continue
+		}
+
+		s.mutex.Lock()
+		s.add(job)
+		s.mutex.Unlock()
+	}
+}
+
+func (s *jobScheduler) load(id string) (*Job, error) {
+	b, err := s.brain.Get(s.ctx, id)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	decoded := &decodedJob{}
+	err = json.Unmarshal(b, decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+
+	destType, ok := destinationTypes.get(s.bot.BotType())
+	if !ok {
+		return nil, fmt.Errorf("destination type is not registered for %s", s.bot.BotType())
+	}
+
+	dest, err := decodeArgument(destType, decoded.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode destination: %w", err)
+	}
+
+	return &Job{
+		ID:          decoded.ID,
+		SenderKey:   decoded.SenderKey,
+		RunAt:       decoded.RunAt,
+		Destination: dest,
+		Content:     decoded.Content,
+	}, nil
+}
+
+func (s *jobScheduler) schedule(job *Job) error {
+	if job.RunAt.IsZero() || job.Destination == nil {
+		return ErrJobInsufficientArgument
+	}
+
+	if job.ID == "" {
+		id, err := newJobID()
+		if err != nil {
+			return err
+		}
+		job.ID = id
+	}
+
+	err := s.brain.SetJSON(s.ctx, job.ID, &encodedJob{
+		ID:          job.ID,
+		SenderKey:   job.SenderKey,
+		RunAt:       job.RunAt,
+		Destination: job.Destination,
+		Content:     job.Content,
+	}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to store job %s: %w", job.ID, err)
+	}
+	if s.volatile {
+		log.Warnf("Job %s for %s is stored in memory and is lost when the process stops. Register a persistent Brain via RegisterBrain such as storages.NewFileBrain to keep jobs across restarts.", job.ID, s.bot.BotType())
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if timer, ok := s.timers[job.ID]; ok {
+		timer.Stop()
+	}
+	s.add(job)
+	return nil
+}
+
+// add starts the timer of the given job.
+// The caller must hold the lock.
+func (s *jobScheduler) add(job *Job) {
+	delay := job.RunAt.Sub(s.now())
+	if delay < 0 {
+		delay = 0
+	}
+
+	s.jobs[job.ID] = job
+	s.timers[job.ID] = time.AfterFunc(delay, func() {
+		s.execute(job)
+	})
+}
+
+func (s *jobScheduler) execute(job *Job) {
+	s.mutex.Lock()
+	if s.jobs[job.ID] != job {
+		// Canceled or replaced.
+		s.mutex.Unlock()
+		return
+	}
+	delete(s.jobs, job.ID)
+	delete(s.timers, job.ID)
+	s.mutex.Unlock()
+
+	if s.ctx.Err() != nil {
+		// The job stays in the Brain and is executed when the Bot starts again.
+		return
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Errorf("Panic on executing job %s: %+v", job.ID, r)
+			}
+		}()
+
+		s.bot.SendMessage(s.ctx, NewOutputMessage(job.Destination, job.Content))
+	}()
+
+	err := s.brain.Delete(s.ctx, job.ID)
+	if err != nil {
+		log.Errorf("Failed to delete executed job %s: %+v", job.ID, err)
+	}
+}
+
+func (s *jobScheduler) cancel(id string) error {
+	s.mutex.Lock()
+	_, ok := s.jobs[id]
+	if ok {
+		s.timers[id].Stop()
+		delete(s.jobs, id)
+		delete(s.timers, id)
+	}
+	s.mutex.Unlock()
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	err := s.brain.Delete(s.ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *jobScheduler) list(senderKey string) []*Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobs := []*Job{}
+	for _, job := range s.jobs {
+		if senderKey != "" && job.SenderKey != senderKey {
+			continue
+		}
+
+		copied := *job
+		jobs = append(jobs, &copied)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].ID < jobs[j].ID
+		}
+		return jobs[i].RunAt.Before(jobs[j].RunAt)
+	})
+	return jobs
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,209 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewJob(t *testing.T) {
+	runAt := time.Now().Add(time.Hour)
+	input := &DummyInput{SenderKeyValue: "sender", ReplyToValue: "#general"}
+	job := NewJob(input, runAt, "content")
+
+	if job.SenderKey != "sender" || job.Destination != "#general" || job.Content != "content" || !job.RunAt.Equal(runAt) {
+		t.Errorf("Unexpected job is returned: %#v.", job)
+	}
+}
+
+func TestNewDelayedJob(t *testing.T) {
+	input := &DummyInput{SenderKeyValue: "sender", ReplyToValue: "#general"}
+	job := NewDelayedJob(input, time.Hour, "content")
+
+	if job.RunAt.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("Unexpected execution time is set: %s.", job.RunAt)
+	}
+}
+
+func TestRegisterDestinationType(t *testing.T) {
+	var botType BotType = "registering"
+	RegisterDestinationType(botType, "")
+
+	destType, ok := destinationTypes.get(botType)
+	if !ok {
+		t.Fatal("Destination type is not registered.")
+	}
+
+	if destType.Kind().String() != "string" {
+		t.Errorf("Unexpected type is registered: %s.", destType)
+	}
+}
+
+func TestJobFunctions_WithoutScheduler(t *testing.T) {
+	ctx := context.Background()
+	if err := ScheduleJob(ctx, &Job{}); !errors.Is(err, ErrJobSchedulerNotAvailable) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if err := CancelJob(ctx, "id"); !errors.Is(err, ErrJobSchedulerNotAvailable) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if _, err := ListJobs(ctx, "sender"); !errors.Is(err, ErrJobSchedulerNotAvailable) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestJobFunctions(t *testing.T) {
+	var botType BotType = "dummyJob"
+	RegisterDestinationType(botType, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sent := make(chan Output, 1)
+	bot := &DummyBot{
+		BotTypeValue: botType,
+		SendMessageFunc: func(_ context.Context, output Output) {
+			sent <- output
+		},
+	}
+	brain := NewMemoryBrain()
+	ctx = withJobScheduler(ctx, runJobScheduler(ctx, bot, brain))
+
+	err := ScheduleJob(ctx, &Job{})
+	if !errors.Is(err, ErrJobInsufficientArgument) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	later := &Job{SenderKey: "sender", RunAt: time.Now().Add(time.Hour), Destination: "#general", Content: "later"}
+	other := &Job{SenderKey: "other", RunAt: time.Now().Add(time.Hour), Destination: "#general", Content: "other"}
+	soon := &Job{SenderKey: "sender", RunAt: time.Now().Add(2 * time.Hour), Destination: "#general", Content: "soon"}
+	for _, job := range []*Job{later, other, soon} {
+		err := ScheduleJob(ctx, job)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+		if job.ID == "" {
+			t.Fatal("Job ID is not assigned.")
+		}
+	}
+
+	jobs, _ := ListJobs(ctx, "sender")
+	if len(jobs) != 2 || jobs[0].ID != later.ID || jobs[1].ID != soon.ID {
+		t.Errorf("Unexpected jobs are listed: %#v.", jobs)
+	}
+
+	jobs, _ = ListJobs(ctx, "")
+	if len(jobs) != 3 {
+		t.Errorf("Unexpected number of jobs are listed: %d.", len(jobs))
+	}
+
+	err = CancelJob(ctx, later.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	err = CancelJob(ctx, later.ID)
+	if !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if stored, _ := brain.Get(ctx, BrainNamespace(botType, jobPluginID), later.ID); stored != nil {
+		t.Errorf("Canceled job is still stored: %s.", stored)
+	}
+
+	err = ScheduleJob(ctx, &Job{RunAt: time.Now(), Destination: "#random", Content: "now"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	select {
+	case output := <-sent:
+		if output.Destination() != "#random" || output.Content() != "now" {
+			t.Errorf("Unexpected output is sent: %#v.", output)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Job is not executed.")
+
+	}
+}
+
+func Test_runJobScheduler_Restore(t *testing.T) {
+	var botType BotType = "restoringJob"
+	RegisterDestinationType(botType, "")
+
+	brain := NewMemoryBrain()
+	bot := &DummyBot{
+		BotTypeValue:    botType,
+		SendMessageFunc: func(_ context.Context, _ Output) {},
+	}
+
+	// Schedule jobs and stop the Bot.
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := runJobScheduler(ctx, bot, brain)
+	missed := &Job{SenderKey: "sender", RunAt: time.Now().Add(50 * time.Millisecond), Destination: "#general", Content: "missed"}
+	future := &Job{SenderKey: "sender", RunAt: time.Now().Add(time.Hour), Destination: "#general", Content: "future"}
+	_ = scheduler.schedule(missed)
+	_ = scheduler.schedule(future)
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+
+	// Start the Bot again.
+	sent := make(chan Output, 1)
+	bot.SendMessageFunc = func(_ context.Context, output Output) {
+		sent <- output
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	restored := runJobScheduler(ctx, bot, brain)
+
+	select {
+	case output := <-sent:
+		if output.Content() != "missed" || output.Destination() != "#general" {
+			t.Errorf("Unexpected output is sent: %#v.", output)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Missed job is not executed.")
+
+	}
+
+	jobs := restored.list("")
+	if len(jobs) != 1 || jobs[0].ID != future.ID || !jobs[0].RunAt.Equal(future.RunAt) {
+		t.Errorf("Unexpected jobs are restored: %#v.", jobs)
+	}
+}
+
+func Test_runJobScheduler_Volatile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bot := &DummyBot{BotTypeValue: "dummyJob"}
+	if !runJobScheduler(ctx, bot, NewMemoryBrain()).volatile {
+		t.Error("Scheduler with the in-memory Brain should be volatile.")
+	}
+
+	// Any Brain other than the in-memory implementation is considered persistent.
+	brain := &struct{ Brain }{NewMemoryBrain()}
+	if runJobScheduler(ctx, bot, brain).volatile {
+		t.Error("Scheduler with the registered Brain should not be volatile.")
+	}
+}
+
+func Test_jobScheduler_load_UnregisteredDestination(t *testing.T) {
+	var botType BotType = "unregisteredJob"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheduler := runJobScheduler(ctx, &DummyBot{BotTypeValue: botType}, NewMemoryBrain())
+	_ = scheduler.schedule(&Job{RunAt: time.Now().Add(time.Hour), Destination: "#general"})
+
+	ids, _ := scheduler.brain.List(ctx)
+	_, err := scheduler.load(ids[0])
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
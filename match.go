@@ -0,0 +1,100 @@
+package sarah
+
+import (
+	"context"
+	"regexp"
+)
+
+type patternMatchKey struct{}
+
+// PatternMatch represents the result of matching a regular expression against user input.
+// When a Command is built with CommandPropsBuilder.MatchPattern, the matched result is stored in the context
+// so the command function does not have to run the same regular expression again to extract its arguments.
+type PatternMatch struct {
+	// Submatches contains the leftmost match and its submatches as returned by regexp.Regexp.FindStringSubmatch.
+	// Submatches[0] is the entire matched text and Submatches[n] is the text of the n-th parenthesized subexpression.
+	Submatches []string
+
+	// Named contains the text of each named capture group such as (?P<name>...).
+	// Groups that did not participate in the match are stored with empty string.
+	Named map[string]string
+}
+
+// Get returns the text captured by the named group.
+// Empty string is returned when no such group is defined or the group did not participate in the match.
+func (m *PatternMatch) Get(name string) string {
+	if m == nil {
+		return ""
+	}
+	return m.Named[name]
+}
+
+// Submatch returns the text of the i-th parenthesized subexpression.
+// Index 0 represents the entire matched text.
+// Empty string is returned when the index is out of range.
+func (m *PatternMatch) Submatch(i int) string {
+	if m == nil || i < 0 || i >= len(m.Submatches) {
+		return ""
+	}
+	return m.Submatches[i]
+}
+
+// NewPatternMatch applies the given regular expression to the given string and returns the matched result.
+// When the string does not match, nil is returned.
+func NewPatternMatch(pattern *regexp.Regexp, str string) *PatternMatch {
+	submatches := pattern.FindStringSubmatch(str)
+	if submatches == nil {
+		return nil
+	}
+
+	named := map[string]string{}
+	for i, name := range pattern.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		named[name] = submatches[i]
+	}
+
+	return &PatternMatch{
+		Submatches: submatches,
+		Named:      named,
+	}
+}
+
+// WithPatternMatch returns a copy of the given context that holds the given *PatternMatch.
+func WithPatternMatch(ctx context.Context, match *PatternMatch) context.Context {
+	return context.WithValue(ctx, patternMatchKey{}, match)
+}
+
+// PatternMatchFromContext returns *PatternMatch stored in the given context.
+// The second returning value tells if a matched result is stored.
+//
+// A matched result is stored when a Command built with CommandPropsBuilder.MatchPattern is executed,
+// or when a ContextualFunc created by NewPatternContextualFunc receives matching input.
+func PatternMatchFromContext(ctx context.Context) (*PatternMatch, bool) {
+	match, ok := ctx.Value(patternMatchKey{}).(*PatternMatch)
+	return match, ok && match != nil
+}
+
+// NewPatternContextualFunc creates a ContextualFunc that applies the given regular expression to the user's next input
+// and passes the matched result to fn.
+// This is handy when a conversational context expects structured input such as "2020-01-01 10:00."
+// When the input does not match, fn is called with nil so the function can ask the user to input again.
+//
+//  pattern := regexp.MustCompile(`^(?P<date>\d{4}-\d{2}-\d{2})$`)
+//  next := sarah.NewPatternContextualFunc(pattern, func(ctx context.Context, input sarah.Input, match *sarah.PatternMatch) (*sarah.CommandResponse, error) {
+//    if match == nil {
+//      return slack.NewResponse(input, "Please input a valid date.", slack.RespWithNext(...))
+//    }
+//    date := match.Get("date")
+//    ...
+//  })
+func NewPatternContextualFunc(pattern *regexp.Regexp, fn func(context.Context, Input, *PatternMatch) (*CommandResponse, error)) ContextualFunc {
+	return func(ctx context.Context, input Input) (*CommandResponse, error) {
+		match := NewPatternMatch(pattern, input.Message())
+		if match != nil {
+			ctx = WithPatternMatch(ctx, match)
+		}
+		return fn(ctx, input, match)
+	}
+}
@@ -0,0 +1,108 @@
+package sarah
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestNewPatternMatch(t *testing.T) {
+	pattern := regexp.MustCompile(`^\.remind\s+(?P<who>\S+)\s+in\s+(\d+)h`)
+
+	match := NewPatternMatch(pattern, ".remind me in 2h to check the deploy")
+	if match == nil {
+		t.Fatal("Expected match is not returned.")
+	}
+
+	if match.Get("who") != "me" {
+		t.Errorf("Unexpected named capture is returned: %s.", match.Get("who"))
+	}
+
+	if match.Submatch(2) != "2" {
+		t.Errorf("Unexpected submatch is returned: %s.", match.Submatch(2))
+	}
+
+	if match.Submatch(3) != "" {
+		t.Errorf("Empty string should return for out of range index: %s.", match.Submatch(3))
+	}
+
+	if match.Get("unknown") != "" {
+		t.Errorf("Empty string should return for unknown name: %s.", match.Get("unknown"))
+	}
+
+	if NewPatternMatch(pattern, ".echo foo") != nil {
+		t.Error("Nil should return for non-matching string.")
+	}
+}
+
+func TestPatternMatch_NilReceiver(t *testing.T) {
+	var match *PatternMatch
+	if match.Get("foo") != "" {
+		t.Error("Empty string should return.")
+	}
+	if match.Submatch(0) != "" {
+		t.Error("Empty string should return.")
+	}
+}
+
+func TestPatternMatchFromContext(t *testing.T) {
+	if _, ok := PatternMatchFromContext(context.TODO()); ok {
+		t.Error("No match should be stored in the empty context.")
+	}
+
+	match := &PatternMatch{}
+	ctx := WithPatternMatch(context.TODO(), match)
+	stored, ok := PatternMatchFromContext(ctx)
+	if !ok {
+		t.Fatal("Stored match is not returned.")
+	}
+	if stored != match {
+		t.Errorf("Unexpected value is returned: %#v.", stored)
+	}
+}
+
+func TestNewPatternContextualFunc(t *testing.T) {
+	pattern := regexp.MustCompile(`^(?P<date>\d{4}-\d{2}-\d{2})$`)
+
+	tests := []struct {
+		message string
+		date    string
+		matched bool
+	}{
+		{
+			message: "2020-01-01",
+			date:    "2020-01-01",
+			matched: true,
+		},
+		{
+			message: "tomorrow",
+			matched: false,
+		},
+	}
+
+	for i, tt := range tests {
+		var given *PatternMatch
+		fnc := NewPatternContextualFunc(pattern, func(ctx context.Context, _ Input, match *PatternMatch) (*CommandResponse, error) {
+			given = match
+			stored, _ := PatternMatchFromContext(ctx)
+			if stored != match {
+				t.Errorf("Given match and stored match differ on test #%d.", i)
+			}
+			return nil, nil
+		})
+
+		_, _ = fnc(context.TODO(), &DummyInput{MessageValue: tt.message})
+
+		if tt.matched {
+			if given == nil {
+				t.Errorf("Expected match is not given on test #%d.", i)
+				continue
+			}
+			if given.Get("date") != tt.date {
+				t.Errorf("Unexpected date is given on test #%d: %s.", i, given.Get("date"))
+			}
+		} else if given != nil {
+			t.Errorf("Nil is expected on test #%d: %#v.", i, given)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package sarah
+
+import (
+	"sync"
+	"time"
+)
+
+// OverlapPolicy defines how a scheduled execution behaves when the previous execution of the same task is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapAllow runs the execution concurrently with the running one.
+	// This is the default policy.
+	OverlapAllow OverlapPolicy = iota
+
+	// OverlapSkip skips the execution while the previous one is running.
+	OverlapSkip
+
+	// OverlapQueueOne runs the execution right after the running one finishes.
+	// Only one execution is queued, and further executions are skipped while one is queued.
+	OverlapQueueOne
+)
+
+// String returns the stringified form of the policy.
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapAllow:
+		return "allow"
+
+	case OverlapSkip:
+		return "skip"
+
+	case OverlapQueueOne:
+		return "queue_one"
+
+	default:
+		return "unknown"
+
+	}
+}
+
+// OverlapControlledTask is an optional interface that ScheduledTask implementation may satisfy to control overlapping executions.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the value given to ScheduledTaskPropsBuilder.OverlapPolicy.
+type OverlapControlledTask interface {
+	OverlapPolicy() OverlapPolicy
+}
+
+// TimeLimitedTask is an optional interface that ScheduledTask implementation may satisfy to limit its execution time.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the value given to ScheduledTaskPropsBuilder.Timeout.
+type TimeLimitedTask interface {
+	// Timeout returns the duration after which the context passed to ScheduledTask.Execute is canceled.
+	// Zero means no timeout.
+	Timeout() time.Duration
+}
+
+func taskOverlapPolicy(task ScheduledTask) OverlapPolicy {
+	if controlled, ok := task.(OverlapControlledTask); ok {
+		return controlled.OverlapPolicy()
+	}
+	return OverlapAllow
+}
+
+func taskTimeout(task ScheduledTask) time.Duration {
+	if limited, ok := task.(TimeLimitedTask); ok {
+		return limited.Timeout()
+	}
+	return 0
+}
+
+// taskGuard tracks the running execution of a scheduled task to apply OverlapPolicy.
+type taskGuard struct {
+	running bool
+	pending func()
+	mutex   sync.Mutex
+}
+
+// run executes fn in a blocking manner unless another execution is running.
+// When another execution is running, fn is queued if the policy is OverlapQueueOne and nothing is queued yet.
+// This returns false when fn is skipped.
+// When fn panics, the queued execution is dropped and the panic is propagated to the caller.
+func (g *taskGuard) run(policy OverlapPolicy, fn func()) bool {
+	g.mutex.Lock()
+	if g.running {
+		defer g.mutex.Unlock()
+		if policy == OverlapQueueOne && g.pending == nil {
+			g.pending = fn
+			return true
+		}
+		return false
+	}
+	g.running = true
+	g.mutex.Unlock()
+
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+
+		// fn panicked. Reset the state so the following executions are not skipped forever.
+		g.mutex.Lock()
+		g.running = false
+		g.pending = nil
+		g.mutex.Unlock()
+	}()
+
+	for fn != nil {
+		fn()
+
+		g.mutex.Lock()
+		fn = g.pending
+		g.pending = nil
+		if fn == nil {
+			g.running = false
+		}
+		g.mutex.Unlock()
+	}
+	completed = true
+
+	return true
+}
+
+// taskGuards holds taskGuard for each scheduled task.
+// The guard outlives the task's rebuild on configuration update, so the rebuilt task does not overlap with the running one.
+type taskGuards struct {
+	guards map[BotType]map[string]*taskGuard
+	mutex  sync.Mutex
+}
+
+func (g *taskGuards) get(botType BotType, taskID string) *taskGuard {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.guards == nil {
+		g.guards = map[BotType]map[string]*taskGuard{}
+	}
+
+	botGuards, ok := g.guards[botType]
+	if !ok {
+		botGuards = map[string]*taskGuard{}
+		g.guards[botType] = botGuards
+	}
+
+	guard, ok := botGuards[taskID]
+	if !ok {
+		guard = &taskGuard{}
+		botGuards[taskID] = guard
+	}
+	return guard
+}
@@ -0,0 +1,211 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOverlapPolicy_String(t *testing.T) {
+	testSets := []struct {
+		policy   OverlapPolicy
+		expected string
+	}{
+		{policy: OverlapAllow, expected: "allow"},
+		{policy: OverlapSkip, expected: "skip"},
+		{policy: OverlapQueueOne, expected: "queue_one"},
+		{policy: OverlapPolicy(100), expected: "unknown"},
+	}
+
+	for _, testSet := range testSets {
+		if testSet.policy.String() != testSet.expected {
+			t.Errorf("Unexpected string is returned: %s.", testSet.policy.String())
+		}
+	}
+}
+
+func Test_taskOverlapPolicy(t *testing.T) {
+	if policy := taskOverlapPolicy(&DummyScheduledTask{}); policy != OverlapAllow {
+		t.Errorf("OverlapAllow should be returned by default: %s.", policy)
+	}
+
+	if policy := taskOverlapPolicy(&scheduledTask{overlapPolicy: OverlapSkip}); policy != OverlapSkip {
+		t.Errorf("Unexpected policy is returned: %s.", policy)
+	}
+}
+
+func Test_taskTimeout(t *testing.T) {
+	if timeout := taskTimeout(&DummyScheduledTask{}); timeout != 0 {
+		t.Errorf("Zero should be returned by default: %s.", timeout)
+	}
+
+	if timeout := taskTimeout(&scheduledTask{timeout: time.Minute}); timeout != time.Minute {
+		t.Errorf("Unexpected timeout is returned: %s.", timeout)
+	}
+}
+
+func Test_taskGuard_run(t *testing.T) {
+	testSets := []struct {
+		policy   OverlapPolicy
+		executed int
+		skipped  int
+	}{
+		{policy: OverlapSkip, executed: 1, skipped: 3},
+		{policy: OverlapQueueOne, executed: 2, skipped: 2},
+	}
+
+	for i, testSet := range testSets {
+		guard := &taskGuard{}
+		started := make(chan struct{})
+		release := make(chan struct{})
+		executed := 0
+		fn := func() {
+			executed++
+			if executed == 1 {
+				close(started)
+				<-release
+			}
+		}
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			guard.run(testSet.policy, fn)
+		}()
+		<-started
+
+		skipped := 0
+		for j := 0; j < 3; j++ {
+			if !guard.run(testSet.policy, fn) {
+				skipped++
+			}
+		}
+		close(release)
+		wg.Wait()
+
+		if executed != testSet.executed {
+			t.Errorf("Unexpected number of executions on test %d: %d.", i, executed)
+		}
+		if skipped != testSet.skipped {
+			t.Errorf("Unexpected number of skips on test %d: %d.", i, skipped)
+		}
+		if guard.running {
+			t.Errorf("Guard should not be running on test %d.", i)
+		}
+	}
+}
+
+func Test_taskGuard_run_Panic(t *testing.T) {
+	guard := &taskGuard{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	queued := false
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Panic should be propagated.")
+			}
+		}()
+		guard.run(OverlapQueueOne, func() {
+			close(started)
+			<-release
+			panic("task panic")
+		})
+	}()
+	<-started
+
+	guard.run(OverlapQueueOne, func() {
+		queued = true
+	})
+	close(release)
+	wg.Wait()
+
+	if queued {
+		t.Error("Queued execution should be dropped after panic.")
+	}
+	if guard.running || guard.pending != nil {
+		t.Fatal("Guard state should be reset after panic.")
+	}
+
+	executed := false
+	if !guard.run(OverlapSkip, func() { executed = true }) || !executed {
+		t.Error("Following execution should not be skipped after panic.")
+	}
+}
+
+func Test_taskGuards_get(t *testing.T) {
+	guards := &taskGuards{}
+	guard := guards.get("dummy", "task")
+
+	if guards.get("dummy", "task") != guard {
+		t.Error("Same guard should be returned for the same task.")
+	}
+
+	if guards.get("dummy", "another") == guard {
+		t.Error("Different guard should be returned for another task.")
+	}
+}
+
+func Test_runner_runGuardedScheduledTask(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		started := make(chan struct{})
+		release := make(chan struct{})
+		task := &scheduledTask{
+			identifier:    "report",
+			overlapPolicy: OverlapSkip,
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				close(started)
+				<-release
+				return nil, nil
+			},
+		}
+		r := &runner{
+			config:   &Config{TaskHistorySize: 3},
+			alerters: &alerters{},
+		}
+		bot := &DummyBot{BotTypeValue: botType}
+
+		finished := make(chan struct{})
+		go func() {
+			r.runGuardedScheduledTask(context.TODO(), bot, task, time.Now())
+			close(finished)
+		}()
+		<-started
+
+		r.runGuardedScheduledTask(context.TODO(), bot, task, time.Now())
+		close(release)
+		<-finished
+
+		status := runnerStatus.findTaskHistory(botType, task.identifier).snapshot()
+		if status.Skipped != 1 {
+			t.Errorf("Skipped execution is not counted: %d.", status.Skipped)
+		}
+		if len(status.Executions) != 1 {
+			t.Errorf("Unexpected number of executions are recorded: %d.", len(status.Executions))
+		}
+	})
+}
+
+func Test_executeScheduledTask_WithTimeout(t *testing.T) {
+	task := &scheduledTask{
+		identifier: "report",
+		timeout:    10 * time.Millisecond,
+		taskFunc: func(ctx context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	_, err := executeScheduledTask(context.TODO(), &DummyBot{}, task, time.Time{}, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
@@ -4,6 +4,7 @@ Package retry provides general retry logic and designated error structure that c
 package retry
 
 import (
+	"context"
 	"math/rand"
 	"strings"
 	"time"
@@ -56,6 +57,12 @@ func WithPolicy(policy *Policy, function func() error) error {
 	return WithBackOff(policy.Trial, function, policy.Interval, policy.RandFactor)
 }
 
+// WithPolicyContext is similar to WithPolicy, but quits retrial when the given context is canceled.
+// The context error is appended to the returned Errors in that case.
+func WithPolicyContext(ctx context.Context, policy *Policy, function func() error) error {
+	return WithBackOffContext(ctx, policy.Trial, function, policy.Interval, policy.RandFactor)
+}
+
 // Retry retries given function as many times as the maximum trial count.
 // It quits retrial when the function returns no error, which is nil.
 func Retry(trial uint, function func() error) error {
@@ -95,6 +102,44 @@ func WithBackOff(trial uint, function func() error, meanInterval time.Duration,
 	return errs
 }
 
+// WithBackOffContext is similar to WithBackOff, but quits retrial when the given context is canceled while waiting for the next trial.
+// The context error is appended to the returned Errors in that case.
+func WithBackOffContext(ctx context.Context, trial uint, function func() error, meanInterval time.Duration, randFactor float64) error {
+	errs := &Errors{}
+	for trial > 0 {
+		trial--
+		err := function()
+		if err == nil {
+			return nil
+		}
+		errs.appendError(err)
+
+		if trial <= 0 {
+			// All trials failed
+			break
+		}
+
+		interval := meanInterval
+		if randFactor > 0 && meanInterval > 0 {
+			interval = randInterval(meanInterval, randFactor)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			errs.appendError(ctx.Err())
+			return errs
+
+		case <-timer.C:
+			// Continue to the next trial
+
+		}
+	}
+
+	return errs
+}
+
 func randInterval(intervalDuration time.Duration, randFactor float64) time.Duration {
 	if randFactor < 0 {
 		randFactor = 0
@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -145,6 +146,46 @@ func TestWithBackOff(t *testing.T) {
 	}
 }
 
+func TestWithPolicyContext(t *testing.T) {
+	policy := &Policy{Trial: 3}
+	called := 0
+	err := WithPolicyContext(context.TODO(), policy, func() error {
+		called++
+		if called < 2 {
+			return errors.New("error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %#v.", err)
+	}
+	if called != 2 {
+		t.Errorf("Unexpected number of trials: %d.", called)
+	}
+}
+
+func TestWithBackOffContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	called := 0
+	err := WithBackOffContext(ctx, 3, func() error {
+		called++
+		cancel()
+		return errors.New("error")
+	}, time.Minute, 0)
+
+	if called != 1 {
+		t.Errorf("Retrial should stop on context cancellation: %d.", called)
+	}
+
+	errs, ok := err.(*Errors)
+	if !ok {
+		t.Fatalf("Unexpected error type is returned: %T.", err)
+	}
+	if len(*errs) != 2 || (*errs)[1] != context.Canceled {
+		t.Errorf("Context error is not appended: %#v.", *errs)
+	}
+}
+
 func Test_randInterval(t *testing.T) {
 	interval := randInterval(5*time.Second, 0)
 	if interval != 5*time.Second {
@@ -2,6 +2,8 @@ package sarah
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/oklahomer/go-sarah/v3/log"
 	"github.com/oklahomer/go-sarah/v3/workers"
@@ -16,13 +18,106 @@ var options = &optionHolder{}
 // Config contains some basic configuration variables for go-sarah.
 type Config struct {
 	TimeZone string `json:"timezone" yaml:"timezone"`
+
+	// BotTimeZones overrides TimeZone for the scheduled tasks of the specified BotType.
+	// A task's own time zone given by ZonedTask or the CRON_TZ= prefix of its schedule still takes priority.
+	BotTimeZones map[BotType]string `json:"bot_timezones" yaml:"bot_timezones"`
+
+	// TaskHistorySize is the number of recent executions to keep for each scheduled task.
+	TaskHistorySize int `json:"task_history_size" yaml:"task_history_size"`
+
+	// TaskFailureAlertThreshold is the number of consecutive failures of a scheduled task to notify registered Alerters.
+	// A recovery notice follows when the task succeeds again. Set zero to disable the notification.
+	TaskFailureAlertThreshold int `json:"task_failure_alert_threshold" yaml:"task_failure_alert_threshold"`
+
+	// TaskLockTTL is the default lease duration of the lock acquired before each scheduled execution when TaskLocker is registered.
+	TaskLockTTL time.Duration `json:"task_lock_ttl" yaml:"task_lock_ttl"`
+
+	// DispatchTasks tells whether scheduled executions run on workers.Worker instead of the scheduler's goroutines.
+	// This bounds the concurrency of scheduled executions and protects the process from their panics.
+	// The worker registered via RegisterTaskWorker is used if any; otherwise the worker for incoming messages is shared.
+	DispatchTasks bool `json:"dispatch_tasks" yaml:"dispatch_tasks"`
+
+	// TaskOverflowPolicy defines how a scheduled execution behaves when the worker's queue is full.
+	TaskOverflowPolicy TaskOverflowPolicy `json:"task_overflow_policy" yaml:"task_overflow_policy"`
+
+	// BotWorkers creates a dedicated worker for each specified BotType so a flood of messages to one Bot does not block the others.
+	// The worker registered via RegisterBotWorker takes priority, and a Bot without either of them shares the worker for incoming messages.
+	BotWorkers BotWorkerConfigs `json:"bot_workers" yaml:"bot_workers"`
+}
+
+// BotWorkerConfigs maps BotType to the configuration of its dedicated worker.
+// Each decoded entry starts from the default values of workers.NewConfig, so omitted fields and an empty entry take the default values.
+type BotWorkerConfigs map[BotType]*workers.Config
+
+// UnmarshalJSON decodes each entry on top of the default values.
+func (c *BotWorkerConfigs) UnmarshalJSON(b []byte) error {
+	raw := map[BotType]json.RawMessage{}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return err
+	}
+
+	configs := BotWorkerConfigs{}
+	for botType, value := range raw {
+		config := workers.NewConfig()
+		err := json.Unmarshal(value, config)
+		if err != nil {
+			return fmt.Errorf("failed to decode worker configuration for %s: %w", botType, err)
+		}
+		configs[botType] = config
+	}
+
+	*c = configs
+	return nil
+}
+
+// UnmarshalYAML decodes each entry on top of the default values.
+func (c *BotWorkerConfigs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	raw := map[BotType]*yamlValue{}
+	err := unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+
+	configs := BotWorkerConfigs{}
+	for botType, value := range raw {
+		config := workers.NewConfig()
+		if value != nil {
+			err := value.unmarshal(config)
+			if err != nil {
+				return fmt.Errorf("failed to decode worker configuration for %s: %w", botType, err)
+			}
+		}
+		configs[botType] = config
+	}
+
+	*c = configs
+	return nil
+}
+
+// yamlValue holds a YAML value to be decoded later.
+type yamlValue struct {
+	unmarshal func(interface{}) error
+}
+
+func (v *yamlValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	v.unmarshal = unmarshal
+	return nil
 }
 
 // NewConfig creates and returns new Config instance with default settings.
 // Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
 func NewConfig() *Config {
 	return &Config{
-		TimeZone: time.Now().Location().String(),
+		TimeZone:                  time.Now().Location().String(),
+		BotTimeZones:              map[BotType]string{},
+		TaskHistorySize:           10,
+		TaskFailureAlertThreshold: 3,
+		TaskLockTTL:               defaultTaskLockTTL,
+		DispatchTasks:             false,
+		TaskOverflowPolicy:        TaskOverflowSkip,
+		BotWorkers:                BotWorkerConfigs{},
 	}
 }
 
@@ -130,6 +225,78 @@ func RegisterWorker(worker workers.Worker) {
 	})
 }
 
+// RegisterBotWorker registers given workers.Worker implementation that is dedicated to the incoming messages of the given BotType.
+// When this is not called for a BotType, a worker is created with Config.BotWorkers if any; otherwise the worker registered via RegisterWorker is shared.
+// To report the dedicated worker's queue stats via CurrentStatus, create the worker with the Reporter returned by NewBotWorkerReporter.
+//
+//  worker, err := workers.Run(ctx, workers.NewConfig(), workers.WithReporter(sarah.NewBotWorkerReporter(gitter.GITTER)))
+//  sarah.RegisterBotWorker(gitter.GITTER, worker)
+func RegisterBotWorker(botType BotType, worker workers.Worker) {
+	options.register(func(r *runner) {
+		r.botWorkers[botType] = worker
+	})
+}
+
+// RegisterInputClassifier registers a function that tells the priority of each incoming message.
+// The priority takes effect when the registered worker satisfies workers.PriorityWorker as the default worker does.
+// When this is not called, DefaultInputPriority is used.
+// Regardless of the classifier, the input that an authorized sender sends to the command given by NewTriggerTaskCommandProps jumps the queue.
+//
+//  sarah.RegisterInputClassifier(func(input sarah.Input) workers.Priority {
+//    if strings.HasPrefix(input.Message(), ".deploy") {
+//      return workers.PriorityHigh
+//    }
+//    return sarah.DefaultInputPriority(input)
+//  })
+func RegisterInputClassifier(classify func(Input) workers.Priority) {
+	options.register(func(r *runner) {
+		r.classifyInput = classify
+	})
+}
+
+// RegisterTaskWorker registers given workers.Worker implementation that is dedicated to scheduled executions.
+// This is used only when Config.DispatchTasks is true.
+// When this is not called, the worker for incoming messages also runs scheduled executions.
+// Create the worker with WithTaskQueueGauge to report the number of queued executions via workers.Stats.
+func RegisterTaskWorker(worker workers.Worker) {
+	options.register(func(r *runner) {
+		r.taskWorker = worker
+	})
+}
+
+// RegisterTaskRunStore registers given TaskRunStore implementation to catch up scheduled executions missed during downtime or pause.
+// When this is not called, no execution is caught up regardless of each task's CatchUpPolicy.
+func RegisterTaskRunStore(store TaskRunStore) {
+	options.register(func(r *runner) {
+		r.taskRunStore = store
+	})
+}
+
+// RegisterTaskPauseStore registers given TaskPauseStore implementation to keep scheduled tasks paused by PauseTask across process restarts.
+// When this is not called, the paused state is held in memory.
+func RegisterTaskPauseStore(store TaskPauseStore) {
+	options.register(func(r *runner) {
+		r.taskPauseStore = store
+	})
+}
+
+// RegisterBrain registers given Brain implementation that plugins use to store their state.
+// Jobs scheduled via ScheduleJob are also stored with this.
+// When this is not called, the in-memory implementation returned by NewMemoryBrain is used.
+func RegisterBrain(brain Brain) {
+	options.register(func(r *runner) {
+		r.brain = brain
+	})
+}
+
+// RegisterTaskLocker registers given TaskLocker implementation that prevents scheduled tasks from running on multiple replicas.
+// When this is not called, scheduled tasks run without any lock.
+func RegisterTaskLocker(locker TaskLocker) {
+	options.register(func(r *runner) {
+		r.taskLocker = locker
+	})
+}
+
 // RegisterBotErrorSupervisor registers a given supervising function that is called when a Bot escalates an error.
 // This function judges if the given error is worth being notified to administrators and if the Bot should stop.
 // A developer may return *SupervisionDirective to tell such order.
@@ -188,24 +355,42 @@ func newRunner(ctx context.Context, config *Config) (*runner, error) {
 		return nil, fmt.Errorf(`given timezone "%s" cannot be converted to time.Location: %w`, config.TimeZone, err)
 	}
 
+	botLocs := map[BotType]*time.Location{}
+	for botType, tz := range config.BotTimeZones {
+		botLoc, e := time.LoadLocation(tz)
+		if e != nil {
+			return nil, fmt.Errorf(`given timezone "%s" for %s cannot be converted to time.Location: %w`, tz, botType, e)
+		}
+		botLocs[botType] = botLoc
+	}
+
 	r := &runner{
 		config:             config,
 		bots:               []Bot{},
 		worker:             nil,
+		botWorkers:         map[BotType]workers.Worker{},
+		taskWorker:         nil,
 		configWatcher:      &nullConfigWatcher{},
 		commands:           make(map[BotType][]Command),
 		commandProps:       make(map[BotType][]*CommandProps),
 		scheduledTasks:     make(map[BotType][]ScheduledTask),
 		scheduledTaskProps: make(map[BotType][]*ScheduledTaskProps),
 		alerters:           &alerters{},
+		brain:              nil,
+		taskLocker:         nil,
+		taskRunStore:       nil,
+		taskPauseStore:     nil,
+		location:           loc,
+		botLocations:       botLocs,
 		scheduler:          runScheduler(ctx, loc),
 		superviseError:     nil,
+		classifyInput:      DefaultInputPriority,
 	}
 
 	options.apply(r)
 
 	if r.worker == nil {
-		w, e := workers.Run(ctx, workers.NewConfig())
+		w, e := workers.Run(ctx, workers.NewConfig(), WithTaskQueueGauge())
 		if e != nil {
 			return nil, fmt.Errorf("worker could not run: %w", e)
 		}
@@ -213,6 +398,28 @@ func newRunner(ctx context.Context, config *Config) (*runner, error) {
 		r.worker = w
 	}
 
+	for botType, workerConfig := range config.BotWorkers {
+		if _, ok := r.botWorkers[botType]; ok {
+			// Explicitly registered worker takes priority.
+			continue
+		}
+		if workerConfig == nil {
+			workerConfig = workers.NewConfig()
+		}
+
+		w, e := workers.Run(ctx, workerConfig, workers.WithReporter(NewBotWorkerReporter(botType)))
+		if e != nil {
+			return nil, fmt.Errorf("worker for %s could not run: %w", botType, e)
+		}
+		r.botWorkers[botType] = w
+	}
+
+	if r.brain == nil {
+		r.brain = NewMemoryBrain()
+	}
+
+	pausedTasks.setStore(r.taskPauseStore)
+
 	return r, nil
 }
 
@@ -220,14 +427,24 @@ type runner struct {
 	config             *Config
 	bots               []Bot
 	worker             workers.Worker
+	botWorkers         map[BotType]workers.Worker
+	taskWorker         workers.Worker
 	configWatcher      ConfigWatcher
 	commands           map[BotType][]Command
 	commandProps       map[BotType][]*CommandProps
 	scheduledTasks     map[BotType][]ScheduledTask
 	scheduledTaskProps map[BotType][]*ScheduledTaskProps
 	alerters           *alerters
+	brain              Brain
+	taskLocker         TaskLocker
+	taskRunStore       TaskRunStore
+	taskPauseStore     TaskPauseStore
+	taskGuards         taskGuards
+	location           *time.Location
+	botLocations       map[BotType]*time.Location
 	scheduler          scheduler
 	superviseError     func(BotType, error) *SupervisionDirective
+	classifyInput      func(Input) workers.Priority
 }
 
 // SupervisionDirective tells go-sarah's core how to react when a Bot escalates an error.
@@ -244,6 +461,14 @@ type SupervisionDirective struct {
 	AlertingErr error
 }
 
+// botWorker returns the worker for the given BotType's incoming messages.
+func (r *runner) botWorker(botType BotType) workers.Worker {
+	if worker, ok := r.botWorkers[botType]; ok {
+		return worker
+	}
+	return r.worker
+}
+
 func (r *runner) botCommands(botType BotType) []Command {
 	if commands, ok := r.commands[botType]; ok {
 		return commands
@@ -308,14 +533,27 @@ func unsubscribeConfigWatcher(watcher ConfigWatcher, botType BotType) {
 func (r *runner) runBot(runnerCtx context.Context, bot Bot) {
 	log.Infof("Starting %s", bot.BotType())
 	botCtx, errNotifier := r.superviseBot(runnerCtx, bot.BotType())
+	if r.brain != nil {
+		botCtx = withBotBrain(botCtx, r.brain, bot.BotType())
+		botCtx = withJobScheduler(botCtx, runJobScheduler(botCtx, bot, r.brain))
+	}
 
 	// Build commands with stashed CommandProps.
 	r.registerCommands(botCtx, bot)
 
 	// Register scheduled tasks.
+	// Restore the paused state before the scheduled tasks start firing or catching up.
+	pausedTasks.restore(botCtx, bot.BotType())
 	r.registerScheduledTasks(botCtx, bot)
+	defer triggerableTasks.removeBot(bot.BotType())
+	defer taskEvents.removeBot(bot.BotType())
+	defer holidayCalendars.removeBot(bot.BotType())
+
+	// Let other Bots' tasks address this Bot via Broadcast.
+	runningBots.add(botCtx, bot)
+	defer runningBots.remove(bot.BotType())
 
-	inputReceiver := setupInputReceiver(botCtx, bot, r.worker)
+	inputReceiver := setupInputReceiver(botCtx, bot, r.botWorker(bot.BotType()), r.classifyInput)
 
 	// Run Bot in a panic-proof manner
 	func() {
@@ -446,21 +684,24 @@ func (r *runner) registerCommands(botCtx context.Context, bot Bot) {
 }
 
 func (r *runner) registerScheduledTasks(botCtx context.Context, bot Bot) {
-	reg := func(p *ScheduledTaskProps) {
+	reg := func(p *ScheduledTaskProps) ScheduledTask {
 		r.scheduler.remove(bot.BotType(), p.identifier)
+		triggerableTasks.remove(bot.BotType(), p.identifier)
+		taskEvents.unsubscribe(bot.BotType(), p.identifier)
 
 		task, err := buildScheduledTask(botCtx, p, r.configWatcher)
 		if err != nil {
 			log.Errorf("Failed to build scheduled task %s: %+v", p.identifier, err)
-			return
+			return nil
 		}
 
-		err = r.scheduler.update(bot.BotType(), task, func() {
-			executeScheduledTask(botCtx, bot, task)
-		})
+		err = r.scheduleTask(botCtx, bot, task)
 		if err != nil {
 			log.Errorf("Failed to schedule a task. ID: %s: %+v", task.Identifier(), err)
+			return nil
 		}
+		triggerableTasks.add(bot.BotType(), task.Identifier(), r.triggerScheduledTask(botCtx, bot, task), r.catchUpOnResume(botCtx, bot, task))
+		return task
 	}
 
 	callback := func(p *ScheduledTaskProps) func() {
@@ -471,7 +712,10 @@ func (r *runner) registerScheduledTasks(botCtx context.Context, bot Bot) {
 	}
 
 	for _, p := range r.botScheduledTaskProps(bot.BotType()) {
-		reg(p)
+		// Missed executions are caught up on Bot start and on ResumeTask, not on the rebuild by configuration update.
+		if task := reg(p); task != nil {
+			go r.catchUpScheduledTask(botCtx, bot, task)
+		}
 		err := r.configWatcher.Watch(botCtx, bot.BotType(), p.identifier, callback(p))
 		if err != nil {
 			log.Errorf("Failed to subscribe configuration for scheduled task %s: %+v", p.identifier, err)
@@ -480,30 +724,252 @@ func (r *runner) registerScheduledTasks(botCtx context.Context, bot Bot) {
 	}
 
 	for _, task := range r.botScheduledTasks(bot.BotType()) {
-		if task.Schedule() == "" {
+		if task.Schedule() == "" && len(taskEventNames(task)) == 0 {
 			log.Errorf("Failed to schedule a task. ID: %s. Reason: %s.", task.Identifier(), "No schedule given.")
 			continue
 		}
 
-		err := r.scheduler.update(bot.BotType(), task, func() {
-			executeScheduledTask(botCtx, bot, task)
-		})
+		err := r.scheduleTask(botCtx, bot, task)
 		if err != nil {
 			log.Errorf("Failed to schedule a task. id: %s: %+v", task.Identifier(), err)
+			continue
 		}
+		triggerableTasks.add(bot.BotType(), task.Identifier(), r.triggerScheduledTask(botCtx, bot, task), r.catchUpOnResume(botCtx, bot, task))
+		go r.catchUpScheduledTask(botCtx, bot, task)
 	}
 }
 
-func executeScheduledTask(ctx context.Context, bot Bot, task ScheduledTask) {
-	results, err := task.Execute(ctx)
+// scheduleTask registers the given task to the scheduler in the task's time zone.
+// The next execution time is then reported in the task's time zone via CurrentStatus.
+// When the task is EventTriggeredTask, the task also subscribes to the events; a task without schedule is executed only on the events.
+func (r *runner) scheduleTask(botCtx context.Context, bot Bot, task ScheduledTask) error {
+	if events := taskEventNames(task); len(events) > 0 {
+		taskEvents.subscribe(bot.BotType(), task.Identifier(), events, func(event *Event) {
+			r.dispatchScheduledTask(WithEvent(botCtx, event), bot, task, time.Time{})
+		})
+	}
+	if task.Schedule() == "" {
+		return nil
+	}
+
+	// Load the holiday calendar beforehand so its update is subscribed to before the first execution.
+	if name, _ := taskCalendar(task); name != "" {
+		holidayCalendars.load(botCtx, bot.BotType(), name, r.configWatcher)
+	}
+
+	loc := r.botLocation(bot.BotType())
+	schedule, err := parseTaskSchedule(task, loc)
 	if err != nil {
-		log.Errorf("Error on scheduled task: %s", task.Identifier())
+		return err
+	}
+
+	err = r.scheduler.update(bot.BotType(), task, loc, func() {
+		// The scheduler may fire a little late, so the occurrence is computed from the schedule instead of the current time.
+		r.dispatchScheduledOccurrence(botCtx, bot, task, loc, scheduledOccurrence(schedule, time.Now()))
+	})
+	if err != nil {
+		return err
+	}
+
+	history := runnerStatus.taskHistory(bot.BotType(), task.Identifier(), r.config.TaskHistorySize)
+	history.setSchedule(schedule, taskLocation(task, loc))
+	return nil
+}
+
+// runScheduledTask executes the given task and records the execution.
+// When the task fails consecutively or recovers from such failures, registered Alerters are notified.
+// The occurrence scheduled at scheduledAt is stored as the last run via TaskRunStore,
+// while neither the execution triggered by an event nor the one triggered via TriggerTask is stored since they do not run any scheduled occurrence.
+func (r *runner) runScheduledTask(ctx context.Context, bot Bot, task ScheduledTask, scheduledAt time.Time) ([]*ScheduledTaskResult, error) {
+	startedAt := time.Now()
+	// Failed execution is retried until the next scheduled execution so the retrial does not overlap with it.
+	retryUntil := nextScheduledRun(task, r.botLocation(bot.BotType()), startedAt)
+	results, err := executeScheduledTask(ctx, bot, task, retryUntil, false)
+	execution := &TaskExecution{
+		StartedAt:   startedAt,
+		Duration:    time.Since(startedAt),
+		Err:         err,
+		ResultCount: len(results),
+	}
+
+	if (err == nil || isBroadcastError(err)) && !scheduledAt.IsZero() && !isTriggeredRun(ctx) {
+		// The results are already delivered to some targets, so the execution is not caught up again.
+		r.recordLastRun(ctx, bot, task, scheduledAt)
+	}
+
+	history := runnerStatus.taskHistory(bot.BotType(), task.Identifier(), r.config.TaskHistorySize)
+	notice := history.record(execution, r.config.TaskFailureAlertThreshold)
+	if notice != nil {
+		e := r.alerters.alertAll(ctx, bot.BotType(), notice)
+		if e != nil {
+			log.Errorf("Failed to send alert for scheduled task %s: %+v", task.Identifier(), e)
+		}
+	}
+
+	return results, err
+}
+
+// runGuardedScheduledTask executes the given task in accordance with the task's OverlapPolicy.
+// scheduledAt is the time the execution is scheduled at, and is zero for the execution triggered by an event.
+func (r *runner) runGuardedScheduledTask(ctx context.Context, bot Bot, task ScheduledTask, scheduledAt time.Time) {
+	policy := taskOverlapPolicy(task)
+	if policy == OverlapAllow {
+		_, _ = r.runLockedScheduledTask(ctx, bot, task, scheduledAt)
 		return
+	}
+
+	guard := r.taskGuards.get(bot.BotType(), task.Identifier())
+	executed := guard.run(policy, func() {
+		_, _ = r.runLockedScheduledTask(ctx, bot, task, scheduledAt)
+	})
+	if !executed {
+		log.Infof("Skipping scheduled task %s since the previous execution is still running.", task.Identifier())
+		runnerStatus.taskHistory(bot.BotType(), task.Identifier(), r.config.TaskHistorySize).skip()
+	}
+}
+
+// runLockedScheduledTask executes the given task when the lock for the occurrence scheduled at scheduledAt is acquired via the registered TaskLocker.
+// The lease is renewed while the task runs, and is kept until it expires after the execution so other replicas skip the same occurrence.
+// The lock is released when the execution fails so another replica can take over.
+// ErrTaskLocked is returned when the lock is held by another process.
+//
+// The execution triggered by an event is not locked since the event is published and delivered within the process.
+func (r *runner) runLockedScheduledTask(ctx context.Context, bot Bot, task ScheduledTask, scheduledAt time.Time) ([]*ScheduledTaskResult, error) {
+	_, triggered := EventFromContext(ctx)
+	if r.taskLocker == nil || triggered {
+		return r.runScheduledTask(ctx, bot, task, scheduledAt)
+	}
+
+	key, ttl := taskLock(bot.BotType(), task, r.config.TaskLockTTL)
+	key = occurrenceLockKey(key, scheduledAt)
+	locked, err := r.taskLocker.TryLock(ctx, key, ttl)
+	if err != nil {
+		log.Errorf("Failed to acquire lock for scheduled task %s: %+v", task.Identifier(), err)
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	} else if !locked {
+		log.Infof("Skipping scheduled task %s since the lock is held by another process.", task.Identifier())
+		return nil, ErrTaskLocked
+	}
+
+	stopRenewal := r.renewTaskLock(ctx, task, key, ttl)
+	results, err := r.runScheduledTask(ctx, bot, task, scheduledAt)
+	stopRenewal()
+	if err == nil || isBroadcastError(err) {
+		// Keep the lock on partial delivery failure so other replicas do not deliver the same results again.
+		return results, err
+	}
+
+	e := r.taskLocker.Unlock(ctx, key)
+	if e != nil {
+		log.Errorf("Failed to release lock for scheduled task %s: %+v", task.Identifier(), e)
+	}
+	return results, err
+}
+
+// renewTaskLock renews the lease of the given lock at half the interval of ttl so the lease does not expire during a long execution.
+// The returned function stops the renewal.
+func (r *runner) renewTaskLock(ctx context.Context, task ScheduledTask, key string, ttl time.Duration) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-done:
+				return
+
+			case <-ticker.C:
+				renewed, err := r.taskLocker.Renew(ctx, key, ttl)
+				if err != nil {
+					log.Errorf("Failed to renew lock for scheduled task %s: %+v", task.Identifier(), err)
+				} else if !renewed {
+					log.Warnf("Lock for scheduled task %s is lost while the task is running.", task.Identifier())
+					return
+				}
+
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// triggerScheduledTask returns a function that executes the given task on demand via TriggerTask.
+// The execution is guarded by the task's OverlapPolicy and locked via TaskLocker in the same way as the scheduled execution.
+// Since the caller waits for the results, the execution is not queued even with OverlapQueueOne; ErrTaskRunning is returned instead.
+func (r *runner) triggerScheduledTask(botCtx context.Context, bot Bot, task ScheduledTask) func(bool) ([]*ScheduledTaskResult, error) {
+	return func(dryRun bool) ([]*ScheduledTaskResult, error) {
+		if botCtx.Err() != nil {
+			return nil, ErrTaskNotFound
+		}
+
+		if dryRun {
+			return executeScheduledTask(botCtx, bot, task, time.Time{}, true)
+		}
+
+		var results []*ScheduledTaskResult
+		var err error
+		run := func() {
+			results, err = r.runLockedScheduledTask(withTriggeredRun(botCtx), bot, task, time.Now().Truncate(time.Second))
+		}
+
+		policy := taskOverlapPolicy(task)
+		if policy == OverlapAllow {
+			run()
+			return results, err
+		}
+
+		executed := r.taskGuards.get(bot.BotType(), task.Identifier()).run(OverlapSkip, run)
+		if !executed {
+			return nil, ErrTaskRunning
+		}
+		return results, err
+	}
+}
+
+// executeScheduledTask executes the given task and sends the results unless dryRun is true.
+// This returns the results with their destinations filled with the task's default destination if necessary.
+// A result without any destination is not sent nor returned.
+// The failed execution is retried in accordance with the task's retry policy until retryUntil comes unless retryUntil is zero.
+// When the results are not broadcast to some of the targets, the results are returned along with *BroadcastError that contains all failures.
+func executeScheduledTask(ctx context.Context, bot Bot, task ScheduledTask, retryUntil time.Time, dryRun bool) ([]*ScheduledTaskResult, error) {
+	results, err := executeTaskWithRetry(withPluginBrainFor(ctx, task.Identifier()), task, retryUntil)
+	if err != nil {
+		log.Errorf("Error on scheduled task: %s: %+v", task.Identifier(), err)
+		return nil, err
 	} else if results == nil {
-		return
+		return nil, nil
 	}
 
+	var delivered []*ScheduledTaskResult
+	var failures []*BroadcastFailure
 	for _, res := range results {
+		// The result may be addressed to multiple Bots.
+		// Failures to some Bots do not prevent the delivery to the others.
+		if len(res.Broadcast) > 0 {
+			delivered = append(delivered, &ScheduledTaskResult{
+				Content:   res.Content,
+				Broadcast: res.Broadcast,
+			})
+			if dryRun {
+				continue
+			}
+
+			err := Broadcast(res.Content, res.Broadcast...)
+			var broadcastErr *BroadcastError
+			if errors.As(err, &broadcastErr) {
+				log.Errorf("Failed to broadcast result of scheduled task %s: %+v", task.Identifier(), err)
+				failures = append(failures, broadcastErr.Failures...)
+			}
+			continue
+		}
+
 		// The destination returned by task execution has higher priority.
 		// e.g. RSS Reader's task searches for stored feed/destination set, and returns which destination to send.
 		dest := res.Destination
@@ -519,20 +985,66 @@ func executeScheduledTask(ctx context.Context, bot Bot, task ScheduledTask) {
 			dest = presetDest
 		}
 
+		delivered = append(delivered, &ScheduledTaskResult{
+			Content:     res.Content,
+			Destination: dest,
+		})
+		if dryRun {
+			continue
+		}
+
 		message := NewOutputMessage(dest, res.Content)
 		bot.SendMessage(ctx, message)
 	}
+
+	if len(failures) > 0 {
+		return delivered, &BroadcastError{Failures: failures}
+	}
+	return delivered, nil
+}
+
+// isBroadcastError tells if the given error is returned by executeScheduledTask on partial delivery failure.
+func isBroadcastError(err error) bool {
+	var broadcastErr *BroadcastError
+	return errors.As(err, &broadcastErr)
+}
+
+// DefaultInputPriority is the default classifier of incoming messages.
+// HelpInput and AbortInput jump the queue of ordinary messages.
+func DefaultInputPriority(input Input) workers.Priority {
+	switch input.(type) {
+	case *HelpInput, *AbortInput:
+		return workers.PriorityHigh
+
+	default:
+		return workers.PriorityNormal
+
+	}
 }
 
-func setupInputReceiver(botCtx context.Context, bot Bot, worker workers.Worker) func(Input) error {
+// setupInputReceiver returns a function that enqueues the given input to the worker.
+// When the worker satisfies workers.PriorityWorker, the input is enqueued with the priority given by classify.
+// The input that the Bot's administrative command such as the one given by NewTriggerTaskCommandProps prioritizes is always enqueued with workers.PriorityHigh.
+func setupInputReceiver(botCtx context.Context, bot Bot, worker workers.Worker, classify func(Input) workers.Priority) func(Input) error {
 	continuousEnqueueErrCnt := 0
 	return func(input Input) error {
-		err := worker.Enqueue(func() {
+		job := func() {
 			err := bot.Respond(botCtx, input)
 			if err != nil {
 				log.Errorf("Error on message handling. Input: %#v. Error: %+v", input, err)
 			}
-		})
+		}
+
+		var err error
+		if priorityWorker, ok := worker.(workers.PriorityWorker); ok && classify != nil {
+			priority := classify(input)
+			if p, ok := bot.(interface{ prioritized(Input) bool }); ok && p.prioritized(input) {
+				priority = workers.PriorityHigh
+			}
+			err = priorityWorker.EnqueueWithPriority(job, priority)
+		} else {
+			err = worker.Enqueue(job)
+		}
 
 		if err == nil {
 			continuousEnqueueErrCnt = 0
@@ -541,6 +1053,7 @@ func setupInputReceiver(botCtx context.Context, bot Bot, worker workers.Worker)
 		}
 
 		continuousEnqueueErrCnt++
+		runnerStatus.addBlockedInput(bot.BotType())
 		// Could not send because probably the workers are too busy or the runner context is already canceled.
 		return NewBlockedInputError(continuousEnqueueErrCnt)
 	}
@@ -2,9 +2,12 @@ package sarah
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/oklahomer/go-sarah/v3/log"
+	"github.com/oklahomer/go-sarah/v3/workers"
+	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	stdLogger "log"
 	"os"
@@ -34,6 +37,11 @@ func SetupAndRun(fnc func()) {
 	// Initialize package variables
 	runnerStatus = &status{}
 	options = &optionHolder{}
+	triggerableTasks = &taskRegistry{}
+	pausedTasks = &pauseRegistry{}
+	taskEvents = &eventRegistry{}
+	runningBots = &botRegistry{}
+	holidayCalendars = &calendarRegistry{}
 
 	fnc()
 }
@@ -71,6 +79,35 @@ func TestNewConfig(t *testing.T) {
 	}
 }
 
+func TestBotWorkerConfigs_Unmarshal(t *testing.T) {
+	jsonConfig := NewConfig()
+	err := json.Unmarshal([]byte(`{"bot_workers": {"slack": {"worker_num": 5}, "gitter": null}}`), jsonConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	yamlConfig := NewConfig()
+	err = yaml.Unmarshal([]byte("bot_workers:\n  slack:\n    worker_num: 5\n  gitter:\n"), yamlConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	for _, config := range []*Config{jsonConfig, yamlConfig} {
+		slackConfig := config.BotWorkers["slack"]
+		if slackConfig == nil || slackConfig.WorkerNum != 5 {
+			t.Fatalf("Given value is not set: %#v.", slackConfig)
+		}
+		if slackConfig.QueueSize != workers.NewConfig().QueueSize {
+			t.Errorf("Omitted field should take the default value: %d.", slackConfig.QueueSize)
+		}
+
+		gitterConfig := config.BotWorkers["gitter"]
+		if gitterConfig == nil || gitterConfig.WorkerNum != workers.NewConfig().WorkerNum {
+			t.Errorf("Empty entry should take the default values: %#v.", gitterConfig)
+		}
+	}
+}
+
 func Test_optionHolder_register(t *testing.T) {
 	opt := func(_ *runner) {}
 	holder := &optionHolder{}
@@ -265,6 +302,22 @@ func TestRegisterConfigWatcher(t *testing.T) {
 	})
 }
 
+func TestRegisterTaskLocker(t *testing.T) {
+	SetupAndRun(func() {
+		locker := NewMemoryTaskLocker()
+		RegisterTaskLocker(locker)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.taskLocker != locker {
+			t.Error("Given TaskLocker is not set.")
+		}
+	})
+}
+
 func TestRegisterWorker(t *testing.T) {
 	SetupAndRun(func() {
 		worker := &DummyWorker{}
@@ -284,6 +337,115 @@ func TestRegisterWorker(t *testing.T) {
 	})
 }
 
+func TestRegisterBotWorker(t *testing.T) {
+	SetupAndRun(func() {
+		worker := &DummyWorker{}
+		RegisterBotWorker("gitter", worker)
+		r := &runner{
+			worker:     &DummyWorker{},
+			botWorkers: map[BotType]workers.Worker{},
+		}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.botWorker("gitter") != worker {
+			t.Error("Given Worker is not set.")
+		}
+		if r.botWorker("slack") != r.worker {
+			t.Error("Shared Worker should be used for the Bot without dedicated worker.")
+		}
+	})
+}
+
+func TestRegisterTaskWorker(t *testing.T) {
+	SetupAndRun(func() {
+		worker := &DummyWorker{}
+		RegisterTaskWorker(worker)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.taskWorker != worker {
+			t.Error("Given Worker is not set.")
+		}
+	})
+}
+
+func TestRegisterInputClassifier(t *testing.T) {
+	SetupAndRun(func() {
+		classified := false
+		RegisterInputClassifier(func(_ Input) workers.Priority {
+			classified = true
+			return workers.PriorityLow
+		})
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.classifyInput == nil {
+			t.Fatal("Given classifier is not set.")
+		}
+		r.classifyInput(&DummyInput{})
+		if !classified {
+			t.Error("Given classifier is not set.")
+		}
+	})
+}
+
+func TestRegisterTaskRunStore(t *testing.T) {
+	SetupAndRun(func() {
+		store := &DummyTaskRunStore{}
+		RegisterTaskRunStore(store)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.taskRunStore != store {
+			t.Error("Given TaskRunStore is not set.")
+		}
+	})
+}
+
+func TestRegisterTaskPauseStore(t *testing.T) {
+	SetupAndRun(func() {
+		store := &DummyTaskPauseStore{}
+		RegisterTaskPauseStore(store)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.taskPauseStore != store {
+			t.Error("Given TaskPauseStore is not set.")
+		}
+	})
+}
+
+func TestRegisterBrain(t *testing.T) {
+	SetupAndRun(func() {
+		brain := NewMemoryBrain()
+		RegisterBrain(brain)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.brain != brain {
+			t.Error("Given Brain is not set.")
+		}
+	})
+}
+
 func TestRegisterBotErrorSupervisor(t *testing.T) {
 	SetupAndRun(func() {
 		supervisor := func(_ BotType, _ error) *SupervisionDirective {
@@ -365,6 +527,42 @@ func Test_newRunner(t *testing.T) {
 		if r.worker == nil {
 			t.Error("Default Worker should be set.")
 		}
+
+		if r.brain == nil {
+			t.Error("Default Brain should be set.")
+		}
+	})
+}
+
+func Test_newRunner_WithBotWorkers(t *testing.T) {
+	SetupAndRun(func() {
+		registered := &DummyWorker{}
+		RegisterBotWorker("slack", registered)
+		config := &Config{
+			TimeZone: time.UTC.String(),
+			BotWorkers: map[BotType]*workers.Config{
+				"slack":  workers.NewConfig(),
+				"gitter": workers.NewConfig(),
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		r, e := newRunner(ctx, config)
+		if e != nil {
+			t.Fatalf("Unexpected error is returned: %s.", e.Error())
+		}
+
+		if r.botWorker("slack") != registered {
+			t.Error("Registered Worker should take priority.")
+		}
+		gitterWorker := r.botWorker("gitter")
+		if gitterWorker == nil || gitterWorker == r.worker {
+			t.Error("Dedicated Worker is not created.")
+		}
+		if r.botWorker("line") != r.worker {
+			t.Error("Shared Worker should be used for the Bot without dedicated worker.")
+		}
 	})
 }
 
@@ -381,6 +579,22 @@ func Test_newRunner_WithTimeZoneError(t *testing.T) {
 	})
 }
 
+func Test_newRunner_WithBotTimeZoneError(t *testing.T) {
+	SetupAndRun(func() {
+		config := &Config{
+			TimeZone: time.UTC.String(),
+			BotTimeZones: map[BotType]string{
+				"dummy": "DUMMY",
+			},
+		}
+
+		_, e := newRunner(context.Background(), config)
+		if e == nil {
+			t.Fatal("Expected error is not returned.")
+		}
+	})
+}
+
 func Test_runner_run(t *testing.T) {
 	SetupAndRun(func() {
 		var botType BotType = "myBot"
@@ -517,7 +731,7 @@ func Test_runner_runBot(t *testing.T) {
 				},
 			},
 			scheduler: &DummyScheduler{
-				UpdateFunc: func(_ BotType, _ ScheduledTask, _ func()) error {
+				UpdateFunc: func(_ BotType, _ ScheduledTask, _ *time.Location, _ func()) error {
 					return nil
 				},
 				RemoveFunc: func(_ BotType, _ string) {},
@@ -795,6 +1009,30 @@ func Test_runner_superviseBot(t *testing.T) {
 	}
 }
 
+func Test_executeScheduledTask_WithBrain(t *testing.T) {
+	var botType BotType = "dummy"
+	ctx := withBotBrain(context.Background(), NewMemoryBrain(), botType)
+
+	var given *PluginBrain
+	task := &DummyScheduledTask{
+		IdentifierValue: "taskID",
+		ExecuteFunc: func(ctx context.Context) ([]*ScheduledTaskResult, error) {
+			given, _ = BrainFromContext(ctx)
+			return nil, nil
+		},
+	}
+
+	_, _ = executeScheduledTask(ctx, &DummyBot{}, task, time.Time{}, false)
+
+	if given == nil {
+		t.Fatal("PluginBrain is not passed.")
+	}
+
+	if given.Namespace() != BrainNamespace(botType, task.IdentifierValue) {
+		t.Errorf("Unexpected namespace is given: %s.", given.Namespace())
+	}
+}
+
 func Test_executeScheduledTask(t *testing.T) {
 	SetupAndRun(func() {
 		dummyContent := "dummy content"
@@ -836,7 +1074,7 @@ func Test_executeScheduledTask(t *testing.T) {
 					mutex: &sync.RWMutex{},
 				},
 			}
-			executeScheduledTask(context.TODO(), dummyBot, task)
+			_, _ = executeScheduledTask(context.TODO(), dummyBot, task, time.Time{}, false)
 		}
 
 		if len(sendingOutput) != 2 {
@@ -851,6 +1089,143 @@ func Test_executeScheduledTask(t *testing.T) {
 	})
 }
 
+func Test_runner_runScheduledTask(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		var alerts []error
+		r := &runner{
+			config: &Config{
+				TaskHistorySize:           5,
+				TaskFailureAlertThreshold: 2,
+			},
+			alerters: &alerters{
+				&DummyAlerter{
+					AlertFunc: func(_ context.Context, _ BotType, err error) error {
+						alerts = append(alerts, err)
+						return nil
+					},
+				},
+			},
+		}
+
+		taskErr := errors.New("task error")
+		var returning []error
+		task := &DummyScheduledTask{
+			IdentifierValue: "report",
+			ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				err := returning[0]
+				returning = returning[1:]
+				return nil, err
+			},
+		}
+		bot := &DummyBot{BotTypeValue: botType}
+
+		returning = []error{taskErr, taskErr, taskErr, nil}
+		for i := 0; i < 4; i++ {
+			_, _ = r.runScheduledTask(context.TODO(), bot, task, time.Now())
+		}
+
+		if len(alerts) != 2 {
+			t.Fatalf("Unexpected number of alerts are sent: %#v.", alerts)
+		}
+
+		failure, ok := alerts[0].(*ScheduledTaskFailure)
+		if !ok {
+			t.Fatalf("Unexpected alert is sent: %#v.", alerts[0])
+		}
+		if failure.ConsecutiveFailures != 2 || !errors.Is(failure, taskErr) {
+			t.Errorf("Unexpected failure is notified: %#v.", failure)
+		}
+
+		recovery, ok := alerts[1].(*ScheduledTaskRecovery)
+		if !ok {
+			t.Fatalf("Unexpected alert is sent: %#v.", alerts[1])
+		}
+		if recovery.Failures != 3 {
+			t.Errorf("Unexpected recovery is notified: %#v.", recovery)
+		}
+
+		history := ScheduledTaskHistory(botType, task.IdentifierValue)
+		if len(history) != 4 {
+			t.Fatalf("Unexpected number of executions are recorded: %d.", len(history))
+		}
+		if history[0].Err != taskErr || history[3].Err != nil {
+			t.Errorf("Unexpected executions are recorded: %#v.", history)
+		}
+	})
+}
+
+func Test_runner_runLockedScheduledTask_WithBroadcastFailure(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		var alerts []error
+		lastRun := time.Time{}
+		unlocked := false
+		r := &runner{
+			config: &Config{
+				TaskHistorySize:           3,
+				TaskFailureAlertThreshold: 1,
+				TaskLockTTL:               time.Minute,
+			},
+			alerters: &alerters{
+				&DummyAlerter{
+					AlertFunc: func(_ context.Context, _ BotType, err error) error {
+						alerts = append(alerts, err)
+						return nil
+					},
+				},
+			},
+			taskRunStore: &DummyTaskRunStore{
+				LastRunFunc: func(_ context.Context, _ BotType, _ string) (time.Time, error) {
+					return lastRun, nil
+				},
+				SetLastRunFunc: func(_ context.Context, _ BotType, _ string, ranAt time.Time) error {
+					lastRun = ranAt
+					return nil
+				},
+			},
+			taskLocker: &DummyTaskLocker{
+				TryLockFunc: func(_ context.Context, _ string, _ time.Duration) (bool, error) {
+					return true, nil
+				},
+				UnlockFunc: func(_ context.Context, _ string) error {
+					unlocked = true
+					return nil
+				},
+			},
+		}
+
+		task := &DummyScheduledTask{
+			IdentifierValue: "announcement",
+			ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				return []*ScheduledTaskResult{
+					{Content: "text", Broadcast: []*BroadcastTarget{{BotType: "stopped", Destination: "room"}}},
+				}, nil
+			},
+		}
+
+		_, err := r.runLockedScheduledTask(context.TODO(), &DummyBot{BotTypeValue: botType}, task, time.Now())
+
+		var broadcastErr *BroadcastError
+		if !errors.As(err, &broadcastErr) {
+			t.Fatalf("Expected error is not returned: %#v.", err)
+		}
+		history := ScheduledTaskHistory(botType, task.IdentifierValue)
+		if len(history) != 1 || !history[0].Failed() {
+			t.Errorf("Partial failure is not recorded: %#v.", history)
+		}
+		if len(alerts) != 1 || !errors.As(alerts[0], &broadcastErr) {
+			t.Errorf("Partial failure is not alerted: %#v.", alerts)
+		}
+		if lastRun.IsZero() {
+			t.Error("Execution with partial failure should be recorded as the last run.")
+		}
+		if unlocked {
+			t.Error("Lock should be kept on partial failure.")
+		}
+	})
+}
+
 func Test_setupInputReceiver(t *testing.T) {
 	SetupAndRun(func() {
 		responded := make(chan bool, 1)
@@ -869,7 +1244,7 @@ func Test_setupInputReceiver(t *testing.T) {
 			},
 		}
 
-		receiveInput := setupInputReceiver(context.TODO(), bot, worker)
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, DefaultInputPriority)
 		if err := receiveInput(&DummyInput{}); err != nil {
 			t.Errorf("Error should not be returned at this point: %s.", err.Error())
 		}
@@ -883,6 +1258,88 @@ func Test_setupInputReceiver(t *testing.T) {
 	})
 }
 
+type DummyPriorityWorker struct {
+	DummyWorker
+	EnqueueWithPriorityFunc func(func(), workers.Priority) error
+}
+
+func (w *DummyPriorityWorker) EnqueueWithPriority(fnc func(), priority workers.Priority) error {
+	return w.EnqueueWithPriorityFunc(fnc, priority)
+}
+
+func Test_setupInputReceiver_WithPriority(t *testing.T) {
+	SetupAndRun(func() {
+		var priorities []workers.Priority
+		worker := &DummyPriorityWorker{
+			EnqueueWithPriorityFunc: func(_ func(), priority workers.Priority) error {
+				priorities = append(priorities, priority)
+				return nil
+			},
+		}
+
+		receiveInput := setupInputReceiver(context.TODO(), &DummyBot{}, worker, DefaultInputPriority)
+		inputs := []Input{
+			&DummyInput{MessageValue: "hello"},
+			NewAbortInput(&DummyInput{MessageValue: ".abort"}),
+			NewHelpInput(&DummyInput{MessageValue: ".help"}),
+			&DummyInput{MessageValue: ".task run daily_report"},
+		}
+		for _, input := range inputs {
+			if err := receiveInput(input); err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+		}
+
+		// The administrative command is not registered, so it does not jump the queue.
+		expected := []workers.Priority{workers.PriorityNormal, workers.PriorityHigh, workers.PriorityHigh, workers.PriorityNormal}
+		if !reflect.DeepEqual(priorities, expected) {
+			t.Errorf("Unexpected priorities are given: %v.", priorities)
+		}
+	})
+}
+
+func Test_setupInputReceiver_WithPrioritizedCommand(t *testing.T) {
+	SetupAndRun(func() {
+		var priorities []workers.Priority
+		worker := &DummyPriorityWorker{
+			EnqueueWithPriorityFunc: func(_ func(), priority workers.Priority) error {
+				priorities = append(priorities, priority)
+				return nil
+			},
+		}
+
+		props, err := NewTriggerTaskCommandProps("dummy", func(input Input) bool {
+			return input.SenderKey() == "admin"
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		command, err := buildCommand(context.TODO(), props, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		bot := &defaultBot{commands: NewCommands()}
+		bot.AppendCommand(command)
+
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, DefaultInputPriority)
+		inputs := []Input{
+			&DummyInput{SenderKeyValue: "admin", MessageValue: ".task run daily_report"},
+			&DummyInput{SenderKeyValue: "guest", MessageValue: ".task run daily_report"},
+			&DummyInput{SenderKeyValue: "admin", MessageValue: "hello"},
+		}
+		for _, input := range inputs {
+			if err := receiveInput(input); err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+		}
+
+		expected := []workers.Priority{workers.PriorityHigh, workers.PriorityNormal, workers.PriorityNormal}
+		if !reflect.DeepEqual(priorities, expected) {
+			t.Errorf("Unexpected priorities are given: %v.", priorities)
+		}
+	})
+}
+
 func Test_setupInputReceiver_BlockedInputError(t *testing.T) {
 	SetupAndRun(func() {
 		bot := &DummyBot{}
@@ -892,7 +1349,7 @@ func Test_setupInputReceiver_BlockedInputError(t *testing.T) {
 			},
 		}
 
-		receiveInput := setupInputReceiver(context.TODO(), bot, worker)
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, DefaultInputPriority)
 		err := receiveInput(&DummyInput{})
 		if err == nil {
 			t.Fatal("Expected error is not returned.")
@@ -901,6 +1358,10 @@ func Test_setupInputReceiver_BlockedInputError(t *testing.T) {
 		if _, ok := err.(*BlockedInputError); !ok {
 			t.Fatalf("Expected error type is not returned: %T.", err)
 		}
+
+		if runnerStatus.blockedInputs[bot.BotType()] != 1 {
+			t.Errorf("Blocked input is not counted: %d.", runnerStatus.blockedInputs[bot.BotType()])
+		}
 	})
 }
 
@@ -1126,6 +1587,7 @@ func Test_registerScheduledTasks(t *testing.T) {
 					BotTypeValue: botType,
 				}
 				r := &runner{
+					config:        &Config{TaskHistorySize: 3},
 					configWatcher: tt.configWatcher,
 					scheduledTaskProps: map[BotType][]*ScheduledTaskProps{
 						botType: tt.props,
@@ -1134,7 +1596,7 @@ func Test_registerScheduledTasks(t *testing.T) {
 						botType: tt.tasks,
 					},
 					scheduler: &DummyScheduler{
-						UpdateFunc: func(_ BotType, _ ScheduledTask, _ func()) error {
+						UpdateFunc: func(_ BotType, _ ScheduledTask, _ *time.Location, _ func()) error {
 							if tt.updateError {
 								return errors.New("update error")
 							}
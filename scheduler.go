@@ -10,7 +10,7 @@ import (
 
 type scheduler interface {
 	remove(BotType, string)
-	update(BotType, ScheduledTask, func()) error
+	update(BotType, ScheduledTask, *time.Location, func()) error
 }
 
 type taskScheduler struct {
@@ -27,12 +27,15 @@ func (s *taskScheduler) remove(botType BotType, taskID string) {
 	s.removingTask <- remove
 }
 
-func (s *taskScheduler) update(botType BotType, task ScheduledTask, fn func()) error {
+// update registers the given task or replaces the registered one.
+// The task's schedule is interpreted in the given location unless the task has its own time zone.
+func (s *taskScheduler) update(botType BotType, task ScheduledTask, location *time.Location, fn func()) error {
 	add := &updatingTask{
-		botType: botType,
-		task:    task,
-		fn:      fn,
-		err:     make(chan error, 1),
+		botType:  botType,
+		task:     task,
+		location: location,
+		fn:       fn,
+		err:      make(chan error, 1),
 	}
 	s.updatingTask <- add
 
@@ -45,10 +48,11 @@ type removingTask struct {
 }
 
 type updatingTask struct {
-	botType BotType
-	task    ScheduledTask
-	fn      func()
-	err     chan error
+	botType  BotType
+	task     ScheduledTask
+	location *time.Location
+	fn       func()
+	err      chan error
 }
 
 func runScheduler(ctx context.Context, location *time.Location) scheduler {
@@ -106,7 +110,7 @@ func (s *taskScheduler) receiveEvent(ctx context.Context) {
 
 			removeFunc(add.botType, add.task.Identifier())
 
-			id, err := s.cron.AddFunc(add.task.Schedule(), add.fn)
+			id, err := s.cron.AddFunc(taskScheduleSpec(add.task, add.location), add.fn)
 			if err != nil {
 				add.err <- err
 				break
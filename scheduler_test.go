@@ -8,15 +8,15 @@ import (
 
 type DummyScheduler struct {
 	RemoveFunc func(BotType, string)
-	UpdateFunc func(BotType, ScheduledTask, func()) error
+	UpdateFunc func(BotType, ScheduledTask, *time.Location, func()) error
 }
 
 func (s *DummyScheduler) remove(botType BotType, taskID string) {
 	s.RemoveFunc(botType, taskID)
 }
 
-func (s *DummyScheduler) update(botType BotType, task ScheduledTask, fn func()) error {
-	return s.UpdateFunc(botType, task, fn)
+func (s *DummyScheduler) update(botType BotType, task ScheduledTask, location *time.Location, fn func()) error {
+	return s.UpdateFunc(botType, task, location, fn)
 }
 
 func Test_runScheduler(t *testing.T) {
@@ -56,12 +56,12 @@ func TestTaskScheduler_updateAndRemove(t *testing.T) {
 	}
 
 	var storedBotType BotType = "Foo"
-	if err := scheduler.update(storedBotType, task, func() {}); err == nil {
+	if err := scheduler.update(storedBotType, task, time.UTC, func() {}); err == nil {
 		t.Fatal("Error should return on invalid schedule value.")
 	}
 
 	task.schedule = "@daily"
-	if err := scheduler.update(storedBotType, task, func() {}); err != nil {
+	if err := scheduler.update(storedBotType, task, time.UTC, func() {}); err != nil {
 		t.Fatalf("Error is returned on valid schedule value: %s", err.Error())
 	}
 	time.Sleep(10 * time.Millisecond)
@@ -89,7 +89,7 @@ func TestTaskScheduler_updateWithEmptySchedule(t *testing.T) {
 	defer cancel()
 	scheduler := runScheduler(ctx, time.Local)
 
-	err := scheduler.update("dummy", &DummyScheduledTask{}, func() {})
+	err := scheduler.update("dummy", &DummyScheduledTask{}, time.UTC, func() {})
 
 	if err == nil {
 		t.Error("Expected error is not returned.")
@@ -0,0 +1,132 @@
+package sarah
+
+// ScopedInput is an optional interface that Input implementation may satisfy to expose structured sender information.
+// Built-in ScopeResolver implementations use these values to derive the key to store users' conversational contexts.
+//
+// See slack.Input and gitter.RoomMessage.
+type ScopedInput interface {
+	Input
+
+	// UserID returns the identifier of the sending user.
+	UserID() string
+
+	// ChannelID returns the identifier of the channel, room, or group the message is sent to.
+	ChannelID() string
+
+	// ThreadID returns the identifier of the thread the message belongs to.
+	// This returns an empty string when the chat service has no concept of thread.
+	ThreadID() string
+}
+
+// ScopeResolver defines an interface that derives the key to store and look up a conversational context for the given Input.
+// By switching the ScopeResolver, a conversation can be shared by everyone in a channel, bound to a thread,
+// or carried by a user across channels.
+//
+// A ScopeResolver can be set to defaultBot via BotWithScopeResolver, to a Command via CommandPropsBuilder.Scope, or to each UserContext.
+type ScopeResolver interface {
+	// ID returns the unique identifier of this strategy.
+	// This is prepended to the derived key so the same value derived by different strategies never collide.
+	ID() string
+
+	// Key derives the key from the given Input.
+	// An empty string is returned when this strategy is not applicable to the given Input.
+	Key(Input) string
+}
+
+type scopeResolver struct {
+	id  string
+	fnc func(Input) string
+}
+
+var _ ScopeResolver = (*scopeResolver)(nil)
+
+// NewScopeResolver creates and returns new ScopeResolver with given identifier and key deriving function.
+// Return an empty string from fnc when the Input lacks required information.
+func NewScopeResolver(id string, fnc func(Input) string) ScopeResolver {
+	return &scopeResolver{
+		id:  id,
+		fnc: fnc,
+	}
+}
+
+func (r *scopeResolver) ID() string {
+	return r.id
+}
+
+func (r *scopeResolver) Key(input Input) string {
+	return r.fnc(input)
+}
+
+var (
+	// ScopePerSender resolves the key with Input.SenderKey as is.
+	// This is the default strategy, and is compatible with the keys stored before ScopeResolver is introduced.
+	ScopePerSender = NewScopeResolver("", func(input Input) string {
+		return input.SenderKey()
+	})
+
+	// ScopePerUser lets a user carry the conversation across channels, threads, and direct messages.
+	ScopePerUser = NewScopeResolver("user", func(input Input) string {
+		scoped, ok := scopedInput(input)
+		if !ok {
+			return ""
+		}
+		return scoped.UserID()
+	})
+
+	// ScopePerChannel lets everyone in the same channel share one conversation.
+	ScopePerChannel = NewScopeResolver("channel", func(input Input) string {
+		scoped, ok := scopedInput(input)
+		if !ok {
+			return ""
+		}
+		return scoped.ChannelID()
+	})
+
+	// ScopePerThread lets everyone in the same thread share one conversation.
+	// This is not applicable when the chat service has no concept of thread.
+	ScopePerThread = NewScopeResolver("thread", func(input Input) string {
+		scoped, ok := scopedInput(input)
+		if !ok || scoped.ThreadID() == "" {
+			return ""
+		}
+		return scoped.ChannelID() + "|" + scoped.ThreadID()
+	})
+
+	// ScopePerUserPerChannel binds the conversation to a user in a particular channel.
+	ScopePerUserPerChannel = NewScopeResolver("user_channel", func(input Input) string {
+		scoped, ok := scopedInput(input)
+		if !ok {
+			return ""
+		}
+		return scoped.ChannelID() + "|" + scoped.UserID()
+	})
+)
+
+// scopedInput returns ScopedInput if the given Input or its original Input satisfies the interface.
+// HelpInput and AbortInput are unwrapped so they are tied to the same conversation as the original Input.
+func scopedInput(input Input) (ScopedInput, bool) {
+	switch typed := input.(type) {
+	case ScopedInput:
+		return typed, true
+
+	case *HelpInput:
+		return scopedInput(typed.OriginalInput)
+
+	case *AbortInput:
+		return scopedInput(typed.OriginalInput)
+
+	default:
+		return nil, false
+
+	}
+}
+
+// scopedKey derives the storage key with the given ScopeResolver.
+// An empty string is returned when the resolver is not applicable to the Input.
+func scopedKey(resolver ScopeResolver, input Input) string {
+	key := resolver.Key(input)
+	if key == "" || resolver.ID() == "" {
+		return key
+	}
+	return resolver.ID() + ":" + key
+}
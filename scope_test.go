@@ -0,0 +1,217 @@
+package sarah
+
+import (
+	"context"
+	"testing"
+)
+
+type DummyScopedInput struct {
+	DummyInput
+	UserIDValue    string
+	ChannelIDValue string
+	ThreadIDValue  string
+}
+
+var _ ScopedInput = (*DummyScopedInput)(nil)
+
+func (i *DummyScopedInput) UserID() string {
+	return i.UserIDValue
+}
+
+func (i *DummyScopedInput) ChannelID() string {
+	return i.ChannelIDValue
+}
+
+func (i *DummyScopedInput) ThreadID() string {
+	return i.ThreadIDValue
+}
+
+func TestNewScopeResolver(t *testing.T) {
+	resolver := NewScopeResolver("custom", func(input Input) string {
+		return "key:" + input.Message()
+	})
+
+	if resolver.ID() != "custom" {
+		t.Errorf("Unexpected ID is returned: %s.", resolver.ID())
+	}
+
+	key := resolver.Key(&DummyInput{MessageValue: "foo"})
+	if key != "key:foo" {
+		t.Errorf("Unexpected key is returned: %s.", key)
+	}
+}
+
+func TestBuiltInScopeResolvers(t *testing.T) {
+	input := &DummyScopedInput{
+		DummyInput:     DummyInput{SenderKeyValue: "C1|U1"},
+		UserIDValue:    "U1",
+		ChannelIDValue: "C1",
+		ThreadIDValue:  "T1",
+	}
+
+	tests := []struct {
+		resolver ScopeResolver
+		input    Input
+		expected string
+	}{
+		{
+			resolver: ScopePerSender,
+			input:    input,
+			expected: "C1|U1",
+		},
+		{
+			resolver: ScopePerUser,
+			input:    input,
+			expected: "user:U1",
+		},
+		{
+			resolver: ScopePerChannel,
+			input:    input,
+			expected: "channel:C1",
+		},
+		{
+			resolver: ScopePerThread,
+			input:    input,
+			expected: "thread:C1|T1",
+		},
+		{
+			resolver: ScopePerUserPerChannel,
+			input:    input,
+			expected: "user_channel:C1|U1",
+		},
+		{
+			resolver: ScopePerChannel,
+			input:    NewAbortInput(input),
+			expected: "channel:C1",
+		},
+		{
+			resolver: ScopePerChannel,
+			input:    NewHelpInput(input),
+			expected: "channel:C1",
+		},
+		{
+			resolver: ScopePerThread,
+			input:    &DummyScopedInput{ChannelIDValue: "C1"},
+			expected: "",
+		},
+		{
+			resolver: ScopePerUser,
+			input:    &DummyInput{SenderKeyValue: "C1|U1"},
+			expected: "",
+		},
+	}
+
+	for i, tt := range tests {
+		key := scopedKey(tt.resolver, tt.input)
+		if key != tt.expected {
+			t.Errorf("Unexpected key is returned on test #%d: %s.", i, key)
+		}
+	}
+}
+
+func TestDefaultBot_Respond_WithScopeResolver(t *testing.T) {
+	storage := NewUserContextStorage(NewCacheConfig())
+	var joined []string
+	var next ContextualFunc
+	next = func(_ context.Context, input Input) (*CommandResponse, error) {
+		joined = append(joined, input.(*DummyScopedInput).UserID())
+		return &CommandResponse{
+			UserContext: NewUserContext(next),
+		}, nil
+	}
+
+	myBot, _ := NewBot(
+		&DummyAdapter{
+			BotTypeValue: "dummy",
+		},
+		BotWithStorage(storage),
+		BotWithScopeResolver(ScopePerChannel),
+	)
+	myBot.AppendCommand(&DummyCommand{
+		IdentifierValue: "game",
+		MatchFunc: func(_ Input) bool {
+			return true
+		},
+		ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return &CommandResponse{
+				UserContext: NewUserContext(next),
+			}, nil
+		},
+	})
+
+	newInput := func(userID string, channelID string) *DummyScopedInput {
+		return &DummyScopedInput{
+			DummyInput:     DummyInput{SenderKeyValue: channelID + "|" + userID},
+			UserIDValue:    userID,
+			ChannelIDValue: channelID,
+		}
+	}
+
+	_ = myBot.Respond(context.TODO(), newInput("U1", "C1"))
+	_ = myBot.Respond(context.TODO(), newInput("U2", "C1"))
+	_ = myBot.Respond(context.TODO(), newInput("U3", "C1"))
+
+	if len(joined) != 2 || joined[0] != "U2" || joined[1] != "U3" {
+		t.Errorf("Users in the same channel should share the conversation: %#v.", joined)
+	}
+
+	if stored, _ := storage.Get("channel:C1"); stored == nil {
+		t.Error("UserContext is not stored with the channel scope.")
+	}
+
+	// Another channel does not share the conversation.
+	_ = myBot.Respond(context.TODO(), newInput("U4", "C2"))
+	if len(joined) != 2 {
+		t.Errorf("Conversation is shared with another channel: %#v.", joined)
+	}
+}
+
+func TestDefaultBot_Respond_WithCommandScope(t *testing.T) {
+	storage := NewUserContextStorage(NewCacheConfig())
+	var next ContextualFunc
+	next = func(_ context.Context, _ Input) (*CommandResponse, error) {
+		return &CommandResponse{
+			UserContext: NewUserContext(next),
+		}, nil
+	}
+
+	myBot, _ := NewBot(&DummyAdapter{BotTypeValue: "dummy"}, BotWithStorage(storage))
+	myBot.AppendCommand(&defaultCommand{
+		identifier: "thread",
+		matchFunc: func(_ Input) bool {
+			return true
+		},
+		commandFunc: func(_ context.Context, _ Input, _ ...CommandConfig) (*CommandResponse, error) {
+			return &CommandResponse{
+				UserContext: NewUserContext(next),
+			}, nil
+		},
+		scopeResolver: ScopePerThread,
+	})
+
+	input := &DummyScopedInput{
+		DummyInput:     DummyInput{SenderKeyValue: "C1|U1"},
+		UserIDValue:    "U1",
+		ChannelIDValue: "C1",
+		ThreadIDValue:  "T1",
+	}
+	_ = myBot.Respond(context.TODO(), input)
+	if stored, _ := storage.Get("thread:C1|T1"); stored == nil {
+		t.Fatal("UserContext is not stored with the command's scope.")
+	}
+
+	// The conversation stays in the thread scope.
+	_ = myBot.Respond(context.TODO(), input)
+	if stored, _ := storage.Get("thread:C1|T1"); stored == nil {
+		t.Error("UserContext does not stay in the scope it started.")
+	}
+	if stored, _ := storage.Get("C1|U1"); stored != nil {
+		t.Error("UserContext is stored with the default scope.")
+	}
+
+	// Abort is applied to the same scope.
+	_ = myBot.Respond(context.TODO(), NewAbortInput(input))
+	if stored, _ := storage.Get("thread:C1|T1"); stored != nil {
+		t.Error("UserContext is not aborted.")
+	}
+}
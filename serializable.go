@@ -0,0 +1,267 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrContextualFuncNotRegistered is returned when a stored SerializableArgument refers to a function that is not registered via RegisterContextualFunc.
+	ErrContextualFuncNotRegistered = errors.New("contextual function is not registered")
+
+	// ErrNonSerializableUserContext is returned when a given UserContext or any of its parents has no Serializable field on encoding.
+	ErrNonSerializableUserContext = errors.New("UserContext.Serializable must be set to persist a context")
+)
+
+// SerializableContextualFunc defines a function signature that continues a conversation with a deserialized argument.
+// Register this with RegisterContextualFunc so a SerializableArgument with the corresponding identifier can be rehydrated on next user input.
+// The third argument has the same type as the prototype value given to RegisterContextualFunc.
+type SerializableContextualFunc func(context.Context, Input, interface{}) (*CommandResponse, error)
+
+var contextualFuncs = &contextualFuncRegistry{
+	funcs: map[string]*registeredContextualFunc{},
+}
+
+type registeredContextualFunc struct {
+	argType reflect.Type
+	fn      SerializableContextualFunc
+}
+
+// contextualFuncRegistry stashes functions that can be referred by SerializableArgument.FuncIdentifier.
+// This is shared among all Bots because a serialized context may be stored by one process and resumed by another.
+type contextualFuncRegistry struct {
+	funcs map[string]*registeredContextualFunc
+	mutex sync.RWMutex
+}
+
+func (r *contextualFuncRegistry) register(id string, argument interface{}, fn SerializableContextualFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.funcs[id] = &registeredContextualFunc{
+		argType: reflect.TypeOf(argument),
+		fn:      fn,
+	}
+}
+
+func (r *contextualFuncRegistry) get(id string) (*registeredContextualFunc, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	registered, ok := r.funcs[id]
+	return registered, ok
+}
+
+// RegisterContextualFunc registers a function that can be referred by SerializableArgument.FuncIdentifier.
+// When a Command returns UserContext with Serializable field, UserContextStorage stores the SerializableArgument instead of a plain function.
+// On the user's next input, go-sarah's core looks up the function registered with the same identifier,
+// decodes SerializableArgument.Argument into a value with the same type as the given prototype argument, and calls the function.
+// This lets a conversation resume even after the process restarts or the next input is handled by another replica.
+//
+//  type todoArgs struct {
+//    Description string `json:"description"`
+//  }
+//
+//  func init() {
+//    sarah.RegisterContextualFunc("todo.inputDate", &todoArgs{}, func(ctx context.Context, input sarah.Input, arg interface{}) (*sarah.CommandResponse, error) {
+//      args := arg.(*todoArgs)
+//      ...
+//    })
+//  }
+//
+// The prototype argument can be nil to receive the decoded argument as is.
+// Registering a function with the same identifier replaces the previous one.
+func RegisterContextualFunc(id string, argument interface{}, fn SerializableContextualFunc) {
+	contextualFuncs.register(id, argument, fn)
+}
+
+// EncodeSerializableArgument converts the given SerializableArgument to a JSON byte slice.
+// UserContextStorage implementations that store contexts in external storage may use this to serialize the argument.
+func EncodeSerializableArgument(arg *SerializableArgument) ([]byte, error) {
+	b, err := json.Marshal(&encodedSerializableArgument{
+		FuncIdentifier: arg.FuncIdentifier,
+		Argument:       arg.Argument,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode serializable argument for %s: %w", arg.FuncIdentifier, err)
+	}
+	return b, nil
+}
+
+// DecodeSerializableArgument converts the given JSON byte slice to SerializableArgument.
+// The Argument field holds json.RawMessage, which is decoded to the registered type when the context is resumed.
+func DecodeSerializableArgument(b []byte) (*SerializableArgument, error) {
+	decoded := &decodedSerializableArgument{}
+	err := json.Unmarshal(b, decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode serializable argument: %w", err)
+	}
+
+	return &SerializableArgument{
+		FuncIdentifier: decoded.FuncIdentifier,
+		Argument:       decoded.Argument,
+	}, nil
+}
+
+// EncodeSerializableUserContext converts the given UserContext to a JSON byte slice.
+// Contexts stacked with PushUserContext are encoded along with the given context, so every context in the stack must have Serializable field.
+// ErrNonSerializableUserContext is returned otherwise.
+func EncodeSerializableUserContext(userContext *UserContext) ([]byte, error) {
+	encoded, err := encodeUserContext(userContext)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user context for %s: %w", encoded.FuncIdentifier, err)
+	}
+	return b, nil
+}
+
+func encodeUserContext(userContext *UserContext) (*encodedSerializableArgument, error) {
+	if userContext.Serializable == nil {
+		return nil, ErrNonSerializableUserContext
+	}
+
+	encoded := &encodedSerializableArgument{
+		FuncIdentifier: userContext.Serializable.FuncIdentifier,
+		Argument:       userContext.Serializable.Argument,
+	}
+
+	if userContext.Parent != nil {
+		parent, err := encodeUserContext(userContext.Parent)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Parent = parent
+	}
+
+	return encoded, nil
+}
+
+// DecodeSerializableUserContext converts the given JSON byte slice to UserContext with Serializable field.
+// Stacked parent contexts are also restored.
+// This can also decode the byte slice encoded by EncodeSerializableArgument.
+func DecodeSerializableUserContext(b []byte) (*UserContext, error) {
+	decoded := &decodedSerializableArgument{}
+	err := json.Unmarshal(b, decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode user context: %w", err)
+	}
+
+	return decoded.userContext(), nil
+}
+
+type encodedSerializableArgument struct {
+	FuncIdentifier string                       `json:"func_identifier"`
+	Argument       interface{}                  `json:"argument"`
+	Parent         *encodedSerializableArgument `json:"parent,omitempty"`
+}
+
+type decodedSerializableArgument struct {
+	FuncIdentifier string                       `json:"func_identifier"`
+	Argument       json.RawMessage              `json:"argument"`
+	Parent         *decodedSerializableArgument `json:"parent,omitempty"`
+}
+
+func (decoded *decodedSerializableArgument) userContext() *UserContext {
+	userContext := &UserContext{
+		Serializable: &SerializableArgument{
+			FuncIdentifier: decoded.FuncIdentifier,
+			Argument:       decoded.Argument,
+		},
+	}
+
+	if decoded.Parent != nil {
+		userContext.Parent = decoded.Parent.userContext()
+	}
+
+	return userContext
+}
+
+// resolveContextualFunc returns a function to be called on the user's next input.
+// UserContext.Next is preferred, and SerializableArgument is resolved with the registered function when Next is not set.
+func resolveContextualFunc(userContext *UserContext) (ContextualFunc, error) {
+	if userContext == nil {
+		return nil, nil
+	}
+
+	if userContext.Next != nil {
+		return userContext.Next, nil
+	}
+
+	arg := userContext.Serializable
+	if arg == nil {
+		return nil, nil
+	}
+
+	registered, ok := contextualFuncs.get(arg.FuncIdentifier)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrContextualFuncNotRegistered, arg.FuncIdentifier)
+	}
+
+	decoded, err := decodeArgument(registered.argType, arg.Argument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode argument for %s: %w", arg.FuncIdentifier, err)
+	}
+
+	return func(ctx context.Context, input Input) (*CommandResponse, error) {
+		return registered.fn(ctx, input, decoded)
+	}, nil
+}
+
+func decodeArgument(argType reflect.Type, argument interface{}) (interface{}, error) {
+	if argument == nil {
+		if argType == nil {
+			return nil, nil
+		}
+		return reflect.Zero(argType).Interface(), nil
+	}
+
+	// In-memory storage may return the argument as is.
+	if argType == nil || reflect.TypeOf(argument) == argType {
+		if raw, ok := argument.(json.RawMessage); ok && argType == nil {
+			var v interface{}
+			err := json.Unmarshal(raw, &v)
+			return v, err
+		}
+		return argument, nil
+	}
+
+	var b []byte
+	switch typed := argument.(type) {
+	case json.RawMessage:
+		b = typed
+
+	case []byte:
+		b = typed
+
+	default:
+		// e.g. map[string]interface{} decoded by storage without type information.
+		var err error
+		b, err = json.Marshal(typed)
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	if argType.Kind() == reflect.Ptr {
+		n := reflect.New(argType.Elem())
+		if err := json.Unmarshal(b, n.Interface()); err != nil {
+			return nil, err
+		}
+		return n.Interface(), nil
+	}
+
+	n := reflect.New(argType)
+	if err := json.Unmarshal(b, n.Interface()); err != nil {
+		return nil, err
+	}
+	return n.Elem().Interface(), nil
+}
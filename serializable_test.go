@@ -0,0 +1,218 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type serializableTestArg struct {
+	Description string `json:"description"`
+	Count       int    `json:"count"`
+}
+
+func TestRegisterContextualFunc(t *testing.T) {
+	id := "test.register"
+	fnc := func(_ context.Context, _ Input, _ interface{}) (*CommandResponse, error) {
+		return nil, nil
+	}
+	RegisterContextualFunc(id, &serializableTestArg{}, fnc)
+
+	registered, ok := contextualFuncs.get(id)
+	if !ok {
+		t.Fatal("Given function is not registered.")
+	}
+
+	if registered.argType.String() != "*sarah.serializableTestArg" {
+		t.Errorf("Unexpected argument type is stored: %s.", registered.argType)
+	}
+}
+
+func TestEncodeSerializableArgument(t *testing.T) {
+	arg := &SerializableArgument{
+		FuncIdentifier: "foo",
+		Argument: &serializableTestArg{
+			Description: "buy milk",
+			Count:       1,
+		},
+	}
+
+	b, err := EncodeSerializableArgument(arg)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	decoded, err := DecodeSerializableArgument(b)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if decoded.FuncIdentifier != "foo" {
+		t.Errorf("Unexpected identifier is decoded: %s.", decoded.FuncIdentifier)
+	}
+
+	if _, ok := decoded.Argument.(json.RawMessage); !ok {
+		t.Errorf("Argument should be decoded as json.RawMessage: %T.", decoded.Argument)
+	}
+
+	_, err = DecodeSerializableArgument([]byte("invalid"))
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func Test_resolveContextualFunc(t *testing.T) {
+	var given interface{}
+	RegisterContextualFunc("test.ptr", &serializableTestArg{}, func(_ context.Context, _ Input, arg interface{}) (*CommandResponse, error) {
+		given = arg
+		return nil, nil
+	})
+	RegisterContextualFunc("test.value", serializableTestArg{}, func(_ context.Context, _ Input, arg interface{}) (*CommandResponse, error) {
+		given = arg
+		return nil, nil
+	})
+	RegisterContextualFunc("test.nil", nil, func(_ context.Context, _ Input, arg interface{}) (*CommandResponse, error) {
+		given = arg
+		return nil, nil
+	})
+
+	raw := json.RawMessage(`{"description":"buy milk","count":2}`)
+	tests := []struct {
+		userContext *UserContext
+		validate    func(interface{}) error
+		hasErr      bool
+		isNil       bool
+	}{
+		{
+			userContext: nil,
+			isNil:       true,
+		},
+		{
+			userContext: &UserContext{},
+			isNil:       true,
+		},
+		{
+			userContext: NewSerializableUserContext("test.ptr", raw),
+			validate: func(arg interface{}) error {
+				typed, ok := arg.(*serializableTestArg)
+				if !ok || typed.Description != "buy milk" || typed.Count != 2 {
+					return errors.New("unexpected argument")
+				}
+				return nil
+			},
+		},
+		{
+			userContext: NewSerializableUserContext("test.value", map[string]interface{}{"description": "buy milk"}),
+			validate: func(arg interface{}) error {
+				typed, ok := arg.(serializableTestArg)
+				if !ok || typed.Description != "buy milk" {
+					return errors.New("unexpected argument")
+				}
+				return nil
+			},
+		},
+		{
+			userContext: NewSerializableUserContext("test.ptr", &serializableTestArg{Count: 3}),
+			validate: func(arg interface{}) error {
+				typed, ok := arg.(*serializableTestArg)
+				if !ok || typed.Count != 3 {
+					return errors.New("unexpected argument")
+				}
+				return nil
+			},
+		},
+		{
+			userContext: NewSerializableUserContext("test.nil", raw),
+			validate: func(arg interface{}) error {
+				typed, ok := arg.(map[string]interface{})
+				if !ok || typed["description"] != "buy milk" {
+					return errors.New("unexpected argument")
+				}
+				return nil
+			},
+		},
+		{
+			userContext: NewSerializableUserContext("test.ptr", json.RawMessage(`invalid`)),
+			hasErr:      true,
+		},
+		{
+			userContext: NewSerializableUserContext("test.unknown", nil),
+			hasErr:      true,
+		},
+	}
+
+	for i, tt := range tests {
+		given = nil
+		fnc, err := resolveContextualFunc(tt.userContext)
+		if tt.hasErr {
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %+v.", i, err)
+			continue
+		}
+
+		if tt.isNil {
+			if fnc != nil {
+				t.Errorf("Nil is expected on test #%d.", i)
+			}
+			continue
+		}
+
+		_, _ = fnc(context.TODO(), &DummyInput{})
+		if err := tt.validate(given); err != nil {
+			t.Errorf("Unexpected argument is given on test #%d: %#v.", i, given)
+		}
+	}
+}
+
+func TestEncodeSerializableUserContext(t *testing.T) {
+	parent := NewSerializableUserContext("parent", &serializableTestArg{Description: "parent"})
+	child := PushUserContext(NewSerializableUserContext("child", &serializableTestArg{Description: "child"}), parent)
+
+	b, err := EncodeSerializableUserContext(child)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	decoded, err := DecodeSerializableUserContext(b)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if decoded.Serializable.FuncIdentifier != "child" {
+		t.Errorf("Unexpected identifier is decoded: %s.", decoded.Serializable.FuncIdentifier)
+	}
+
+	if decoded.Parent == nil || decoded.Parent.Serializable.FuncIdentifier != "parent" {
+		t.Errorf("Parent context is not decoded: %#v.", decoded.Parent)
+	}
+}
+
+func TestEncodeSerializableUserContext_NonSerializable(t *testing.T) {
+	parent := NewUserContext(func(_ context.Context, _ Input) (*CommandResponse, error) { return nil, nil })
+	child := PushUserContext(NewSerializableUserContext("child", nil), parent)
+
+	_, err := EncodeSerializableUserContext(child)
+	if err != ErrNonSerializableUserContext {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestDecodeSerializableUserContext_WithSerializableArgument(t *testing.T) {
+	b, _ := EncodeSerializableArgument(&SerializableArgument{FuncIdentifier: "foo"})
+
+	decoded, err := DecodeSerializableUserContext(b)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if decoded.Serializable.FuncIdentifier != "foo" || decoded.Parent != nil {
+		t.Errorf("Unexpected context is decoded: %#v.", decoded)
+	}
+}
@@ -19,6 +19,11 @@ const (
 	SLACK sarah.BotType = "slack"
 )
 
+func init() {
+	// Let go-sarah's core restore the destinations of the stored jobs.
+	sarah.RegisterDestinationType(SLACK, event.ChannelID(""))
+}
+
 // ErrNonSupportedEvent is returned when given event is not supported by this adapter.
 var ErrNonSupportedEvent = errors.New("event not supported")
 
@@ -204,8 +209,20 @@ func nonBlockSignal(id string, target chan<- struct{}) {
 	}
 }
 
+var _ sarah.ErrorReportingSender = (*Adapter)(nil)
+
 // SendMessage let Bot send message to Slack.
+// The failure is logged; use SendMessageWithError to receive it.
 func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) {
+	err := adapter.SendMessageWithError(ctx, output)
+	if err != nil {
+		log.Errorf("Failed to send message: %+v", err)
+	}
+}
+
+// SendMessageWithError let Bot send message to Slack, and returns an error when the message is not sent.
+// This satisfies sarah.ErrorReportingSender so sarah.Broadcast can report the failure.
+func (adapter *Adapter) SendMessageWithError(ctx context.Context, output sarah.Output) error {
 	var message *webapi.PostMessage
 	switch content := output.Content().(type) {
 	case *webapi.PostMessage:
@@ -214,16 +231,14 @@ func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) {
 	case string:
 		channel, ok := output.Destination().(event.ChannelID)
 		if !ok {
-			log.Errorf("Destination is not instance of Channel. %#v.", output.Destination())
-			return
+			return fmt.Errorf("destination is not instance of Channel: %#v", output.Destination())
 		}
 		message = webapi.NewPostMessage(channel, content)
 
 	case *sarah.CommandHelps:
 		channelID, ok := output.Destination().(event.ChannelID)
 		if !ok {
-			log.Errorf("Destination is not instance of Channel. %#v.", output.Destination())
-			return
+			return fmt.Errorf("destination is not instance of Channel: %#v", output.Destination())
 		}
 
 		var fields []*webapi.AttachmentField
@@ -244,20 +259,34 @@ func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) {
 		}
 		message = webapi.NewPostMessage(channelID, "").WithAttachments(attachments)
 
+	case *sarah.Announcement:
+		channelID, ok := output.Destination().(event.ChannelID)
+		if !ok {
+			return fmt.Errorf("destination is not instance of Channel: %#v", output.Destination())
+		}
+
+		attachments := []*webapi.MessageAttachment{
+			{
+				Fallback: content.Text,
+				Title:    content.Title,
+				Text:     content.Text,
+			},
+		}
+		message = webapi.NewPostMessage(channelID, "").WithAttachments(attachments)
+
 	default:
-		log.Warnf("Unexpected output %#v", output)
-		return
+		return fmt.Errorf("unexpected output: %#v", output)
 	}
 
 	resp, err := adapter.client.PostMessage(ctx, message)
 	if err != nil {
-		log.Errorf("Something went wrong with Web API posting: %+v. %+v", err, message)
-		return
+		return fmt.Errorf("failed to post message via Web API: %w", err)
 	}
 
 	if !resp.OK {
-		log.Errorf("Failed to post message %#v: %s", message, resp.Error)
+		return fmt.Errorf("failed to post message: %s", resp.Error)
 	}
+	return nil
 }
 
 // Input represents a Slack-specific implementation of sarah.Input.
@@ -269,8 +298,11 @@ type Input struct {
 	timestamp       *event.TimeStamp
 	threadTimeStamp *event.TimeStamp
 	channelID       event.ChannelID
+	userID          event.UserID
 }
 
+var _ sarah.ScopedInput = (*Input)(nil)
+
 // SenderKey returns string representing message sender.
 func (i *Input) SenderKey() string {
 	return i.senderKey
@@ -291,6 +323,26 @@ func (i *Input) ReplyTo() sarah.OutputDestination {
 	return i.channelID
 }
 
+// UserID returns the sending user's ID.
+func (i *Input) UserID() string {
+	return i.userID.String()
+}
+
+// ChannelID returns the ID of the channel the message is sent to.
+func (i *Input) ChannelID() string {
+	return i.channelID.String()
+}
+
+// ThreadID returns the timestamp of the thread's parent message.
+// When the message is not sent in a thread, its own timestamp is returned since the thread started by a reply to this message has the same ID.
+func (i *Input) ThreadID() string {
+	ts := threadTimeStamp(i)
+	if ts == nil {
+		return ""
+	}
+	return ts.OriginalValue
+}
+
 // EventToInput converts given event payload to *Input.
 func EventToInput(e interface{}) (sarah.Input, error) {
 	switch typed := e.(type) {
@@ -302,6 +354,7 @@ func EventToInput(e interface{}) (sarah.Input, error) {
 			timestamp:       typed.TimeStamp,
 			threadTimeStamp: typed.ThreadTimeStamp,
 			channelID:       typed.ChannelID,
+			userID:          typed.UserID,
 		}, nil
 
 	case *event.ChannelMessage:
@@ -312,6 +365,7 @@ func EventToInput(e interface{}) (sarah.Input, error) {
 			timestamp:       typed.TimeStamp,
 			threadTimeStamp: typed.ThreadTimeStamp,
 			channelID:       typed.ChannelID,
+			userID:          typed.UserID,
 		}, nil
 
 	default:
@@ -429,8 +483,18 @@ func RespWithNext(fnc sarah.ContextualFunc) RespOption {
 	}
 }
 
+// RespWithUserContext sets given userContext as part of the response.
+// Use this instead of RespWithNext or RespWithNextSerializable to pass UserContext.ExpiresIn, UserContext.ExpirationMessage, or UserContext.OnExpire.
+// See sarah.UserContextStorage must be present or otherwise, userContext will be ignored.
+func RespWithUserContext(userContext *sarah.UserContext) RespOption {
+	return func(options *respOptions) {
+		options.userContext = userContext
+	}
+}
+
 // RespWithNextSerializable sets given arg as part of the response's *sarah.UserContext.
 // The next input from the same user will be passed to the function defined in the arg.
+// The function must be registered with sarah.RegisterContextualFunc under the identifier of arg.FuncIdentifier.
 // See sarah.UserContextStorage must be present or otherwise, arg will be ignored.
 func RespWithNextSerializable(arg *sarah.SerializableArgument) RespOption {
 	return func(options *respOptions) {
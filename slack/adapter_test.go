@@ -214,6 +214,73 @@ func TestAdapter_Run(t *testing.T) {
 	}
 }
 
+func TestAdapter_SendMessageWithError(t *testing.T) {
+	tests := []struct {
+		output   sarah.Output
+		err      error
+		response *webapi.APIResponse
+		called   bool
+		hasErr   bool
+	}{
+		{
+			output:   sarah.NewOutputMessage(event.ChannelID("channelID"), "text"),
+			response: &webapi.APIResponse{OK: true},
+			called:   true,
+			hasErr:   false,
+		},
+		{
+			output: sarah.NewOutputMessage(event.ChannelID("channelID"), "text"),
+			err:    errors.New("error"),
+			called: true,
+			hasErr: true,
+		},
+		{
+			output:   sarah.NewOutputMessage(event.ChannelID("channelID"), "text"),
+			response: &webapi.APIResponse{OK: false, Error: "channel_not_found"},
+			called:   true,
+			hasErr:   true,
+		},
+		{
+			// Unexpected content
+			output: sarah.NewOutputMessage(event.ChannelID("channelID"), 123),
+			called: false,
+			hasErr: true,
+		},
+		{
+			// Invalid destination
+			output: sarah.NewOutputMessage("invalid", "text"),
+			called: false,
+			hasErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			called := false
+			adapter := &Adapter{
+				client: &DummyClient{
+					PostMessageFunc: func(_ context.Context, _ *webapi.PostMessage) (*webapi.APIResponse, error) {
+						called = true
+						return tt.response, tt.err
+					},
+				},
+			}
+
+			err := adapter.SendMessageWithError(context.TODO(), tt.output)
+
+			if called != tt.called {
+				t.Errorf("Unexpected call of Client.PostMessage: %t.", called)
+			}
+			if tt.hasErr && err == nil {
+				t.Error("Expected error is not returned.")
+			}
+			if !tt.hasErr && err != nil {
+				t.Errorf("Unexpected error is returned: %s.", err.Error())
+			}
+		})
+	}
+}
+
 func TestAdapter_SendMessage(t *testing.T) {
 	t.Run("Regular message", func(t *testing.T) {
 		tests := []struct {
@@ -317,6 +384,39 @@ func TestAdapter_SendMessage(t *testing.T) {
 			t.Fatal("Client.PostMessage is not called.")
 		}
 	})
+
+	t.Run("Announcement", func(t *testing.T) {
+		var posted *webapi.PostMessage
+		adapter := &Adapter{
+			client: &DummyClient{
+				PostMessageFunc: func(_ context.Context, message *webapi.PostMessage) (*webapi.APIResponse, error) {
+					posted = message
+					return &webapi.APIResponse{
+						OK:    true,
+						Error: "",
+					}, nil
+				},
+			},
+		}
+
+		announcement := &sarah.Announcement{
+			Title: "Maintenance",
+			Text:  "Starts at 10 PM.",
+		}
+
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage("invalidID", announcement))
+		if posted != nil {
+			t.Fatal("Invalid output reached Client.PostMessage.")
+		}
+
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(event.ChannelID("test"), announcement))
+		if posted == nil {
+			t.Fatal("Client.PostMessage is not called.")
+		}
+		if len(posted.Attachments) != 1 || posted.Attachments[0].Title != "Maintenance" || posted.Attachments[0].Text != "Starts at 10 PM." {
+			t.Errorf("Unexpected attachments are posted: %#v.", posted.Attachments)
+		}
+	})
 }
 
 type DummyInput struct {
@@ -549,6 +649,20 @@ func TestRespWithNext(t *testing.T) {
 	}
 }
 
+func TestRespWithUserContext(t *testing.T) {
+	options := &respOptions{}
+	userContext := &sarah.UserContext{
+		ExpirationMessage: "timed out",
+	}
+	opt := RespWithUserContext(userContext)
+
+	opt(options)
+
+	if options.userContext != userContext {
+		t.Error("Passed UserContext is not set.")
+	}
+}
+
 func TestRespWithNextSerializable(t *testing.T) {
 	options := &respOptions{}
 	arg := &sarah.SerializableArgument{}
@@ -662,3 +776,35 @@ func Test_nonBlockSignal(t *testing.T) {
 		t.Errorf("The target channel should have exactly one signal: %d", len(target))
 	}
 }
+
+func TestInput_ScopedInput(t *testing.T) {
+	ts := &event.TimeStamp{
+		OriginalValue: "1355517536.000001",
+	}
+	threadTS := &event.TimeStamp{
+		OriginalValue: "1355517500.000001",
+	}
+	input := &Input{
+		userID:    "U123",
+		channelID: "C123",
+		timestamp: ts,
+	}
+
+	if input.UserID() != "U123" {
+		t.Errorf("Unexpected user ID is returned: %s.", input.UserID())
+	}
+
+	if input.ChannelID() != "C123" {
+		t.Errorf("Unexpected channel ID is returned: %s.", input.ChannelID())
+	}
+
+	// A message that is not in a thread may become the parent of a thread.
+	if input.ThreadID() != ts.OriginalValue {
+		t.Errorf("Unexpected thread ID is returned: %s.", input.ThreadID())
+	}
+
+	input.threadTimeStamp = threadTS
+	if input.ThreadID() != threadTS.OriginalValue {
+		t.Errorf("Unexpected thread ID is returned: %s.", input.ThreadID())
+	}
+}
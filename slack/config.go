@@ -7,15 +7,16 @@ import (
 
 // Config contains some configuration variables for slack Adapter.
 type Config struct {
-	Token            string        `json:"token" yaml:"token"`
-	AppSecret        string        `json:"app_secret" yaml:"app_secret"`
-	ListenPort       int           `json:"listen_port" yaml:"listen_port"`
-	HelpCommand      string        `json:"help_command" yaml:"help_command"`
-	AbortCommand     string        `json:"abort_command" yaml:"abort_command"`
-	SendingQueueSize uint          `json:"sending_queue_size" yaml:"sending_queue_size"`
-	RequestTimeout   time.Duration `json:"request_timeout" yaml:"request_timeout"`
-	PingInterval     time.Duration `json:"ping_interval" yaml:"ping_interval"`
-	RetryPolicy      *retry.Policy `json:"retry_policy" yaml:"retry_policy"`
+	Token               string        `json:"token" yaml:"token"`
+	AppSecret           string        `json:"app_secret" yaml:"app_secret"`
+	ListenPort          int           `json:"listen_port" yaml:"listen_port"`
+	HelpCommand         string        `json:"help_command" yaml:"help_command"`
+	AbortCommand        string        `json:"abort_command" yaml:"abort_command"`
+	AbortCurrentCommand string        `json:"abort_current_command" yaml:"abort_current_command"`
+	SendingQueueSize    uint          `json:"sending_queue_size" yaml:"sending_queue_size"`
+	RequestTimeout      time.Duration `json:"request_timeout" yaml:"request_timeout"`
+	PingInterval        time.Duration `json:"ping_interval" yaml:"ping_interval"`
+	RetryPolicy         *retry.Policy `json:"retry_policy" yaml:"retry_policy"`
 }
 
 // NewConfig returns initialized Config struct with default settings.
@@ -23,14 +24,15 @@ type Config struct {
 // or direct assignment.
 func NewConfig() *Config {
 	return &Config{
-		Token:            "",
-		AppSecret:        "",
-		ListenPort:       8080,
-		HelpCommand:      ".help",
-		AbortCommand:     ".abort",
-		SendingQueueSize: 100,
-		RequestTimeout:   3 * time.Second,
-		PingInterval:     30 * time.Second,
+		Token:               "",
+		AppSecret:           "",
+		ListenPort:          8080,
+		HelpCommand:         ".help",
+		AbortCommand:        ".abort",
+		AbortCurrentCommand: "",
+		SendingQueueSize:    100,
+		RequestTimeout:      3 * time.Second,
+		PingInterval:        30 * time.Second,
 		RetryPolicy: &retry.Policy{
 			Trial:    10,
 			Interval: 500 * time.Millisecond,
@@ -65,6 +65,10 @@ func DefaultEventsPayloadHandler(_ context.Context, config *Config, payload *eve
 		// Abort command
 		abort := sarah.NewAbortInput(input)
 		_ = enqueueInput(abort)
+	} else if config.AbortCurrentCommand != "" && trimmed == config.AbortCurrentCommand {
+		// Abort the current level of stacked contexts
+		abort := sarah.NewAbortCurrentInput(input)
+		_ = enqueueInput(abort)
 	} else {
 		// Regular input
 		_ = enqueueInput(input)
@@ -168,6 +168,37 @@ func TestDefaultEventsPayloadHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("Abort current message", func(t *testing.T) {
+		ev := &event.ChannelMessage{
+			Text: ".back",
+			TimeStamp: &event.TimeStamp{
+				Time: time.Time{},
+			},
+		}
+		wrapper := &eventsapi.EventWrapper{
+			Event: ev,
+		}
+
+		config := &Config{
+			AbortCommand:        ".abort",
+			AbortCurrentCommand: ".back",
+		}
+		incoming := make(chan sarah.Input, 1)
+		enqueueInput := func(input sarah.Input) error {
+			incoming <- input
+			return nil
+		}
+		DefaultEventsPayloadHandler(context.TODO(), config, wrapper, enqueueInput)
+
+		select {
+		case input := <-incoming:
+			abort, ok := input.(*sarah.AbortInput)
+			if !ok || !abort.CurrentOnly() {
+				t.Fatalf("Unexpected input is given: %#v", input)
+			}
+		}
+	})
+
 	t.Run("Unsupported message", func(t *testing.T) {
 		wrapper := &eventsapi.EventWrapper{
 			Event: struct{}{},
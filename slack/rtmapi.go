@@ -178,6 +178,10 @@ func DefaultRTMPayloadHandler(_ context.Context, config *Config, payload rtmapi.
 			// Abort command
 			abort := sarah.NewAbortInput(input)
 			_ = enqueueInput(abort)
+		} else if config.AbortCurrentCommand != "" && trimmed == config.AbortCurrentCommand {
+			// Abort the current level of stacked contexts
+			abort := sarah.NewAbortCurrentInput(input)
+			_ = enqueueInput(abort)
 		} else {
 			// Regular input
 			_ = enqueueInput(input)
@@ -371,8 +371,9 @@ func Test_rtmAPIAdapter_handleRTMPayload(t *testing.T) {
 	helpCommand := ".help"
 	abortCommand := ".abort"
 	config := &Config{
-		HelpCommand:  helpCommand,
-		AbortCommand: ".abort",
+		HelpCommand:         helpCommand,
+		AbortCommand:        ".abort",
+		AbortCurrentCommand: ".back",
 	}
 	inputs := []struct {
 		payload   rtmapi.DecodedPayload
@@ -430,6 +431,17 @@ func Test_rtmAPIAdapter_handleRTMPayload(t *testing.T) {
 			},
 			inputType: reflect.ValueOf(&sarah.AbortInput{}).Type(),
 		},
+		{
+			payload: &event.Message{
+				ChannelID: event.ChannelID("abc"),
+				UserID:    event.UserID("cde"),
+				Text:      ".back",
+				TimeStamp: &event.TimeStamp{
+					Time: time.Now(),
+				},
+			},
+			inputType: reflect.ValueOf(&sarah.AbortInput{}).Type(),
+		},
 		{
 			payload: &event.Message{
 				ChannelID: event.ChannelID("abc"),
@@ -0,0 +1,95 @@
+package sarah
+
+import "context"
+
+// ReturnedValue represents the result of a nested conversation that is passed to the parent context.
+// A parent ContextualFunc can receive this via ReturnedValueFromContext when a child context finishes with ReturnToParent,
+// or when the child context is aborted with an AbortInput created by NewAbortCurrentInput.
+type ReturnedValue struct {
+	// Value is the value given to ReturnToParent.
+	Value interface{}
+
+	// Aborted tells that the child conversation is aborted by the user.
+	Aborted bool
+}
+
+type returnedValueKey struct{}
+
+// WithReturnedValue returns a copy of the given context that carries the ReturnedValue.
+func WithReturnedValue(ctx context.Context, returned *ReturnedValue) context.Context {
+	return context.WithValue(ctx, returnedValueKey{}, returned)
+}
+
+// ReturnedValueFromContext returns the ReturnedValue passed from a child context if any.
+// A ContextualFunc that pushes a child context should check this first to see if it is resumed by the child.
+//
+//  func (c *todoCommand) inputDate(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//    if returned, ok := sarah.ReturnedValueFromContext(ctx); ok {
+//      if returned.Aborted {
+//        return slack.NewResponse(input, "Date is not picked. Input the due date.", slack.RespWithNext(c.inputDate))
+//      }
+//      date := returned.Value.(time.Time)
+//      ...
+//    }
+//    ...
+//  }
+func ReturnedValueFromContext(ctx context.Context) (*ReturnedValue, bool) {
+	returned, ok := ctx.Value(returnedValueKey{}).(*ReturnedValue)
+	return returned, ok
+}
+
+// PushUserContext stacks the child context on top of the parent context, and returns the child context.
+// The user's next input is fed to the child context.
+// When the child conversation finishes with ReturnToParent, the parent context is resumed immediately with the returned value.
+// When the child conversation finishes without any UserContext, the parent context receives the user's next input.
+//
+//  // Inside of "create todo" conversation, start "pick a date" conversation and come back to inputDate afterwards.
+//  child := sarah.PushUserContext(sarah.NewUserContext(pickDate), sarah.NewUserContext(c.inputDate))
+//  return slack.NewResponse(input, "Which month?", slack.RespWithUserContext(child))
+func PushUserContext(child *UserContext, parent *UserContext) *UserContext {
+	child.Parent = parent
+	return child
+}
+
+// ReturnToParent creates a UserContext that finishes the current context and resumes the parent context with the given value.
+// The parent ContextualFunc receives the value via ReturnedValueFromContext along with the current input.
+// When there is no parent context, the conversation simply finishes.
+func ReturnToParent(value interface{}) *UserContext {
+	return &UserContext{
+		returned: &ReturnedValue{
+			Value: value,
+		},
+	}
+}
+
+// NewReturnResponse creates and returns new CommandResponse that sends the content and returns to the parent context with the given value.
+// Adapter-specific response builders can achieve the same by passing ReturnToParent's result as UserContext.
+func NewReturnResponse(content interface{}, value interface{}) *CommandResponse {
+	return &CommandResponse{
+		Content:     content,
+		UserContext: ReturnToParent(value),
+	}
+}
+
+// stackUserContext puts the given parent context under the bottom of the given context stack.
+// When the stack already contains the parent, the stack is returned as is to avoid a circular reference.
+func stackUserContext(userContext *UserContext, parent *UserContext) *UserContext {
+	if parent == nil {
+		return userContext
+	}
+
+	bottom := userContext
+	for {
+		if bottom == parent {
+			return userContext
+		}
+
+		if bottom.Parent == nil {
+			break
+		}
+		bottom = bottom.Parent
+	}
+
+	bottom.Parent = parent
+	return userContext
+}
@@ -0,0 +1,236 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReturnedValueFromContext(t *testing.T) {
+	if _, ok := ReturnedValueFromContext(context.TODO()); ok {
+		t.Error("ReturnedValue should not be returned for empty context.")
+	}
+
+	returned := &ReturnedValue{Value: "foo"}
+	ctx := WithReturnedValue(context.TODO(), returned)
+	given, ok := ReturnedValueFromContext(ctx)
+	if !ok || given != returned {
+		t.Errorf("Expected ReturnedValue is not returned: %#v.", given)
+	}
+}
+
+func TestPushUserContext(t *testing.T) {
+	parent := NewSerializableUserContext("parent", nil)
+	child := NewSerializableUserContext("child", nil)
+
+	pushed := PushUserContext(child, parent)
+	if pushed != child || pushed.Parent != parent {
+		t.Errorf("Contexts are not stacked: %#v.", pushed)
+	}
+}
+
+func TestNewReturnResponse(t *testing.T) {
+	res := NewReturnResponse("content", 123)
+
+	if res.Content != "content" {
+		t.Errorf("Unexpected content is set: %#v.", res.Content)
+	}
+
+	if res.UserContext == nil || res.UserContext.returned == nil || res.UserContext.returned.Value != 123 {
+		t.Errorf("Returning value is not set: %#v.", res.UserContext)
+	}
+}
+
+func Test_stackUserContext(t *testing.T) {
+	grandParent := NewSerializableUserContext("grandParent", nil)
+	parent := NewSerializableUserContext("parent", nil)
+	parent.Parent = grandParent
+
+	child := NewSerializableUserContext("child", nil)
+	stacked := stackUserContext(child, parent)
+	if stacked.Parent != parent || stacked.Parent.Parent != grandParent {
+		t.Errorf("Contexts are not stacked: %#v.", stacked)
+	}
+
+	// Already stacked.
+	stacked = stackUserContext(child, grandParent)
+	if stacked.Parent != parent || grandParent.Parent != nil {
+		t.Error("Circular reference is made.")
+	}
+
+	if stackUserContext(child, nil) != child {
+		t.Error("The given context should be returned as is.")
+	}
+}
+
+func newStackTestBot(storage UserContextStorage, command ContextualFunc) (*defaultBot, *[]interface{}) {
+	var sent []interface{}
+	bot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, output Output) {
+			sent = append(sent, output.Content())
+		},
+		commands: &Commands{
+			collection: []Command{
+				&DummyCommand{
+					MatchFunc: func(_ Input) bool {
+						return true
+					},
+					ExecuteFunc: command,
+				},
+			},
+		},
+		userContextStorage: storage,
+		scopeResolver:      ScopePerSender,
+	}
+	return bot, &sent
+}
+
+func TestDefaultBot_Respond_WithStackedContexts(t *testing.T) {
+	var picked interface{}
+	var parentFunc ContextualFunc
+	parentFunc = func(ctx context.Context, _ Input) (*CommandResponse, error) {
+		returned, ok := ReturnedValueFromContext(ctx)
+		if !ok {
+			return &CommandResponse{Content: "Still in parent.", UserContext: NewUserContext(parentFunc)}, nil
+		}
+		if returned.Aborted {
+			return &CommandResponse{Content: "Pick again.", UserContext: NewUserContext(parentFunc)}, nil
+		}
+		picked = returned.Value
+		return &CommandResponse{Content: "Done."}, nil
+	}
+
+	var childFunc ContextualFunc
+	childFunc = func(_ context.Context, input Input) (*CommandResponse, error) {
+		if input.Message() == "next" {
+			return &CommandResponse{Content: "Input date.", UserContext: NewUserContext(childFunc)}, nil
+		}
+		if input.Message() == "quit" {
+			return &CommandResponse{Content: "Quit picking."}, nil
+		}
+		return NewReturnResponse("Picked.", input.Message()), nil
+	}
+
+	storage := NewUserContextStorage(NewCacheConfig())
+	bot, sent := newStackTestBot(storage, func(_ context.Context, _ Input) (*CommandResponse, error) {
+		return &CommandResponse{
+			Content:     "Input month.",
+			UserContext: PushUserContext(NewUserContext(childFunc), NewUserContext(parentFunc)),
+		}, nil
+	})
+	input := func(message string) Input {
+		return &DummyInput{SenderKeyValue: "sender", MessageValue: message}
+	}
+
+	// Start with the child context stacked on the parent.
+	_ = bot.Respond(context.TODO(), input(".todo"))
+	stored, _ := storage.Get("sender")
+	if stored == nil || stored.Parent == nil {
+		t.Fatalf("Stacked contexts are not stored: %#v.", stored)
+	}
+
+	// The child context proceeds while keeping the parent.
+	_ = bot.Respond(context.TODO(), input("next"))
+	stored, _ = storage.Get("sender")
+	if stored == nil || stored.Parent == nil {
+		t.Fatalf("Parent context is lost: %#v.", stored)
+	}
+
+	// Return to the parent with a value.
+	_ = bot.Respond(context.TODO(), input("2020-01-01"))
+	if picked != "2020-01-01" {
+		t.Errorf("Returned value is not passed to the parent: %#v.", picked)
+	}
+	if stored, _ := storage.Get("sender"); stored != nil {
+		t.Errorf("Finished context is left: %#v.", stored)
+	}
+	expected := []interface{}{"Input month.", "Input date.", "Picked.", "Done."}
+	if len(*sent) != len(expected) {
+		t.Fatalf("Unexpected messages are sent: %#v.", *sent)
+	}
+	for i, content := range expected {
+		if (*sent)[i] != content {
+			t.Errorf("Unexpected message is sent on #%d: %#v.", i, (*sent)[i])
+		}
+	}
+
+	// Child finishing without returning leaves the parent for the next input.
+	_ = bot.Respond(context.TODO(), input(".todo"))
+	_ = bot.Respond(context.TODO(), input("quit"))
+	stored, _ = storage.Get("sender")
+	if stored == nil || stored.Parent != nil {
+		t.Fatalf("Parent context is not left: %#v.", stored)
+	}
+	_ = bot.Respond(context.TODO(), input("foo"))
+	if (*sent)[len(*sent)-1] != "Still in parent." {
+		t.Errorf("Parent context does not receive the input: %#v.", (*sent)[len(*sent)-1])
+	}
+}
+
+func TestDefaultBot_Respond_AbortStackedContexts(t *testing.T) {
+	var parentFunc ContextualFunc
+	parentFunc = func(ctx context.Context, _ Input) (*CommandResponse, error) {
+		if returned, ok := ReturnedValueFromContext(ctx); ok && returned.Aborted {
+			return &CommandResponse{Content: "Pick again.", UserContext: NewUserContext(parentFunc)}, nil
+		}
+		return nil, nil
+	}
+	childFunc := func(_ context.Context, _ Input) (*CommandResponse, error) {
+		return nil, nil
+	}
+
+	storage := NewUserContextStorage(NewCacheConfig())
+	bot, sent := newStackTestBot(storage, func(_ context.Context, _ Input) (*CommandResponse, error) {
+		return &CommandResponse{
+			UserContext: PushUserContext(NewUserContext(childFunc), NewUserContext(parentFunc)),
+		}, nil
+	})
+	input := &DummyInput{SenderKeyValue: "sender"}
+
+	// Abort current level.
+	_ = bot.Respond(context.TODO(), input)
+	_ = bot.Respond(context.TODO(), NewAbortCurrentInput(input))
+	if len(*sent) != 1 || (*sent)[0] != "Pick again." {
+		t.Errorf("Parent context is not resumed on abortion: %#v.", *sent)
+	}
+	stored, _ := storage.Get("sender")
+	if stored == nil || stored.Parent != nil {
+		t.Errorf("Parent context is not stored: %#v.", stored)
+	}
+
+	// Abort all.
+	_ = storage.Flush()
+	_ = bot.Respond(context.TODO(), input)
+	_ = bot.Respond(context.TODO(), NewAbortInput(input))
+	if stored, _ := storage.Get("sender"); stored != nil {
+		t.Errorf("Contexts are left after abortion: %#v.", stored)
+	}
+}
+
+func TestDefaultBot_Respond_StackedContextWithError(t *testing.T) {
+	parentFunc := func(_ context.Context, _ Input) (*CommandResponse, error) {
+		return nil, nil
+	}
+	expectedErr := errors.New("ERROR")
+	childFunc := func(_ context.Context, _ Input) (*CommandResponse, error) {
+		return nil, expectedErr
+	}
+
+	storage := NewUserContextStorage(NewCacheConfig())
+	bot, _ := newStackTestBot(storage, func(_ context.Context, _ Input) (*CommandResponse, error) {
+		return &CommandResponse{
+			UserContext: PushUserContext(NewUserContext(childFunc), NewUserContext(parentFunc)),
+		}, nil
+	})
+	input := &DummyInput{SenderKeyValue: "sender"}
+
+	_ = bot.Respond(context.TODO(), input)
+	err := bot.Respond(context.TODO(), input)
+	if err != expectedErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if stored, _ := storage.Get("sender"); stored == nil {
+		t.Error("Parent context should be kept on child's error.")
+	}
+}
@@ -1,9 +1,13 @@
 package sarah
 
 import (
+	"context"
 	"errors"
 	"github.com/oklahomer/go-sarah/v3/log"
+	"github.com/oklahomer/go-sarah/v3/workers"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 var runnerStatus = &status{}
@@ -27,18 +31,55 @@ func CurrentStatus() Status {
 type Status struct {
 	Running bool
 	Bots    []BotStatus
+
+	// TaskQueueSize is the number of scheduled executions waiting in the worker's queue.
+	// This is always zero unless Config.DispatchTasks is true.
+	// The same value is reported via workers.Stats.QueueGauges when the worker is given WithTaskQueueGauge.
+	TaskQueueSize int
 }
 
 // BotStatus represents the current status of a Bot.
 type BotStatus struct {
 	Type    BotType
 	Running bool
+	Tasks   []TaskStatus
+
+	// WorkerStats is the latest stats of the worker dedicated to the Bot.
+	// This is nil when the Bot shares the worker or when the dedicated worker does not report via NewBotWorkerReporter.
+	WorkerStats *workers.Stats
+
+	// BlockedInputs is the number of the incoming messages that could not be enqueued to the worker.
+	BlockedInputs int
 }
 
 type status struct {
-	bots     []*botStatus
-	finished chan struct{}
-	mutex    sync.RWMutex
+	// queuedTasks is placed first to guarantee 64-bit alignment for atomic operations.
+	queuedTasks   int64
+	bots          []*botStatus
+	tasks         map[BotType]map[string]*taskHistory
+	workerStats   map[BotType]*workers.Stats
+	blockedInputs map[BotType]int
+	finished      chan struct{}
+	mutex         sync.RWMutex
+}
+
+// NewBotWorkerReporter creates and returns workers.Reporter that reports the stats of the worker dedicated to the given BotType.
+// The reported stats are available as BotStatus.WorkerStats via CurrentStatus.
+func NewBotWorkerReporter(botType BotType) workers.Reporter {
+	return &botWorkerReporter{
+		botType: botType,
+	}
+}
+
+type botWorkerReporter struct {
+	botType BotType
+}
+
+var _ workers.Reporter = (*botWorkerReporter)(nil)
+
+func (r *botWorkerReporter) Report(_ context.Context, stats *workers.Stats) {
+	log.Infof("Worker queue length for %s: %d", r.botType, stats.QueueSize)
+	runnerStatus.setWorkerStats(r.botType, stats)
 }
 
 func (s *status) running() bool {
@@ -103,15 +144,102 @@ func (s *status) snapshot() Status {
 	var bots []BotStatus
 	for _, botStatus := range s.bots {
 		bs := BotStatus{
-			Type:    botStatus.botType,
-			Running: botStatus.running(),
+			Type:          botStatus.botType,
+			Running:       botStatus.running(),
+			Tasks:         s.taskStatuses(botStatus.botType),
+			WorkerStats:   s.workerStats[botStatus.botType],
+			BlockedInputs: s.blockedInputs[botStatus.botType],
 		}
 		bots = append(bots, bs)
 	}
 	return Status{
-		Running: s.running(),
-		Bots:    bots,
+		Running:       s.running(),
+		Bots:          bots,
+		TaskQueueSize: s.queuedTaskCount(),
+	}
+}
+
+// addQueuedTasks adds delta to the number of scheduled executions waiting in the worker's queue.
+func (s *status) addQueuedTasks(delta int64) {
+	atomic.AddInt64(&s.queuedTasks, delta)
+}
+
+// queuedTaskCount returns the number of scheduled executions waiting in the worker's queue.
+func (s *status) queuedTaskCount() int {
+	return int(atomic.LoadInt64(&s.queuedTasks))
+}
+
+// setWorkerStats stores the latest stats of the worker dedicated to the given BotType.
+func (s *status) setWorkerStats(botType BotType, stats *workers.Stats) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.workerStats == nil {
+		s.workerStats = map[BotType]*workers.Stats{}
+	}
+	s.workerStats[botType] = stats
+}
+
+// addBlockedInput counts the incoming message of the given BotType that could not be enqueued to the worker.
+func (s *status) addBlockedInput(botType BotType) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.blockedInputs == nil {
+		s.blockedInputs = map[BotType]int{}
+	}
+	s.blockedInputs[botType]++
+}
+
+// taskHistory returns the execution history of the given scheduled task.
+// The history is created with the given size on the first call.
+func (s *status) taskHistory(botType BotType, taskID string, size int) *taskHistory {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.tasks == nil {
+		s.tasks = map[BotType]map[string]*taskHistory{}
+	}
+
+	histories, ok := s.tasks[botType]
+	if !ok {
+		histories = map[string]*taskHistory{}
+		s.tasks[botType] = histories
+	}
+
+	history, ok := histories[taskID]
+	if !ok {
+		history = newTaskHistory(taskID, size)
+		histories[taskID] = history
+	}
+	return history
+}
+
+func (s *status) findTaskHistory(botType BotType, taskID string) *taskHistory {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.tasks[botType][taskID]
+}
+
+// taskStatuses returns the statuses of the given Bot's scheduled tasks sorted by their identifiers.
+// The caller must hold the lock.
+func (s *status) taskStatuses(botType BotType) []TaskStatus {
+	histories := s.tasks[botType]
+	if len(histories) == 0 {
+		return nil
+	}
+
+	var tasks []TaskStatus
+	for _, history := range histories {
+		task := history.snapshot()
+		task.Paused = pausedTasks.paused(botType, task.Identifier)
+		tasks = append(tasks, task)
 	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Identifier < tasks[j].Identifier
+	})
+	return tasks
 }
 
 func (s *status) stop() {
@@ -1,6 +1,9 @@
 package sarah
 
 import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v3/workers"
 	"testing"
 	"time"
 )
@@ -32,6 +35,23 @@ func TestCurrentStatus(t *testing.T) {
 	if currentStatus.Bots[0].Type != botType {
 		t.Errorf("Expected BotType is not set. %#v", currentStatus.Bots[0])
 	}
+
+	if len(currentStatus.Bots[0].Tasks) != 0 {
+		t.Errorf("No task should be executed at this point: %#v.", currentStatus.Bots[0].Tasks)
+	}
+
+	runnerStatus.taskHistory(botType, "b", 3).record(&TaskExecution{}, 0)
+	runnerStatus.taskHistory(botType, "a", 3).record(&TaskExecution{Err: errors.New("dummy")}, 0)
+	runnerStatus.taskHistory("other", "c", 3).record(&TaskExecution{}, 0)
+
+	tasks := CurrentStatus().Bots[0].Tasks
+	if len(tasks) != 2 {
+		t.Fatalf("Unexpected number of TaskStatus is returned: %d.", len(tasks))
+	}
+
+	if tasks[0].Identifier != "a" || tasks[0].ConsecutiveFailures != 1 || tasks[1].Identifier != "b" {
+		t.Errorf("Unexpected TaskStatus is returned: %#v.", tasks)
+	}
 }
 
 func Test_status_start(t *testing.T) {
@@ -183,6 +203,30 @@ func Test_status_snapshot(t *testing.T) {
 	}
 }
 
+func TestNewBotWorkerReporter(t *testing.T) {
+	SetupAndRun(func() {
+		botType := BotType("dummy")
+		runnerStatus.addBot(&DummyBot{BotTypeValue: botType})
+
+		if stats := CurrentStatus().Bots[0].WorkerStats; stats != nil {
+			t.Fatalf("Stats should not be reported at this point: %#v.", stats)
+		}
+
+		reporter := NewBotWorkerReporter(botType)
+		reporter.Report(context.TODO(), &workers.Stats{QueueSize: 3})
+		runnerStatus.addBlockedInput(botType)
+		runnerStatus.addBlockedInput(botType)
+
+		bs := CurrentStatus().Bots[0]
+		if bs.WorkerStats == nil || bs.WorkerStats.QueueSize != 3 {
+			t.Errorf("Reported stats are not returned: %#v.", bs.WorkerStats)
+		}
+		if bs.BlockedInputs != 2 {
+			t.Errorf("Unexpected number of blocked inputs is returned: %d.", bs.BlockedInputs)
+		}
+	})
+}
+
 func Test_botStatus_running(t *testing.T) {
 	bs := &botStatus{
 		botType:  "dummy",
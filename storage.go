@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/patrickmn/go-cache"
+	"sync"
 	"time"
 )
 
@@ -55,8 +56,37 @@ type UserContext struct {
 	// Serializable, on the other hand, contains arguments and function identifier to be stored in external storage.
 	// When user input is given next time, serialized SerializableArgument is fetched from storage, deserialized, and fed to pre-registered function.
 	// Pre-registered function is identified by SerializableArgument.FuncIdentifier.
+	// Register such function with RegisterContextualFunc so go-sarah's core can resume the conversation.
 	// A reference implementation is available at https://github.com/oklahomer/go-sarah-rediscontext
 	Serializable *SerializableArgument
+
+	// ExpiresIn overrides the storage's default expiration such as CacheConfig.ExpiresIn when this is positive.
+	// Set a shorter duration for a conversation that should not last long, or a longer one for a conversation that takes time to answer.
+	ExpiresIn time.Duration
+
+	// ExpirationMessage is sent to the destination of the Input that started this context when the context expires without the user's further input.
+	// This is sent only when the storage implements ExpirationNotifier.
+	ExpirationMessage interface{}
+
+	// OnExpire is called when this context expires without the user's further input.
+	// This is called only when the storage implements ExpirationNotifier.
+	OnExpire func(context.Context)
+
+	// Scope is the ScopeResolver that derives the key to store this context.
+	// When this is nil, the ScopeResolver of the current conversation, the Command, or the Bot is used in this order.
+	// e.g. Set ScopePerChannel to let everyone in the channel continue the conversation.
+	Scope ScopeResolver
+
+	// Parent is the context to be resumed after this context finishes.
+	// Use PushUserContext to stack a nested conversation on top of the current one.
+	// defaultBot keeps the ancestors of the resumed context, so a ContextualFunc does not have to set this for each step.
+	Parent *UserContext
+
+	// returned is set by ReturnToParent to resume the parent context with the value.
+	returned *ReturnedValue
+
+	// replyTo is set by defaultBot on store so ExpirationMessage can be sent to where the conversation took place.
+	replyTo OutputDestination
 }
 
 // NewUserContext creates and returns new UserContext with given ContextualFunc.
@@ -67,29 +97,78 @@ func NewUserContext(next ContextualFunc) *UserContext {
 	}
 }
 
+// NewSerializableUserContext creates and returns new UserContext with given function identifier and argument.
+// The function must be registered with RegisterContextualFunc under the same identifier.
+// Unlike NewUserContext, the returned context can be stored in external storage and be resumed by another process.
+func NewSerializableUserContext(funcIdentifier string, argument interface{}) *UserContext {
+	return &UserContext{
+		Serializable: &SerializableArgument{
+			FuncIdentifier: funcIdentifier,
+			Argument:       argument,
+		},
+	}
+}
+
 // UserContextStorage defines an interface of Bot's storage mechanism for users' conversational contexts.
+//
+// Get returns the stored *UserContext as is.
+// An implementation that stores contexts in external storage should store UserContext.Serializable,
+// and return *UserContext with Serializable field on Get.
+// EncodeSerializableArgument and DecodeSerializableArgument may help such serialization.
+// go-sarah's core then resolves the function registered via RegisterContextualFunc and decodes the argument to its registered type.
 type UserContextStorage interface {
-	Get(string) (ContextualFunc, error)
+	Get(string) (*UserContext, error)
 	Set(string, *UserContext) error
 	Delete(string) error
 	Flush() error
 }
 
+// ExpirationNotifier is an optional interface that UserContextStorage implementation may satisfy to notify each UserContext's expiration.
+// defaultBot registers its handler on Bot.Run to send UserContext.ExpirationMessage and call UserContext.OnExpire.
+//
+// The handler must not be called when the stored context is explicitly deleted, overwritten, or flushed.
+//
+// A UserContextStorage that does not satisfy this silently drops expired contexts, so UserContext.ExpirationMessage and UserContext.OnExpire are ignored.
+// The default storage created by NewUserContextStorage satisfies this.
+// Among the storages package, the file-based storage satisfies this only for the contexts stored by the running process,
+// and the RESP-based storage does not satisfy this since its contexts expire on the server side.
+type ExpirationNotifier interface {
+	NotifyExpiration(func(key string, userContext *UserContext))
+}
+
 // defaultUserContextStorage is the default implementation of UserContexts.
 // This stores user contexts in-memory.
 type defaultUserContextStorage struct {
-	cache *cache.Cache
+	cache          *cache.Cache
+	expiresIn      time.Duration
+	timers         map[string]*time.Timer
+	expirationFunc func(string, *UserContext)
+	mutex          sync.Mutex
 }
 
+var _ ExpirationNotifier = (*defaultUserContextStorage)(nil)
+
 // NewUserContextStorage creates and returns new defaultUserContextStorage instance to store users' conversational contexts.
+// The returned storage satisfies ExpirationNotifier, so each context's expiration is notified to the Bot.
 func NewUserContextStorage(config *CacheConfig) UserContextStorage {
 	return &defaultUserContextStorage{
-		cache: cache.New(config.ExpiresIn, config.CleanupInterval),
+		cache:     cache.New(config.ExpiresIn, config.CleanupInterval),
+		expiresIn: config.ExpiresIn,
+		timers:    map[string]*time.Timer{},
 	}
 }
 
+// NotifyExpiration registers a function that is called when a stored UserContext expires.
+// Only the contexts stored after this call are notified.
+func (storage *defaultUserContextStorage) NotifyExpiration(fnc func(key string, userContext *UserContext)) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.expirationFunc = fnc
+}
+
 // Get searches for user's stored state with given user key, and return it if any found.
-func (storage *defaultUserContextStorage) Get(key string) (ContextualFunc, error) {
+func (storage *defaultUserContextStorage) Get(key string) (*UserContext, error) {
 	val, hasKey := storage.cache.Get(key)
 	if !hasKey || val == nil {
 		return nil, nil
@@ -97,7 +176,7 @@ func (storage *defaultUserContextStorage) Get(key string) (ContextualFunc, error
 
 	switch v := val.(type) {
 	case *UserContext:
-		return v.Next, nil
+		return v, nil
 
 	default:
 		return nil, fmt.Errorf("cached value has illegal type of %T", v)
@@ -108,23 +187,76 @@ func (storage *defaultUserContextStorage) Get(key string) (ContextualFunc, error
 // Delete removes currently stored user's conversational context.
 // This does nothing if corresponding stored context is not found.
 func (storage *defaultUserContextStorage) Delete(key string) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.stopTimer(key)
 	storage.cache.Delete(key)
 	return nil
 }
 
 // Set stores given UserContext.
 // Stored context is tied to given key, which represents a particular user.
+// Both UserContext.Next and UserContext.Serializable are stored in memory as is.
+// The context expires after UserContext.ExpiresIn if given, or CacheConfig.ExpiresIn otherwise.
 func (storage *defaultUserContextStorage) Set(key string, userContext *UserContext) error {
-	if userContext.Next == nil {
-		return errors.New("required UserContext.Next is not set. defaultUserContextStorage only supports in-memory ContextualFunc cache")
+	if userContext.Next == nil && userContext.Serializable == nil {
+		return errors.New("either UserContext.Next or UserContext.Serializable must be set")
 	}
 
-	storage.cache.Set(key, userContext, cache.DefaultExpiration)
+	expiresIn := storage.expiresIn
+	if userContext.ExpiresIn > 0 {
+		expiresIn = userContext.ExpiresIn
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.stopTimer(key)
+	storage.cache.Set(key, userContext, expiresIn)
+
+	if storage.expirationFunc == nil || expiresIn <= 0 {
+		return nil
+	}
+
+	// go-cache only evicts expired items on its periodical cleanup, so a timer is set to notify the expiration on time.
+	fnc := storage.expirationFunc
+	var timer *time.Timer
+	timer = time.AfterFunc(expiresIn, func() {
+		storage.mutex.Lock()
+		if storage.timers[key] != timer {
+			// Already deleted or overwritten.
+			storage.mutex.Unlock()
+			return
+		}
+		delete(storage.timers, key)
+		storage.cache.Delete(key)
+		storage.mutex.Unlock()
+
+		fnc(key, userContext)
+	})
+	storage.timers[key] = timer
+
 	return nil
 }
 
 // Flush removes all stored UserContext from its storage.
 func (storage *defaultUserContextStorage) Flush() error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	for key := range storage.timers {
+		storage.stopTimer(key)
+	}
 	storage.cache.Flush()
 	return nil
 }
+
+func (storage *defaultUserContextStorage) stopTimer(key string) {
+	timer, ok := storage.timers[key]
+	if !ok {
+		return
+	}
+	timer.Stop()
+	delete(storage.timers, key)
+}
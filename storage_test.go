@@ -8,13 +8,13 @@ import (
 )
 
 type DummyUserContextStorage struct {
-	GetFunc    func(string) (ContextualFunc, error)
+	GetFunc    func(string) (*UserContext, error)
 	SetFunc    func(string, *UserContext) error
 	DeleteFunc func(string) error
 	FlushFunc  func() error
 }
 
-func (storage *DummyUserContextStorage) Get(key string) (ContextualFunc, error) {
+func (storage *DummyUserContextStorage) Get(key string) (*UserContext, error) {
 	return storage.GetFunc(key)
 }
 
@@ -49,6 +49,23 @@ func TestDefaultUserContextStorage_Set_WithEmptyNext(t *testing.T) {
 	}
 }
 
+func TestDefaultUserContextStorage_Set_WithSerializable(t *testing.T) {
+	storage := &defaultUserContextStorage{
+		cache: cache.New(3*time.Minute, 10*time.Minute),
+	}
+
+	userContext := NewSerializableUserContext("foo", &struct{}{})
+	err := storage.Set("key", userContext)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	stored, _ := storage.Get("key")
+	if stored != userContext {
+		t.Errorf("Stored context is not returned: %#v.", stored)
+	}
+}
+
 func TestDefaultUserContextStorage_CRUD(t *testing.T) {
 	storage := &defaultUserContextStorage{
 		cache: cache.New(3*time.Minute, 10*time.Minute),
@@ -85,3 +102,62 @@ func TestDefaultUserContextStorage_CRUD(t *testing.T) {
 		t.Errorf("Invalid stored value shouldn't be returned: %T", invalidVal)
 	}
 }
+
+func TestDefaultUserContextStorage_Set_WithExpiresIn(t *testing.T) {
+	storage := NewUserContextStorage(NewCacheConfig()).(*defaultUserContextStorage)
+
+	userContext := NewUserContext(func(_ context.Context, _ Input) (*CommandResponse, error) { return nil, nil })
+	userContext.ExpiresIn = 10 * time.Millisecond
+	_ = storage.Set("key", userContext)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if stored, _ := storage.Get("key"); stored != nil {
+		t.Errorf("Expired context is returned: %#v.", stored)
+	}
+}
+
+func TestDefaultUserContextStorage_NotifyExpiration(t *testing.T) {
+	storage := NewUserContextStorage(NewCacheConfig()).(*defaultUserContextStorage)
+
+	expired := make(chan string, 3)
+	storage.NotifyExpiration(func(key string, _ *UserContext) {
+		expired <- key
+	})
+
+	newContext := func() *UserContext {
+		userContext := NewUserContext(func(_ context.Context, _ Input) (*CommandResponse, error) { return nil, nil })
+		userContext.ExpiresIn = 10 * time.Millisecond
+		return userContext
+	}
+	_ = storage.Set("expiring", newContext())
+	_ = storage.Set("deleted", newContext())
+	_ = storage.Delete("deleted")
+	_ = storage.Set("overwritten", newContext())
+	_ = storage.Set("overwritten", NewUserContext(func(_ context.Context, _ Input) (*CommandResponse, error) { return nil, nil }))
+
+	select {
+	case key := <-expired:
+		if key != "expiring" {
+			t.Errorf("Unexpected key is notified: %s.", key)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Expiration is not notified.")
+
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if len(expired) != 0 {
+		t.Errorf("Deleted or overwritten context is notified: %s.", <-expired)
+	}
+
+	if stored, _ := storage.Get("overwritten"); stored == nil {
+		t.Error("Overwritten context must be kept with the default expiration.")
+	}
+
+	_ = storage.Flush()
+	if len(storage.timers) != 0 {
+		t.Errorf("Timers are not stopped on flush: %d.", len(storage.timers))
+	}
+}
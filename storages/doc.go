@@ -0,0 +1,23 @@
+/*
+Package storages provides implementations of go-sarah's pluggable storage interfaces that persist state
+outside of the process memory, so the state survives restarts and deployments.
+
+The package provides the following implementations:
+
+  - NewFileUserContextStorage and NewRESPUserContextStorage return sarah.UserContextStorage to store users' conversational contexts.
+    Pass the storage to sarah.BotWithStorage.
+  - NewFileBrain returns sarah.Brain to store plugins' values. Register it with sarah.RegisterBrain.
+  - NewFileTaskLocker returns sarah.TaskLocker to run each occurrence of a scheduled task on only one of the replicas sharing a volume.
+    Register it with sarah.RegisterTaskLocker. This is not supported on Windows.
+  - NewFileTaskRunStore returns sarah.TaskRunStore to catch up the scheduled executions missed during downtime or pause.
+    Register it with sarah.RegisterTaskRunStore.
+  - NewFileTaskPauseStore returns sarah.TaskPauseStore to keep the tasks paused via sarah.PauseTask across restarts.
+    Register it with sarah.RegisterTaskPauseStore.
+
+Only serializable contexts can be persisted by the UserContextStorage implementations.
+Return sarah.UserContext with Serializable field, and register the corresponding function with sarah.RegisterContextualFunc.
+sarah.UserContext.ExpiresIn is honored, but sarah.UserContext.ExpirationMessage and sarah.UserContext.OnExpire are not persisted.
+The file-based storage satisfies sarah.ExpirationNotifier only for the contexts stored by the running process,
+and the RESP-based storage does not notify expirations at all.
+*/
+package storages
@@ -0,0 +1,411 @@
+package storages
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const fileBrainName = "brain.log"
+
+// FileBrainConfig contains some configuration variables for file-backed sarah.Brain.
+type FileBrainConfig struct {
+	// DataDir is the directory to store the data file.
+	DataDir string `json:"data_dir" yaml:"data_dir"`
+
+	// CompactionInterval is the interval to rewrite the data file with only valid values.
+	// Set zero to disable scheduled compaction.
+	CompactionInterval time.Duration `json:"compaction_interval" yaml:"compaction_interval"`
+}
+
+// NewFileBrainConfig creates and returns new FileBrainConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewFileBrainConfig() *FileBrainConfig {
+	return &FileBrainConfig{
+		DataDir:            "",
+		CompactionInterval: 10 * time.Minute,
+	}
+}
+
+// brainRecord represents one line of the append-only brain data file.
+type brainRecord struct {
+	Op        string    `json:"op"`
+	Namespace string    `json:"namespace"`
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Value     []byte    `json:"value,omitempty"`
+}
+
+type brainEntry struct {
+	expiresAt time.Time
+	value     []byte
+}
+
+func (e *brainEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// NewFileBrain creates and returns new sarah.Brain implementation that persists plugins' values to a local data directory.
+// Each operation is appended to a data file, and the file is replayed on construction so stored values survive process restarts.
+// The data file is compacted at the interval of FileBrainConfig.CompactionInterval until the given ctx is canceled.
+//
+//  config := storages.NewFileBrainConfig()
+//  config.DataDir = "/var/lib/mybot"
+//  brain, err := storages.NewFileBrain(ctx, config)
+//  sarah.RegisterBrain(brain)
+func NewFileBrain(ctx context.Context, config *FileBrainConfig) (sarah.Brain, error) {
+	if config.DataDir == "" {
+		return nil, errors.New("FileBrainConfig.DataDir must be set")
+	}
+
+	err := os.MkdirAll(config.DataDir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", config.DataDir, err)
+	}
+
+	brain := &fileBrain{
+		path:    filepath.Join(config.DataDir, fileBrainName),
+		entries: map[string]map[string]*brainEntry{},
+		now:     time.Now,
+	}
+
+	err = brain.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove expired or overwritten records left by the previous process.
+	err = brain.compact()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.CompactionInterval > 0 {
+		go brain.runCompaction(ctx, config.CompactionInterval)
+	}
+
+	return brain, nil
+}
+
+type fileBrain struct {
+	path    string
+	entries map[string]map[string]*brainEntry
+	file    *os.File
+	now     func() time.Time
+	mutex   sync.Mutex
+}
+
+var _ sarah.Brain = (*fileBrain)(nil)
+
+// entry returns the valid entry for the given namespace and key.
+// The caller must hold the lock.
+func (brain *fileBrain) entry(namespace string, key string) *brainEntry {
+	entry, ok := brain.entries[namespace][key]
+	if !ok {
+		return nil
+	}
+
+	if entry.expired(brain.now()) {
+		delete(brain.entries[namespace], key)
+		return nil
+	}
+
+	return entry
+}
+
+// put appends a set record and updates the in-memory entry.
+// The caller must hold the lock.
+func (brain *fileBrain) put(namespace string, key string, value []byte, expiresAt time.Time) error {
+	err := brain.append(&brainRecord{
+		Op:        opSet,
+		Namespace: namespace,
+		Key:       key,
+		ExpiresAt: expiresAt,
+		Value:     value,
+	})
+	if err != nil {
+		return err
+	}
+
+	entries, ok := brain.entries[namespace]
+	if !ok {
+		entries = map[string]*brainEntry{}
+		brain.entries[namespace] = entries
+	}
+	entries[key] = &brainEntry{
+		expiresAt: expiresAt,
+		value:     value,
+	}
+	return nil
+}
+
+// Get returns the value stored with the given namespace and key.
+func (brain *fileBrain) Get(_ context.Context, namespace string, key string) ([]byte, error) {
+	brain.mutex.Lock()
+	defer brain.mutex.Unlock()
+
+	entry := brain.entry(namespace, key)
+	if entry == nil {
+		return nil, nil
+	}
+
+	value := make([]byte, len(entry.value))
+	copy(value, entry.value)
+	return value, nil
+}
+
+// Set stores the value with the given namespace and key.
+func (brain *fileBrain) Set(_ context.Context, namespace string, key string, value []byte, ttl time.Duration) error {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = brain.now().Add(ttl)
+	}
+
+	stored := make([]byte, len(value))
+	copy(stored, value)
+
+	brain.mutex.Lock()
+	defer brain.mutex.Unlock()
+
+	return brain.put(namespace, key, stored, expiresAt)
+}
+
+// Delete removes the value stored with the given namespace and key.
+// This does nothing if corresponding value is not found.
+func (brain *fileBrain) Delete(_ context.Context, namespace string, key string) error {
+	brain.mutex.Lock()
+	defer brain.mutex.Unlock()
+
+	if _, ok := brain.entries[namespace][key]; !ok {
+		return nil
+	}
+
+	err := brain.append(&brainRecord{
+		Op:        opDelete,
+		Namespace: namespace,
+		Key:       key,
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(brain.entries[namespace], key)
+	return nil
+}
+
+// List returns the sorted keys stored under the given namespace.
+func (brain *fileBrain) List(_ context.Context, namespace string) ([]string, error) {
+	brain.mutex.Lock()
+	defer brain.mutex.Unlock()
+
+	now := brain.now()
+	keys := []string{}
+	for key, entry := range brain.entries[namespace] {
+		if entry.expired(now) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Increment atomically adds delta to the integer value stored with the given namespace and key.
+func (brain *fileBrain) Increment(_ context.Context, namespace string, key string, delta int64) (int64, error) {
+	brain.mutex.Lock()
+	defer brain.mutex.Unlock()
+
+	var current int64
+	var expiresAt time.Time
+	if entry := brain.entry(namespace, key); entry != nil {
+		i, err := sarah.ParseBrainInteger(entry.value)
+		if err != nil {
+			return 0, err
+		}
+		current = i
+		expiresAt = entry.expiresAt
+	}
+
+	current += delta
+	err := brain.put(namespace, key, []byte(strconv.FormatInt(current, 10)), expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return current, nil
+}
+
+func (brain *fileBrain) load() error {
+	brain.mutex.Lock()
+	defer brain.mutex.Unlock()
+
+	f, err := os.Open(brain.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open data file %s: %w", brain.path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		record := &brainRecord{}
+		err := json.Unmarshal(scanner.Bytes(), record)
+		if err != nil {
+			// The last line may be partially written when the previous process crashed.
+			log.Warnf("Skipping malformed record in %s: %+v", brain.path, err)
+			continue
+		}
+
+		switch record.Op {
+		case opSet:
+			entries, ok := brain.entries[record.Namespace]
+			if !ok {
+				entries = map[string]*brainEntry{}
+				brain.entries[record.Namespace] = entries
+			}
+			entries[record.Key] = &brainEntry{
+				expiresAt: record.ExpiresAt,
+				value:     record.Value,
+			}
+
+		case opDelete:
+			delete(brain.entries[record.Namespace], record.Key)
+
+		default:
+			log.Warnf("Skipping unknown operation in %s: %s", brain.path, record.Op)
+
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read data file %s: %w", brain.path, err)
+	}
+	return nil
+}
+
+func (brain *fileBrain) append(record *brainRecord) error {
+	if brain.file == nil {
+		f, err := os.OpenFile(brain.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open data file %s: %w", brain.path, err)
+		}
+		brain.file = f
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	_, err = brain.file.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write to data file %s: %w", brain.path, err)
+	}
+
+	return brain.file.Sync()
+}
+
+// rewrite writes all valid entries to a temporary file and atomically replaces the data file.
+// The caller must hold the lock.
+func (brain *fileBrain) rewrite() error {
+	tmpPath := brain.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file %s: %w", tmpPath, err)
+	}
+
+	now := brain.now()
+	w := bufio.NewWriter(tmp)
+	for namespace, entries := range brain.entries {
+		for key, entry := range entries {
+			if entry.expired(now) {
+				delete(entries, key)
+				continue
+			}
+
+			b, err := json.Marshal(&brainRecord{
+				Op:        opSet,
+				Namespace: namespace,
+				Key:       key,
+				ExpiresAt: entry.expiresAt,
+				Value:     entry.value,
+			})
+			if err != nil {
+				_ = tmp.Close()
+				return fmt.Errorf("failed to encode record: %w", err)
+			}
+
+			_, _ = w.Write(append(b, '\n'))
+		}
+
+		if len(entries) == 0 {
+			delete(brain.entries, namespace)
+		}
+	}
+
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temporary file %s: %w", tmpPath, err)
+	}
+
+	if brain.file != nil {
+		_ = brain.file.Close()
+		brain.file = nil
+	}
+
+	err = os.Rename(tmpPath, brain.path)
+	if err != nil {
+		return fmt.Errorf("failed to replace data file %s: %w", brain.path, err)
+	}
+	return nil
+}
+
+func (brain *fileBrain) compact() error {
+	brain.mutex.Lock()
+	defer brain.mutex.Unlock()
+
+	return brain.rewrite()
+}
+
+func (brain *fileBrain) runCompaction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			brain.mutex.Lock()
+			if brain.file != nil {
+				_ = brain.file.Close()
+				brain.file = nil
+			}
+			brain.mutex.Unlock()
+			return
+
+		case <-ticker.C:
+			err := brain.compact()
+			if err != nil {
+				log.Errorf("Failed to compact brain: %+v", err)
+			}
+
+		}
+	}
+}
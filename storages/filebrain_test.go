@@ -0,0 +1,163 @@
+package storages
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v3"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestFileBrain(t *testing.T, dir string) *fileBrain {
+	config := NewFileBrainConfig()
+	config.DataDir = dir
+	config.CompactionInterval = 0
+
+	brain, err := NewFileBrain(context.TODO(), config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	return brain.(*fileBrain)
+}
+
+func TestNewFileBrain(t *testing.T) {
+	_, err := NewFileBrain(context.TODO(), NewFileBrainConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty DataDir.")
+	}
+}
+
+func TestFileBrain_CRUD(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	ctx := context.TODO()
+	brain := newTestFileBrain(t, dir)
+
+	if value, _ := brain.Get(ctx, "ns", "key"); value != nil {
+		t.Fatalf("Nil should return on empty brain: %#v.", value)
+	}
+
+	err := brain.Set(ctx, "ns", "key", []byte("value"), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	_ = brain.Set(ctx, "ns", "another", []byte("value"), 0)
+	_ = brain.Set(ctx, "other", "key", []byte("other"), 0)
+
+	value, _ := brain.Get(ctx, "ns", "key")
+	if string(value) != "value" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	keys, _ := brain.List(ctx, "ns")
+	if !reflect.DeepEqual(keys, []string{"another", "key"}) {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+
+	err = brain.Delete(ctx, "ns", "key")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if value, _ := brain.Get(ctx, "ns", "key"); value != nil {
+		t.Errorf("Deleted value is returned: %s.", value)
+	}
+
+	if value, _ := brain.Get(ctx, "other", "key"); string(value) != "other" {
+		t.Errorf("Value in other namespace must not be deleted: %s.", value)
+	}
+}
+
+func TestFileBrain_Increment(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	ctx := context.TODO()
+	brain := newTestFileBrain(t, dir)
+
+	for i := 0; i < 3; i++ {
+		_, err := brain.Increment(ctx, "ns", "counter", 2)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+	}
+
+	cnt, _ := brain.Increment(ctx, "ns", "counter", -1)
+	if cnt != 5 {
+		t.Errorf("Unexpected count is returned: %d.", cnt)
+	}
+
+	_ = brain.Set(ctx, "ns", "string", []byte("foo"), 0)
+	_, err := brain.Increment(ctx, "ns", "string", 1)
+	if !errors.Is(err, sarah.ErrBrainValueNotInteger) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestFileBrain_Persistence(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	ctx := context.TODO()
+	brain := newTestFileBrain(t, dir)
+	_, _ = brain.Increment(ctx, "ns", "counter", 1)
+	_, _ = brain.Increment(ctx, "ns", "counter", 1)
+	_ = brain.Set(ctx, "ns", "deleted", []byte("value"), 0)
+	_ = brain.Delete(ctx, "ns", "deleted")
+	_ = brain.Set(ctx, "ns", "expiring", []byte("value"), time.Hour)
+	_ = brain.file.Close()
+
+	// Simulate a partially written line left by a crash.
+	f, _ := os.OpenFile(brain.path, os.O_WRONLY|os.O_APPEND, 0600)
+	_, _ = f.WriteString(`{"op":"set","names`)
+	_ = f.Close()
+
+	restored := newTestFileBrain(t, dir)
+	value, _ := restored.Get(ctx, "ns", "counter")
+	if string(value) != "2" {
+		t.Errorf("Unexpected value is restored: %s.", value)
+	}
+
+	keys, _ := restored.List(ctx, "ns")
+	if !reflect.DeepEqual(keys, []string{"counter", "expiring"}) {
+		t.Errorf("Unexpected keys are restored: %#v.", keys)
+	}
+
+	if restored.entries["ns"]["expiring"].expiresAt.IsZero() {
+		t.Error("Expiration is not restored.")
+	}
+}
+
+func TestFileBrain_Expiration(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	ctx := context.TODO()
+	now := time.Now()
+	brain := newTestFileBrain(t, dir)
+	brain.now = func() time.Time {
+		return now
+	}
+
+	_ = brain.Set(ctx, "ns", "key", []byte("value"), time.Minute)
+	now = now.Add(time.Minute)
+
+	if value, _ := brain.Get(ctx, "ns", "key"); value != nil {
+		t.Errorf("Expired value is returned: %s.", value)
+	}
+
+	_ = brain.Set(ctx, "ns", "expired", []byte("value"), time.Second)
+	now = now.Add(time.Second)
+	err := brain.compact()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if _, ok := brain.entries["ns"]; ok {
+		t.Error("Expired entries must be removed on compaction.")
+	}
+}
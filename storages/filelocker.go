@@ -0,0 +1,73 @@
+package storages
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// FileTaskLockerConfig contains some configuration variables for file-based sarah.TaskLocker.
+type FileTaskLockerConfig struct {
+	// LockDir is the directory to create lock files.
+	// Place this on a volume shared by all replicas.
+	LockDir string `json:"lock_dir" yaml:"lock_dir"`
+
+	// Owner is the identifier of this process that is written to lock files.
+	// When empty, the combination of hostname, process ID and a random value is used.
+	Owner string `json:"owner" yaml:"owner"`
+}
+
+// NewFileTaskLockerConfig creates and returns new FileTaskLockerConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewFileTaskLockerConfig() *FileTaskLockerConfig {
+	return &FileTaskLockerConfig{
+		LockDir: "",
+		Owner:   "",
+	}
+}
+
+// fileLease represents the content of a lock file.
+type fileLease struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (l *fileLease) expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+func validateFileTaskLockerConfig(config *FileTaskLockerConfig) error {
+	if config.LockDir == "" {
+		return errors.New("FileTaskLockerConfig.LockDir must be set")
+	}
+
+	err := os.MkdirAll(config.LockDir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create lock directory %s: %w", config.LockDir, err)
+	}
+	return nil
+}
+
+func defaultLockOwner() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
+
+	b := make([]byte, 4)
+	_, err = rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+
+	return fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), hex.EncodeToString(b)), nil
+}
+
+// lockFileName returns the file name for the given lock key.
+// The key is hex-encoded so any key is safely mapped to a file in the lock directory.
+func lockFileName(key string) string {
+	return hex.EncodeToString([]byte(key)) + ".lock"
+}
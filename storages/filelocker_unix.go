@@ -0,0 +1,248 @@
+// +build !windows
+
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// NewFileTaskLocker creates and returns new sarah.TaskLocker implementation that coordinates replicas with lock files on a shared volume.
+// Each lock file holds the owner and the expiration of the lease, and is guarded by flock(2) while it is read and updated.
+// Since a lock is acquired for each scheduled occurrence, expired lock files are periodically removed on TryLock.
+// Make sure the shared file system supports flock(2); e.g. NFS requires a proper configuration to support it.
+//
+//  config := storages.NewFileTaskLockerConfig()
+//  config.LockDir = "/mnt/shared/locks"
+//  locker, err := storages.NewFileTaskLocker(config)
+//  sarah.RegisterTaskLocker(locker)
+func NewFileTaskLocker(config *FileTaskLockerConfig) (sarah.TaskLocker, error) {
+	err := validateFileTaskLockerConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := config.Owner
+	if owner == "" {
+		owner, err = defaultLockOwner()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &fileTaskLocker{
+		dir:   config.LockDir,
+		owner: owner,
+		now:   time.Now,
+	}, nil
+}
+
+// lockSweepInterval is the minimum interval to remove expired lock files.
+const lockSweepInterval = time.Minute
+
+type fileTaskLocker struct {
+	dir       string
+	owner     string
+	now       func() time.Time
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+var _ sarah.TaskLocker = (*fileTaskLocker)(nil)
+
+// TryLock acquires the lock for the given key for the duration of ttl.
+// A valid lease is respected until it expires even if this locker holds it.
+func (l *fileTaskLocker) TryLock(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	l.sweep()
+
+	locked := false
+	err := l.withFile(key, func(f *os.File, lease *fileLease) error {
+		if lease != nil && !lease.expired(l.now()) {
+			return nil
+		}
+
+		err := writeLease(f, &fileLease{
+			Owner:     l.owner,
+			ExpiresAt: l.now().Add(ttl),
+		})
+		if err != nil {
+			return err
+		}
+
+		locked = true
+		return nil
+	})
+	return locked, err
+}
+
+// Renew extends the lease of the lock for the given key if this locker holds it.
+func (l *fileTaskLocker) Renew(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	renewed := false
+	err := l.withFile(key, func(f *os.File, lease *fileLease) error {
+		if lease == nil || lease.Owner != l.owner || lease.expired(l.now()) {
+			return nil
+		}
+
+		err := writeLease(f, &fileLease{
+			Owner:     l.owner,
+			ExpiresAt: l.now().Add(ttl),
+		})
+		if err != nil {
+			return err
+		}
+
+		renewed = true
+		return nil
+	})
+	return renewed, err
+}
+
+// Unlock releases the lock for the given key if this locker holds it.
+func (l *fileTaskLocker) Unlock(_ context.Context, key string) error {
+	return l.withFile(key, func(f *os.File, lease *fileLease) error {
+		if lease == nil || lease.Owner != l.owner {
+			return nil
+		}
+		return writeLease(f, nil)
+	})
+}
+
+// withFile opens the lock file for the given key, takes an exclusive flock(2), and passes the current lease to fnc.
+// The given lease is nil when no valid lease is written.
+func (l *fileTaskLocker) withFile(key string, fnc func(*os.File, *fileLease) error) error {
+	path := filepath.Join(l.dir, lockFileName(key))
+	f, err := openLockFile(path, os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		return err
+	}
+	defer closeLockFile(f)
+
+	lease, err := readLease(f)
+	if err != nil {
+		return err
+	}
+
+	return fnc(f, lease)
+}
+
+// sweep removes the expired lock files at most once in lockSweepInterval.
+// Errors are ignored since the files are removed on the next sweep.
+func (l *fileTaskLocker) sweep() {
+	l.mutex.Lock()
+	now := l.now()
+	if now.Sub(l.lastSweep) < lockSweepInterval {
+		l.mutex.Unlock()
+		return
+	}
+	l.lastSweep = now
+	l.mutex.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(l.dir, "*.lock"))
+	if err != nil {
+		return
+	}
+
+	for _, path := range paths {
+		f, err := openLockFile(path, os.O_RDWR)
+		if err != nil {
+			continue
+		}
+
+		lease, err := readLease(f)
+		if err == nil && (lease == nil || lease.expired(now)) {
+			// Remove while holding flock(2) so another process waiting for the lock notices the removal and opens a new file.
+			_ = os.Remove(path)
+		}
+		closeLockFile(f)
+	}
+}
+
+// openLockFile opens the lock file at the given path and takes an exclusive flock(2).
+// When the file is removed by another process's sweep while waiting for the lock, the file is opened again.
+func openLockFile(path string, flag int) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, flag, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+		}
+
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock file %s: %w", path, err)
+		}
+
+		opened, err := f.Stat()
+		if err != nil {
+			closeLockFile(f)
+			return nil, fmt.Errorf("failed to stat lock file %s: %w", path, err)
+		}
+		current, err := os.Stat(path)
+		if err == nil && os.SameFile(opened, current) {
+			return f, nil
+		}
+
+		closeLockFile(f)
+		if flag&os.O_CREATE == 0 {
+			return nil, fmt.Errorf("lock file %s is removed", path)
+		}
+	}
+}
+
+func closeLockFile(f *os.File) {
+	_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	_ = f.Close()
+}
+
+// readLease reads the lease from the given lock file.
+// Nil is returned when no valid lease is written.
+func readLease(f *os.File) (*fileLease, error) {
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file %s: %w", f.Name(), err)
+	}
+
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	lease := &fileLease{}
+	if json.Unmarshal(b, lease) != nil {
+		// Broken content is considered as no lease.
+		return nil, nil
+	}
+	return lease, nil
+}
+
+// writeLease replaces the content of the lock file with the given lease.
+// A nil lease empties the file.
+func writeLease(f *os.File, lease *fileLease) error {
+	var b []byte
+	if lease != nil {
+		var err error
+		b, err = json.Marshal(lease)
+		if err != nil {
+			return fmt.Errorf("failed to encode lease: %w", err)
+		}
+	}
+
+	err := f.Truncate(0)
+	if err == nil {
+		_, err = f.WriteAt(b, 0)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write lock file %s: %w", f.Name(), err)
+	}
+	return nil
+}
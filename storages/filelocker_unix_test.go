@@ -0,0 +1,151 @@
+// +build !windows
+
+package storages
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestFileTaskLocker(t *testing.T, dir string, owner string) *fileTaskLocker {
+	config := NewFileTaskLockerConfig()
+	config.LockDir = dir
+	config.Owner = owner
+
+	locker, err := NewFileTaskLocker(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	return locker.(*fileTaskLocker)
+}
+
+func TestNewFileTaskLocker(t *testing.T) {
+	_, err := NewFileTaskLocker(NewFileTaskLockerConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty LockDir.")
+	}
+
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	config := NewFileTaskLockerConfig()
+	config.LockDir = filepath.Join(dir, "nested")
+	locker, err := NewFileTaskLocker(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	owner := locker.(*fileTaskLocker).owner
+	if !strings.Contains(owner, ":") {
+		t.Errorf("Default owner is not set: %s.", owner)
+	}
+}
+
+func TestFileTaskLocker(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	ctx := context.TODO()
+	now := time.Now()
+	replica1 := newTestFileTaskLocker(t, dir, "replica1")
+	replica2 := newTestFileTaskLocker(t, dir, "replica2")
+	for _, locker := range []*fileTaskLocker{replica1, replica2} {
+		locker.now = func() time.Time {
+			return now
+		}
+	}
+
+	locked, err := replica1.TryLock(ctx, "slack:morning", time.Minute)
+	if err != nil || !locked {
+		t.Fatalf("Lock should be acquired: %t, %+v.", locked, err)
+	}
+
+	if locked, _ := replica2.TryLock(ctx, "slack:morning", time.Minute); locked {
+		t.Error("Lock held by another replica should not be acquired.")
+	}
+
+	if locked, _ := replica1.TryLock(ctx, "slack:morning", time.Minute); locked {
+		t.Error("Lock should not be acquired again while the lease is valid.")
+	}
+
+	if renewed, _ := replica2.Renew(ctx, "slack:morning", time.Minute); renewed {
+		t.Error("Lease held by another replica should not be renewed.")
+	}
+
+	now = now.Add(30 * time.Second)
+	if renewed, err := replica1.Renew(ctx, "slack:morning", time.Minute); err != nil || !renewed {
+		t.Errorf("Lease held by the same replica should be renewed: %t, %+v.", renewed, err)
+	}
+
+	now = now.Add(45 * time.Second)
+	if locked, _ := replica2.TryLock(ctx, "slack:morning", time.Minute); locked {
+		t.Error("Lock should not be acquired while the renewed lease is valid.")
+	}
+
+	if locked, _ := replica2.TryLock(ctx, "slack/evening", time.Minute); !locked {
+		t.Error("Lock with another key should be acquired.")
+	}
+
+	// Unlocking the lock held by another replica does nothing.
+	_ = replica2.Unlock(ctx, "slack:morning")
+	if locked, _ := replica2.TryLock(ctx, "slack:morning", time.Minute); locked {
+		t.Error("Lock should not be released by another replica.")
+	}
+
+	err = replica1.Unlock(ctx, "slack:morning")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if locked, _ := replica2.TryLock(ctx, "slack:morning", time.Minute); !locked {
+		t.Error("Lock should be acquired after unlock.")
+	}
+
+	now = now.Add(time.Minute)
+	if locked, _ := replica1.TryLock(ctx, "slack:morning", time.Minute); !locked {
+		t.Error("Lock should be acquired after the lease expires.")
+	}
+}
+
+func TestFileTaskLocker_BrokenFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	_ = ioutil.WriteFile(filepath.Join(dir, lockFileName("key")), []byte("{"), 0600)
+
+	locker := newTestFileTaskLocker(t, dir, "replica1")
+	if locked, _ := locker.TryLock(context.TODO(), "key", time.Minute); !locked {
+		t.Error("Broken lock file should be considered as no lease.")
+	}
+}
+
+func TestFileTaskLocker_sweep(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	ctx := context.TODO()
+	now := time.Now()
+	locker := newTestFileTaskLocker(t, dir, "replica1")
+	locker.now = func() time.Time {
+		return now
+	}
+
+	_, _ = locker.TryLock(ctx, "slack:morning@2027-01-01T00:00:00Z", time.Minute)
+	_, _ = locker.TryLock(ctx, "slack:morning@2027-01-01T00:01:00Z", time.Hour)
+
+	now = now.Add(2 * time.Minute)
+	_, _ = locker.TryLock(ctx, "slack:morning@2027-01-01T00:02:00Z", time.Minute)
+
+	if _, err := os.Stat(filepath.Join(dir, lockFileName("slack:morning@2027-01-01T00:00:00Z"))); !os.IsNotExist(err) {
+		t.Error("Expired lock file should be removed.")
+	}
+	for _, key := range []string{"slack:morning@2027-01-01T00:01:00Z", "slack:morning@2027-01-01T00:02:00Z"} {
+		if _, err := os.Stat(filepath.Join(dir, lockFileName(key))); err != nil {
+			t.Errorf("Valid lock file should remain: %s.", key)
+		}
+	}
+}
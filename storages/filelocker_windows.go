@@ -0,0 +1,14 @@
+// +build windows
+
+package storages
+
+import (
+	"errors"
+	"github.com/oklahomer/go-sarah/v3"
+)
+
+// NewFileTaskLocker is not supported on Windows since flock(2) is not available.
+// Implement sarah.TaskLocker with a database or other shared storage instead.
+func NewFileTaskLocker(_ *FileTaskLockerConfig) (sarah.TaskLocker, error) {
+	return nil, errors.New("file-based TaskLocker is not supported on windows")
+}
@@ -0,0 +1,435 @@
+package storages
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrNonSerializableContext is returned when a given sarah.UserContext has no Serializable field.
+// A plain function can not be persisted, so sarah.UserContext.Serializable must be set.
+var ErrNonSerializableContext = sarah.ErrNonSerializableUserContext
+
+const fileStorageName = "user_contexts.log"
+
+const (
+	opSet    = "set"
+	opDelete = "delete"
+)
+
+// FileConfig contains some configuration variables for file-backed storage.
+type FileConfig struct {
+	// DataDir is the directory to store the data file.
+	DataDir string `json:"data_dir" yaml:"data_dir"`
+
+	// ExpiresIn is the duration a stored context stays valid.
+	ExpiresIn time.Duration `json:"expires_in" yaml:"expires_in"`
+
+	// CompactionInterval is the interval to rewrite the data file with only valid contexts.
+	// Set zero to disable scheduled compaction.
+	CompactionInterval time.Duration `json:"compaction_interval" yaml:"compaction_interval"`
+}
+
+// NewFileConfig creates and returns new FileConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewFileConfig() *FileConfig {
+	return &FileConfig{
+		DataDir:            "",
+		ExpiresIn:          3 * time.Minute,
+		CompactionInterval: 10 * time.Minute,
+	}
+}
+
+// fileRecord represents one line of the append-only data file.
+type fileRecord struct {
+	Op        string          `json:"op"`
+	Key       string          `json:"key"`
+	ExpiresAt time.Time       `json:"expires_at,omitempty"`
+	Argument  json.RawMessage `json:"argument,omitempty"`
+}
+
+type fileEntry struct {
+	expiresAt time.Time
+	argument  json.RawMessage
+
+	// userContext and timer are set only when the expiration is notified.
+	// userContext is the given context as is, so the notified one still has the fields that are not persisted.
+	userContext *sarah.UserContext
+	timer       *time.Timer
+}
+
+func (e *fileEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func (e *fileEntry) stopTimer() {
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+}
+
+// NewFileUserContextStorage creates and returns new sarah.UserContextStorage implementation that persists contexts to a local data directory.
+// Each operation is appended to a data file, and the file is replayed on construction so stored conversations survive process restarts.
+// The data file is compacted at the interval of FileConfig.CompactionInterval until the given ctx is canceled.
+//
+// The returned storage satisfies sarah.ExpirationNotifier.
+// Only the contexts stored by the running process are notified on expiration,
+// since sarah.UserContext.ExpirationMessage, sarah.UserContext.OnExpire and the destination to send the message are not persisted.
+// The contexts restored from the data file silently expire.
+//
+//  config := storages.NewFileConfig()
+//  config.DataDir = "/var/lib/mybot"
+//  storage, err := storages.NewFileUserContextStorage(ctx, config)
+//  bot, err := sarah.NewBot(myAdapter, sarah.BotWithStorage(storage))
+func NewFileUserContextStorage(ctx context.Context, config *FileConfig) (sarah.UserContextStorage, error) {
+	if config.DataDir == "" {
+		return nil, errors.New("FileConfig.DataDir must be set")
+	}
+
+	err := os.MkdirAll(config.DataDir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", config.DataDir, err)
+	}
+
+	storage := &fileUserContextStorage{
+		path:      filepath.Join(config.DataDir, fileStorageName),
+		expiresIn: config.ExpiresIn,
+		entries:   map[string]*fileEntry{},
+		now:       time.Now,
+	}
+
+	err = storage.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove expired or overwritten records left by the previous process.
+	err = storage.compact()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.CompactionInterval > 0 {
+		go storage.runCompaction(ctx, config.CompactionInterval)
+	}
+
+	return storage, nil
+}
+
+type fileUserContextStorage struct {
+	path           string
+	expiresIn      time.Duration
+	entries        map[string]*fileEntry
+	file           *os.File
+	now            func() time.Time
+	expirationFunc func(string, *sarah.UserContext)
+	mutex          sync.Mutex
+}
+
+var _ sarah.UserContextStorage = (*fileUserContextStorage)(nil)
+var _ sarah.ExpirationNotifier = (*fileUserContextStorage)(nil)
+
+// NotifyExpiration registers a function that is called when a stored UserContext expires.
+// Only the contexts stored after this call are notified.
+func (storage *fileUserContextStorage) NotifyExpiration(fnc func(key string, userContext *sarah.UserContext)) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.expirationFunc = fnc
+}
+
+// Get searches for user's stored state with given user key, and return it if any found.
+func (storage *fileUserContextStorage) Get(key string) (*sarah.UserContext, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	entry, ok := storage.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if entry.expired(storage.now()) {
+		storage.expire(key, entry)
+		return nil, nil
+	}
+
+	userContext, err := sarah.DecodeSerializableUserContext(entry.argument)
+	if err != nil {
+		return nil, err
+	}
+
+	return userContext, nil
+}
+
+// Set stores given UserContext with the expiration of sarah.UserContext.ExpiresIn if given, or FileConfig.ExpiresIn otherwise.
+// Only sarah.UserContext.Serializable is stored along with stacked parents, so ErrNonSerializableContext is returned when any of them lacks the field.
+func (storage *fileUserContextStorage) Set(key string, userContext *sarah.UserContext) error {
+	encoded, err := sarah.EncodeSerializableUserContext(userContext)
+	if err != nil {
+		return err
+	}
+
+	expiresIn := storage.expiresIn
+	if userContext.ExpiresIn > 0 {
+		expiresIn = userContext.ExpiresIn
+	}
+
+	var expiresAt time.Time
+	if expiresIn > 0 {
+		expiresAt = storage.now().Add(expiresIn)
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	err = storage.append(&fileRecord{
+		Op:        opSet,
+		Key:       key,
+		ExpiresAt: expiresAt,
+		Argument:  encoded,
+	})
+	if err != nil {
+		return err
+	}
+
+	if old, ok := storage.entries[key]; ok {
+		old.stopTimer()
+	}
+
+	entry := &fileEntry{
+		expiresAt: expiresAt,
+		argument:  encoded,
+	}
+	storage.entries[key] = entry
+
+	if storage.expirationFunc == nil || expiresIn <= 0 {
+		return nil
+	}
+
+	// Expired entries are only removed on Get or compaction, so a timer is set to notify the expiration on time.
+	entry.userContext = userContext
+	entry.timer = time.AfterFunc(expiresIn, func() {
+		storage.mutex.Lock()
+		defer storage.mutex.Unlock()
+
+		if storage.entries[key] != entry {
+			// Already deleted, overwritten, or expired.
+			return
+		}
+		storage.expire(key, entry)
+	})
+	return nil
+}
+
+// Delete removes currently stored user's conversational context.
+// This does nothing if corresponding stored context is not found.
+func (storage *fileUserContextStorage) Delete(key string) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	entry, ok := storage.entries[key]
+	if !ok {
+		return nil
+	}
+
+	err := storage.append(&fileRecord{
+		Op:  opDelete,
+		Key: key,
+	})
+	if err != nil {
+		return err
+	}
+
+	entry.stopTimer()
+	delete(storage.entries, key)
+	return nil
+}
+
+// Flush removes all stored UserContext from its storage.
+func (storage *fileUserContextStorage) Flush() error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	for _, entry := range storage.entries {
+		entry.stopTimer()
+	}
+	storage.entries = map[string]*fileEntry{}
+	return storage.rewrite()
+}
+
+// expire removes the expired entry and notifies the expiration if the entry is set to be notified.
+// The caller must hold the lock.
+func (storage *fileUserContextStorage) expire(key string, entry *fileEntry) {
+	delete(storage.entries, key)
+	if entry.timer == nil {
+		return
+	}
+
+	entry.timer.Stop()
+	fnc := storage.expirationFunc
+	if fnc != nil {
+		go fnc(key, entry.userContext)
+	}
+}
+
+func (storage *fileUserContextStorage) load() error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	f, err := os.Open(storage.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open data file %s: %w", storage.path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		record := &fileRecord{}
+		err := json.Unmarshal(scanner.Bytes(), record)
+		if err != nil {
+			// The last line may be partially written when the previous process crashed.
+			log.Warnf("Skipping malformed record in %s: %+v", storage.path, err)
+			continue
+		}
+
+		switch record.Op {
+		case opSet:
+			storage.entries[record.Key] = &fileEntry{
+				expiresAt: record.ExpiresAt,
+				argument:  record.Argument,
+			}
+
+		case opDelete:
+			delete(storage.entries, record.Key)
+
+		default:
+			log.Warnf("Skipping unknown operation in %s: %s", storage.path, record.Op)
+
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read data file %s: %w", storage.path, err)
+	}
+	return nil
+}
+
+func (storage *fileUserContextStorage) append(record *fileRecord) error {
+	if storage.file == nil {
+		f, err := os.OpenFile(storage.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open data file %s: %w", storage.path, err)
+		}
+		storage.file = f
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	_, err = storage.file.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write to data file %s: %w", storage.path, err)
+	}
+
+	return storage.file.Sync()
+}
+
+// rewrite writes all valid entries to a temporary file and atomically replaces the data file.
+// The caller must hold the lock.
+func (storage *fileUserContextStorage) rewrite() error {
+	tmpPath := storage.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file %s: %w", tmpPath, err)
+	}
+
+	now := storage.now()
+	w := bufio.NewWriter(tmp)
+	for key, entry := range storage.entries {
+		if entry.expired(now) {
+			storage.expire(key, entry)
+			continue
+		}
+
+		b, err := json.Marshal(&fileRecord{
+			Op:        opSet,
+			Key:       key,
+			ExpiresAt: entry.expiresAt,
+			Argument:  entry.argument,
+		})
+		if err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+
+		_, _ = w.Write(append(b, '\n'))
+	}
+
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temporary file %s: %w", tmpPath, err)
+	}
+
+	if storage.file != nil {
+		_ = storage.file.Close()
+		storage.file = nil
+	}
+
+	err = os.Rename(tmpPath, storage.path)
+	if err != nil {
+		return fmt.Errorf("failed to replace data file %s: %w", storage.path, err)
+	}
+	return nil
+}
+
+func (storage *fileUserContextStorage) compact() error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	return storage.rewrite()
+}
+
+func (storage *fileUserContextStorage) runCompaction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			storage.mutex.Lock()
+			if storage.file != nil {
+				_ = storage.file.Close()
+				storage.file = nil
+			}
+			storage.mutex.Unlock()
+			return
+
+		case <-ticker.C:
+			err := storage.compact()
+			if err != nil {
+				log.Errorf("Failed to compact user context storage: %+v", err)
+			}
+
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v3"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"io/ioutil"
+	stdLogger "log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	oldLogger := log.GetLogger()
+	defer log.SetLogger(oldLogger)
+
+	l := stdLogger.New(ioutil.Discard, "dummyLog", 0)
+	logger := log.NewWithStandardLogger(l)
+	log.SetLogger(logger)
+
+	code := m.Run()
+
+	os.Exit(code)
+}
+
+type testArg struct {
+	Answer int `json:"answer"`
+}
+
+func newTestFileStorage(t *testing.T, dir string) *fileUserContextStorage {
+	config := NewFileConfig()
+	config.DataDir = dir
+	config.CompactionInterval = 0
+
+	storage, err := NewFileUserContextStorage(context.TODO(), config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	return storage.(*fileUserContextStorage)
+}
+
+func TestNewFileConfig(t *testing.T) {
+	config := NewFileConfig()
+	if config.ExpiresIn <= 0 {
+		t.Errorf("Default expiration is not set: %d.", config.ExpiresIn)
+	}
+}
+
+func TestNewFileUserContextStorage(t *testing.T) {
+	_, err := NewFileUserContextStorage(context.TODO(), NewFileConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty DataDir.")
+	}
+
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, filepath.Join(dir, "nested"))
+	if storage == nil {
+		t.Fatal("Storage is not initialized.")
+	}
+}
+
+func TestFileUserContextStorage_CRUD(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, dir)
+
+	key := "myKey"
+	if empty, _ := storage.Get(key); empty != nil {
+		t.Fatalf("Nil should return on empty storage: %#v.", empty)
+	}
+
+	err := storage.Set(key, sarah.NewUserContext(func(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+		return nil, nil
+	}))
+	if err != ErrNonSerializableContext {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	err = storage.Set(key, sarah.NewSerializableUserContext("guess", &testArg{Answer: 3}))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	stored, err := storage.Get(key)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if stored == nil || stored.Serializable == nil {
+		t.Fatalf("Expected value is not stored: %#v.", stored)
+	}
+	if stored.Serializable.FuncIdentifier != "guess" {
+		t.Errorf("Unexpected identifier is stored: %s.", stored.Serializable.FuncIdentifier)
+	}
+	arg := &testArg{}
+	_ = json.Unmarshal(stored.Serializable.Argument.(json.RawMessage), arg)
+	if arg.Answer != 3 {
+		t.Errorf("Unexpected argument is stored: %#v.", arg)
+	}
+
+	_ = storage.Delete(key)
+	if empty, _ := storage.Get(key); empty != nil {
+		t.Fatalf("Nil should return after deletion: %#v.", empty)
+	}
+
+	_ = storage.Set(key, sarah.NewSerializableUserContext("guess", &testArg{}))
+	_ = storage.Flush()
+	if empty, _ := storage.Get(key); empty != nil {
+		t.Fatalf("Nil should return after flush: %#v.", empty)
+	}
+}
+
+func TestFileUserContextStorage_Restart(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, dir)
+	_ = storage.Set("alive", sarah.NewSerializableUserContext("guess", &testArg{Answer: 1}))
+	_ = storage.Set("deleted", sarah.NewSerializableUserContext("guess", &testArg{Answer: 2}))
+	_ = storage.Delete("deleted")
+	_ = storage.Set("overwritten", sarah.NewSerializableUserContext("guess", &testArg{Answer: 3}))
+	_ = storage.Set("overwritten", sarah.NewSerializableUserContext("todo", &testArg{Answer: 4}))
+
+	// Simulate a partially written line left by a crash.
+	f, _ := os.OpenFile(filepath.Join(dir, fileStorageName), os.O_WRONLY|os.O_APPEND, 0600)
+	_, _ = f.WriteString(`{"op":"set","key":"broken`)
+	_ = f.Close()
+
+	restarted := newTestFileStorage(t, dir)
+
+	if stored, _ := restarted.Get("alive"); stored == nil {
+		t.Error("Stored context is not restored.")
+	}
+
+	if stored, _ := restarted.Get("deleted"); stored != nil {
+		t.Errorf("Deleted context is restored: %#v.", stored)
+	}
+
+	stored, _ := restarted.Get("overwritten")
+	if stored == nil || stored.Serializable.FuncIdentifier != "todo" {
+		t.Errorf("The latest context is not restored: %#v.", stored)
+	}
+
+	// Data file must be compacted on restart.
+	b, _ := ioutil.ReadFile(filepath.Join(dir, fileStorageName))
+	if lines := strings.Count(string(b), "\n"); lines != 2 {
+		t.Errorf("Unexpected number of records is left after compaction: %d.", lines)
+	}
+}
+
+func TestFileUserContextStorage_Expiration(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, dir)
+	now := time.Now()
+	storage.now = func() time.Time {
+		return now
+	}
+	_ = storage.Set("key", sarah.NewSerializableUserContext("guess", &testArg{}))
+
+	now = now.Add(storage.expiresIn)
+	if stored, _ := storage.Get("key"); stored != nil {
+		t.Errorf("Expired context is returned: %#v.", stored)
+	}
+
+	_ = storage.Set("expiring", sarah.NewSerializableUserContext("guess", &testArg{}))
+	now = now.Add(storage.expiresIn)
+	_ = storage.compact()
+	if _, ok := storage.entries["expiring"]; ok {
+		t.Error("Expired context is not removed on compaction.")
+	}
+}
+
+func TestFileUserContextStorage_NotifyExpiration(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, dir)
+	notified := make(chan *sarah.UserContext, 3)
+	storage.NotifyExpiration(func(key string, userContext *sarah.UserContext) {
+		if key != "expiring" {
+			t.Errorf("Unexpected key is notified: %s.", key)
+		}
+		notified <- userContext
+	})
+
+	expiring := sarah.NewSerializableUserContext("guess", &testArg{})
+	expiring.ExpiresIn = 10 * time.Millisecond
+	expiring.ExpirationMessage = "timed out"
+	_ = storage.Set("expiring", expiring)
+
+	// Neither deleted nor overwritten context is notified.
+	deleted := sarah.NewSerializableUserContext("guess", &testArg{})
+	deleted.ExpiresIn = 10 * time.Millisecond
+	_ = storage.Set("deleted", deleted)
+	_ = storage.Delete("deleted")
+	_ = storage.Set("overwritten", deleted)
+	_ = storage.Set("overwritten", sarah.NewSerializableUserContext("guess", &testArg{}))
+
+	select {
+	case userContext := <-notified:
+		if userContext != expiring {
+			t.Errorf("Stored context is not notified as is: %#v.", userContext)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Expiration is not notified.")
+
+	}
+
+	if stored, _ := storage.Get("expiring"); stored != nil {
+		t.Errorf("Expired context is returned: %#v.", stored)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if len(notified) != 0 {
+		t.Errorf("Unexpected expiration is notified: %d.", len(notified))
+	}
+}
+
+func TestFileUserContextStorage_Compaction(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	config := NewFileConfig()
+	config.DataDir = dir
+	config.CompactionInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage, err := NewFileUserContextStorage(ctx, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		_ = storage.Set("key", sarah.NewSerializableUserContext("guess", &testArg{Answer: i}))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	typed := storage.(*fileUserContextStorage)
+	typed.mutex.Lock()
+	b, _ := ioutil.ReadFile(typed.path)
+	typed.mutex.Unlock()
+	if lines := strings.Count(string(b), "\n"); lines != 1 {
+		t.Errorf("Data file is not compacted: %d lines.", lines)
+	}
+}
+
+func TestFileUserContextStorage_Concurrency(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, dir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := string(rune('a' + i))
+			_ = storage.Set(key, sarah.NewSerializableUserContext("guess", &testArg{Answer: i}))
+			_, _ = storage.Get(key)
+			if i%2 == 0 {
+				_ = storage.Delete(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	restarted := newTestFileStorage(t, dir)
+	if len(restarted.entries) != 10 {
+		t.Errorf("Unexpected number of contexts is restored: %d.", len(restarted.entries))
+	}
+}
+
+func TestFileUserContextStorage_Set_WithExpiresIn(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, dir)
+	now := time.Now()
+	storage.now = func() time.Time {
+		return now
+	}
+
+	userContext := sarah.NewSerializableUserContext("guess", &testArg{})
+	userContext.ExpiresIn = storage.expiresIn * 2
+	_ = storage.Set("key", userContext)
+
+	now = now.Add(storage.expiresIn)
+	if stored, _ := storage.Get("key"); stored == nil {
+		t.Error("UserContext.ExpiresIn is not honored.")
+	}
+}
+
+func TestFileUserContextStorage_Set_WithParent(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	storage := newTestFileStorage(t, dir)
+	parent := sarah.NewSerializableUserContext("todo", &testArg{Answer: 1})
+	child := sarah.PushUserContext(sarah.NewSerializableUserContext("pickDate", &testArg{Answer: 2}), parent)
+	_ = storage.Set("key", child)
+
+	restarted := newTestFileStorage(t, dir)
+	stored, _ := restarted.Get("key")
+	if stored == nil || stored.Parent == nil || stored.Parent.Serializable.FuncIdentifier != "todo" {
+		t.Errorf("Stacked contexts are not restored: %#v.", stored)
+	}
+
+	nonSerializable := sarah.PushUserContext(sarah.NewSerializableUserContext("pickDate", nil), sarah.NewUserContext(nil))
+	err := storage.Set("key", nonSerializable)
+	if err != ErrNonSerializableContext {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
@@ -0,0 +1,131 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const fileTaskPauseStoreName = "paused_tasks.json"
+
+// FileTaskPauseStoreConfig contains some configuration variables for file-based sarah.TaskPauseStore.
+type FileTaskPauseStoreConfig struct {
+	// DataDir is the directory to store the data file.
+	DataDir string `json:"data_dir" yaml:"data_dir"`
+}
+
+// NewFileTaskPauseStoreConfig creates and returns new FileTaskPauseStoreConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewFileTaskPauseStoreConfig() *FileTaskPauseStoreConfig {
+	return &FileTaskPauseStoreConfig{
+		DataDir: "",
+	}
+}
+
+// NewFileTaskPauseStore creates and returns new sarah.TaskPauseStore implementation that persists the paused tasks to a local data directory.
+// The whole data file is rewritten on every update, and is read on construction so the paused state survives process restarts.
+//
+//  config := storages.NewFileTaskPauseStoreConfig()
+//  config.DataDir = "/var/lib/mybot"
+//  store, err := storages.NewFileTaskPauseStore(config)
+//  sarah.RegisterTaskPauseStore(store)
+func NewFileTaskPauseStore(config *FileTaskPauseStoreConfig) (sarah.TaskPauseStore, error) {
+	if config.DataDir == "" {
+		return nil, errors.New("FileTaskPauseStoreConfig.DataDir must be set")
+	}
+
+	err := os.MkdirAll(config.DataDir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", config.DataDir, err)
+	}
+
+	store := &fileTaskPauseStore{
+		path:   filepath.Join(config.DataDir, fileTaskPauseStoreName),
+		paused: map[sarah.BotType][]string{},
+	}
+
+	err = store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+type fileTaskPauseStore struct {
+	path   string
+	paused map[sarah.BotType][]string
+	mutex  sync.Mutex
+}
+
+var _ sarah.TaskPauseStore = (*fileTaskPauseStore)(nil)
+
+func (store *fileTaskPauseStore) PausedTasks(_ context.Context, botType sarah.BotType) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	ids := make([]string, len(store.paused[botType]))
+	copy(ids, store.paused[botType])
+	return ids, nil
+}
+
+func (store *fileTaskPauseStore) SetPaused(_ context.Context, botType sarah.BotType, taskID string, paused bool) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	prev := store.paused[botType]
+	var ids []string
+	for _, id := range prev {
+		if id != taskID {
+			ids = append(ids, id)
+		}
+	}
+	if paused {
+		ids = append(ids, taskID)
+		sort.Strings(ids)
+	}
+	store.paused[botType] = ids
+
+	err := store.save()
+	if err != nil {
+		// Keep the in-memory state consistent with the data file.
+		store.paused[botType] = prev
+		return err
+	}
+	return nil
+}
+
+// load reads the data file if any.
+func (store *fileTaskPauseStore) load() error {
+	b, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.path, err)
+	}
+
+	err = json.Unmarshal(b, &store.paused)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", store.path, err)
+	}
+	return nil
+}
+
+// save writes the whole data so the data file is never left half-written.
+// The caller must hold the lock.
+func (store *fileTaskPauseStore) save() error {
+	b, err := json.Marshal(store.paused)
+	if err != nil {
+		return fmt.Errorf("failed to serialize paused tasks: %w", err)
+	}
+
+	return replaceFile(store.path, b)
+}
@@ -0,0 +1,59 @@
+package storages
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestNewFileTaskPauseStore(t *testing.T) {
+	_, err := NewFileTaskPauseStore(NewFileTaskPauseStoreConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty DataDir.")
+	}
+}
+
+func TestFileTaskPauseStore_SetPaused(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	config := NewFileTaskPauseStoreConfig()
+	config.DataDir = dir
+	store, err := NewFileTaskPauseStore(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	ctx := context.TODO()
+	for _, id := range []string{"b", "a", "c"} {
+		err = store.SetPaused(ctx, "dummy", id, true)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+	}
+	err = store.SetPaused(ctx, "dummy", "c", false)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	// The stored value must survive the process restart.
+	restored, err := NewFileTaskPauseStore(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	ids, err := restored.PausedTasks(ctx, "dummy")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("Unexpected tasks are returned: %#v.", ids)
+	}
+
+	ids, _ = restored.PausedTasks(ctx, "other")
+	if len(ids) != 0 {
+		t.Errorf("No task should be returned: %#v.", ids)
+	}
+}
@@ -0,0 +1,144 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const fileTaskRunStoreName = "task_runs.json"
+
+// FileTaskRunStoreConfig contains some configuration variables for file-based sarah.TaskRunStore.
+type FileTaskRunStoreConfig struct {
+	// DataDir is the directory to store the data file.
+	DataDir string `json:"data_dir" yaml:"data_dir"`
+}
+
+// NewFileTaskRunStoreConfig creates and returns new FileTaskRunStoreConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewFileTaskRunStoreConfig() *FileTaskRunStoreConfig {
+	return &FileTaskRunStoreConfig{
+		DataDir: "",
+	}
+}
+
+// NewFileTaskRunStore creates and returns new sarah.TaskRunStore implementation that persists the last execution times to a local data directory.
+// The whole data file is rewritten on every update, and is read on construction so the stored times survive process restarts.
+//
+//  config := storages.NewFileTaskRunStoreConfig()
+//  config.DataDir = "/var/lib/mybot"
+//  store, err := storages.NewFileTaskRunStore(config)
+//  sarah.RegisterTaskRunStore(store)
+func NewFileTaskRunStore(config *FileTaskRunStoreConfig) (sarah.TaskRunStore, error) {
+	if config.DataDir == "" {
+		return nil, errors.New("FileTaskRunStoreConfig.DataDir must be set")
+	}
+
+	err := os.MkdirAll(config.DataDir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", config.DataDir, err)
+	}
+
+	store := &fileTaskRunStore{
+		path: filepath.Join(config.DataDir, fileTaskRunStoreName),
+		runs: map[sarah.BotType]map[string]time.Time{},
+	}
+
+	err = store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+type fileTaskRunStore struct {
+	path  string
+	runs  map[sarah.BotType]map[string]time.Time
+	mutex sync.Mutex
+}
+
+var _ sarah.TaskRunStore = (*fileTaskRunStore)(nil)
+
+func (store *fileTaskRunStore) LastRun(_ context.Context, botType sarah.BotType, taskID string) (time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.runs[botType][taskID], nil
+}
+
+func (store *fileTaskRunStore) SetLastRun(_ context.Context, botType sarah.BotType, taskID string, ranAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	runs, ok := store.runs[botType]
+	if !ok {
+		runs = map[string]time.Time{}
+		store.runs[botType] = runs
+	}
+
+	prev, existed := runs[taskID]
+	runs[taskID] = ranAt
+
+	err := store.save()
+	if err != nil {
+		// Keep the in-memory state consistent with the data file.
+		if existed {
+			runs[taskID] = prev
+		} else {
+			delete(runs, taskID)
+		}
+		return err
+	}
+	return nil
+}
+
+// load reads the data file if any.
+func (store *fileTaskRunStore) load() error {
+	b, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.path, err)
+	}
+
+	err = json.Unmarshal(b, &store.runs)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", store.path, err)
+	}
+	return nil
+}
+
+// save writes the whole data so the data file is never left half-written.
+// The caller must hold the lock.
+func (store *fileTaskRunStore) save() error {
+	b, err := json.Marshal(store.runs)
+	if err != nil {
+		return fmt.Errorf("failed to serialize task runs: %w", err)
+	}
+
+	return replaceFile(store.path, b)
+}
+
+// replaceFile writes the given data to a temporary file and renames it to the given path.
+func replaceFile(path string, b []byte) error {
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package storages
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewFileTaskRunStore(t *testing.T) {
+	_, err := NewFileTaskRunStore(NewFileTaskRunStoreConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty DataDir.")
+	}
+}
+
+func TestFileTaskRunStore_SetLastRun(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "storages")
+	defer os.RemoveAll(dir)
+
+	config := NewFileTaskRunStoreConfig()
+	config.DataDir = dir
+	store, err := NewFileTaskRunStore(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	ctx := context.TODO()
+	lastRun, err := store.LastRun(ctx, "dummy", "task")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if !lastRun.IsZero() {
+		t.Errorf("Zero time should be returned for unknown task: %s.", lastRun)
+	}
+
+	ranAt := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+	err = store.SetLastRun(ctx, "dummy", "task", ranAt)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	// The stored value must survive the process restart.
+	restored, err := NewFileTaskRunStore(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	lastRun, err = restored.LastRun(ctx, "dummy", "task")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if !lastRun.Equal(ranAt) {
+		t.Errorf("Unexpected time is returned: %s.", lastRun)
+	}
+}
@@ -0,0 +1,282 @@
+package storages
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RESPError represents an error reply sent by a RESP-compatible server.
+type RESPError struct {
+	Message string
+}
+
+// Error returns the error message sent by the server.
+func (e *RESPError) Error() string {
+	return e.Message
+}
+
+var _ error = (*RESPError)(nil)
+
+// errPoolClosed is returned when a connection is requested after the pool is closed.
+var errPoolClosed = errors.New("connection pool is already closed")
+
+// respConn is a connection to a RESP-compatible server such as Redis.
+// This is not safe for concurrent use; respPool hands one connection to one caller at a time.
+type respConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	writer    *bufio.Writer
+	ioTimeout time.Duration
+}
+
+func (c *respConn) do(args ...string) (interface{}, error) {
+	if c.ioTimeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.ioTimeout))
+	}
+
+	err := writeCommand(c.writer, args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.writer.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return readReply(c.reader)
+}
+
+func (c *respConn) close() error {
+	return c.conn.Close()
+}
+
+func writeCommand(w *bufio.Writer, args []string) error {
+	_, err := fmt.Fprintf(w, "*%d\r\n", len(args))
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		_, err = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed RESP line: %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// readReply reads one RESP reply.
+// Simple strings are returned as string, integers as int64, bulk strings as []byte, arrays as []interface{},
+// null bulk strings and null arrays as nil, and error replies as *RESPError.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if line == "" {
+		return nil, errors.New("empty RESP line")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+
+	case '-':
+		return nil, &RESPError{Message: line[1:]}
+
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk string length: %w", err)
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size+2)
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed array length: %w", err)
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		array := make([]interface{}, size)
+		for i := range array {
+			elem, err := readReply(r)
+			var respErr *RESPError
+			if err != nil && !errors.As(err, &respErr) {
+				return nil, err
+			}
+			if respErr != nil {
+				array[i] = respErr
+				continue
+			}
+			array[i] = elem
+		}
+		return array, nil
+
+	default:
+		return nil, fmt.Errorf("unknown RESP type: %q", line[0])
+
+	}
+}
+
+// respPool keeps idle connections to be reused.
+type respPool struct {
+	config *RESPConfig
+	idle   chan *respConn
+	closed bool
+	mutex  sync.Mutex
+}
+
+func newRESPPool(config *RESPConfig) *respPool {
+	return &respPool{
+		config: config,
+		idle:   make(chan *respConn, config.MaxIdle),
+	}
+}
+
+func (p *respPool) dial() (*respConn, error) {
+	conn, err := net.DialTimeout("tcp", p.config.Address, p.config.DialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", p.config.Address, err)
+	}
+
+	c := &respConn{
+		conn:      conn,
+		reader:    bufio.NewReader(conn),
+		writer:    bufio.NewWriter(conn),
+		ioTimeout: p.config.IOTimeout,
+	}
+
+	if p.config.Password != "" {
+		_, err := c.do("AUTH", p.config.Password)
+		if err != nil {
+			_ = c.close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if p.config.DB != 0 {
+		_, err := c.do("SELECT", strconv.Itoa(p.config.DB))
+		if err != nil {
+			_ = c.close()
+			return nil, fmt.Errorf("failed to select database %d: %w", p.config.DB, err)
+		}
+	}
+
+	return c, nil
+}
+
+func (p *respPool) get() (*respConn, error) {
+	p.mutex.Lock()
+	closed := p.closed
+	p.mutex.Unlock()
+	if closed {
+		return nil, errPoolClosed
+	}
+
+	select {
+	case c := <-p.idle:
+		return c, nil
+
+	default:
+		return p.dial()
+
+	}
+}
+
+func (p *respPool) put(c *respConn, err error) {
+	// A connection that faced non-RESP error such as network error may be in an unknown state.
+	var respErr *RESPError
+	if err != nil && !errors.As(err, &respErr) {
+		_ = c.close()
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		_ = c.close()
+		return
+	}
+
+	select {
+	case p.idle <- c:
+		// O.K.
+
+	default:
+		// Too many idle connections.
+		_ = c.close()
+
+	}
+}
+
+// do borrows a connection from the pool, sends the given command, and returns the connection to the pool.
+func (p *respPool) do(args ...string) (interface{}, error) {
+	c, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.do(args...)
+	p.put(c, err)
+	return reply, err
+}
+
+func (p *respPool) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	for {
+		select {
+		case c := <-p.idle:
+			_ = c.close()
+
+		default:
+			return
+
+		}
+	}
+}
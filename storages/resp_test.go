@@ -0,0 +1,395 @@
+package storages
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// respServer is an in-process stand-in for a RESP-compatible server.
+// This only supports the commands that the storages package sends.
+type respServer struct {
+	listener net.Listener
+	password string
+	values   map[string]string
+	expires  map[string]time.Time
+	conns    int
+	mutex    sync.Mutex
+}
+
+func runRESPServer(t *testing.T, password string) *respServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %+v.", err)
+	}
+
+	server := &respServer{
+		listener: listener,
+		password: password,
+		values:   map[string]string{},
+		expires:  map[string]time.Time{},
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mutex.Lock()
+			server.conns++
+			server.mutex.Unlock()
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *respServer) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *respServer) close() {
+	_ = s.listener.Close()
+}
+
+func (s *respServer) connCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.conns
+}
+
+func (s *respServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	authenticated := s.password == ""
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+
+		array, ok := reply.([]interface{})
+		if !ok || len(array) == 0 {
+			_, _ = conn.Write([]byte("-ERR protocol error\r\n"))
+			continue
+		}
+
+		args := make([]string, len(array))
+		for i, elem := range array {
+			args[i] = string(elem.([]byte))
+		}
+
+		command := strings.ToUpper(args[0])
+		if command == "AUTH" {
+			if len(args) == 2 && args[1] == s.password {
+				authenticated = true
+				_, _ = conn.Write([]byte("+OK\r\n"))
+			} else {
+				_, _ = conn.Write([]byte("-ERR invalid password\r\n"))
+			}
+			continue
+		}
+
+		if !authenticated {
+			_, _ = conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+			continue
+		}
+
+		_, _ = conn.Write(s.handle(command, args[1:]))
+	}
+}
+
+func (s *respServer) handle(command string, args []string) []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expire()
+
+	switch command {
+	case "PING":
+		return []byte("+PONG\r\n")
+
+	case "SELECT":
+		return []byte("+OK\r\n")
+
+	case "SET":
+		s.values[args[0]] = args[1]
+		delete(s.expires, args[0])
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			s.expires[args[0]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return []byte("+OK\r\n")
+
+	case "GET":
+		value, ok := s.values[args[0]]
+		if !ok {
+			return []byte("$-1\r\n")
+		}
+		return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(value), value))
+
+	case "DEL":
+		cnt := 0
+		for _, key := range args {
+			if _, ok := s.values[key]; ok {
+				cnt++
+			}
+			delete(s.values, key)
+			delete(s.expires, key)
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", cnt))
+
+	case "SCAN":
+		// Return all matching keys at once.
+		prefix := strings.TrimSuffix(args[2], "*")
+		buf := &bytes.Buffer{}
+		var keys []string
+		for key := range s.values {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		buf.WriteString("*2\r\n$1\r\n0\r\n")
+		buf.WriteString(fmt.Sprintf("*%d\r\n", len(keys)))
+		for _, key := range keys {
+			buf.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(key), key))
+		}
+		return buf.Bytes()
+
+	default:
+		return []byte(fmt.Sprintf("-ERR unknown command '%s'\r\n", command))
+
+	}
+}
+
+func (s *respServer) expire() {
+	now := time.Now()
+	for key, expiresAt := range s.expires {
+		if !now.Before(expiresAt) {
+			delete(s.values, key)
+			delete(s.expires, key)
+		}
+	}
+}
+
+func (s *respServer) ttl(key string) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expiresAt, ok := s.expires[key]
+	if !ok {
+		return 0
+	}
+	return time.Until(expiresAt)
+}
+
+func (s *respServer) set(key, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values[key] = value
+}
+
+func (s *respServer) has(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.values[key]
+	return ok
+}
+
+func TestRESPError_Error(t *testing.T) {
+	err := &RESPError{Message: "ERR foo"}
+	if err.Error() != "ERR foo" {
+		t.Errorf("Unexpected error message is returned: %s.", err.Error())
+	}
+}
+
+func Test_writeCommand(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	err := writeCommand(w, []string{"SET", "key", "日本語"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	_ = w.Flush()
+
+	expected := "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$9\r\n日本語\r\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected command is written: %q.", buf.String())
+	}
+}
+
+func Test_readReply(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		hasErr   bool
+	}{
+		{
+			input:    "+OK\r\n",
+			expected: "OK",
+		},
+		{
+			input:  "-ERR foo\r\n",
+			hasErr: true,
+		},
+		{
+			input:    ":10\r\n",
+			expected: int64(10),
+		},
+		{
+			input:    "$3\r\nfoo\r\n",
+			expected: []byte("foo"),
+		},
+		{
+			input:    "$-1\r\n",
+			expected: nil,
+		},
+		{
+			input:    "*2\r\n$3\r\nfoo\r\n:1\r\n",
+			expected: []interface{}{[]byte("foo"), int64(1)},
+		},
+		{
+			input:    "*-1\r\n",
+			expected: nil,
+		},
+		{
+			input:  "?unknown\r\n",
+			hasErr: true,
+		},
+		{
+			input:  "+OK\n",
+			hasErr: true,
+		},
+		{
+			input:  "$10\r\nfoo\r\n",
+			hasErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		reply, err := readReply(bufio.NewReader(strings.NewReader(tt.input)))
+		if tt.hasErr {
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %+v.", i, err)
+			continue
+		}
+
+		if fmt.Sprintf("%#v", reply) != fmt.Sprintf("%#v", tt.expected) {
+			t.Errorf("Unexpected reply is returned on test #%d: %#v.", i, reply)
+		}
+	}
+}
+
+func TestRESPPool(t *testing.T) {
+	server := runRESPServer(t, "secret")
+	defer server.close()
+
+	config := NewRESPConfig()
+	config.Address = server.address()
+	config.Password = "secret"
+	config.DB = 1
+	config.MaxIdle = 1
+	pool := newRESPPool(config)
+
+	for i := 0; i < 3; i++ {
+		reply, err := pool.do("PING")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+		if reply != "PONG" {
+			t.Errorf("Unexpected reply is returned: %#v.", reply)
+		}
+	}
+
+	// Connection must be reused.
+	if server.connCount() != 1 {
+		t.Errorf("Unexpected number of connections are established: %d.", server.connCount())
+	}
+
+	// RESP error does not discard the connection.
+	_, err := pool.do("UNKNOWN")
+	var respErr *RESPError
+	if !errors.As(err, &respErr) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+	if len(pool.idle) != 1 {
+		t.Error("Connection should be returned to the pool.")
+	}
+
+	pool.close()
+	_, err = pool.do("PING")
+	if err != errPoolClosed {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestRESPPool_AuthFailure(t *testing.T) {
+	server := runRESPServer(t, "secret")
+	defer server.close()
+
+	config := NewRESPConfig()
+	config.Address = server.address()
+	config.Password = "wrong"
+	pool := newRESPPool(config)
+
+	_, err := pool.do("PING")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestRESPPool_Concurrency(t *testing.T) {
+	server := runRESPServer(t, "")
+	defer server.close()
+
+	config := NewRESPConfig()
+	config.Address = server.address()
+	config.MaxIdle = 2
+	pool := newRESPPool(config)
+	defer pool.close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := strconv.Itoa(i)
+			_, err := pool.do("SET", key, key)
+			if err != nil {
+				t.Errorf("Unexpected error is returned: %+v.", err)
+				return
+			}
+			reply, err := pool.do("GET", key)
+			if err != nil {
+				t.Errorf("Unexpected error is returned: %+v.", err)
+				return
+			}
+			if string(reply.([]byte)) != key {
+				t.Errorf("Unexpected value is returned: %s.", reply)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(pool.idle) > 2 {
+		t.Errorf("Too many idle connections are kept: %d.", len(pool.idle))
+	}
+}
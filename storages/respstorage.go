@@ -0,0 +1,230 @@
+package storages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3"
+	"strconv"
+	"time"
+)
+
+// RESPConfig contains some configuration variables for RESP-protocol (Redis-compatible) storage.
+type RESPConfig struct {
+	// Address is the "host:port" of the RESP-compatible server.
+	Address string `json:"address" yaml:"address"`
+
+	// Password is sent with AUTH command when it is not empty.
+	Password string `json:"password" yaml:"password"`
+
+	// DB is selected with SELECT command when it is not zero.
+	DB int `json:"db" yaml:"db"`
+
+	// Namespace is prepended to each key so multiple bot systems can share one server.
+	Namespace string `json:"namespace" yaml:"namespace"`
+
+	// MaxIdle is the maximum number of idle connections kept in the pool.
+	MaxIdle int `json:"max_idle" yaml:"max_idle"`
+
+	// DialTimeout is the timeout for establishing a new connection.
+	DialTimeout time.Duration `json:"dial_timeout" yaml:"dial_timeout"`
+
+	// IOTimeout is the timeout for each command's round trip.
+	IOTimeout time.Duration `json:"io_timeout" yaml:"io_timeout"`
+}
+
+// NewRESPConfig creates and returns new RESPConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewRESPConfig() *RESPConfig {
+	return &RESPConfig{
+		Address:     "127.0.0.1:6379",
+		Password:    "",
+		DB:          0,
+		Namespace:   "sarah",
+		MaxIdle:     10,
+		DialTimeout: 5 * time.Second,
+		IOTimeout:   3 * time.Second,
+	}
+}
+
+// NewRESPUserContextStorage creates and returns new sarah.UserContextStorage implementation that stores contexts in a RESP-compatible server such as Redis.
+// This lets multiple bot replicas share users' conversational contexts.
+//
+// Each key is namespaced with RESPConfig.Namespace and the given BotType, and expires after sarah.CacheConfig.ExpiresIn.
+// Connections are pooled and closed when the given ctx is canceled.
+//
+// The returned storage does not satisfy sarah.ExpirationNotifier.
+// Each context expires on the server side, so sarah.UserContext.ExpirationMessage is not sent and sarah.UserContext.OnExpire is not called.
+//
+//  storage, err := storages.NewRESPUserContextStorage(ctx, slack.SLACK, storages.NewRESPConfig(), sarah.NewCacheConfig())
+//  bot, err := sarah.NewBot(myAdapter, sarah.BotWithStorage(storage))
+func NewRESPUserContextStorage(ctx context.Context, botType sarah.BotType, config *RESPConfig, cacheConfig *sarah.CacheConfig) (sarah.UserContextStorage, error) {
+	if config.Address == "" {
+		return nil, errors.New("RESPConfig.Address must be set")
+	}
+
+	if botType == "" {
+		return nil, errors.New("BotType must be given")
+	}
+
+	if config.MaxIdle < 0 {
+		return nil, errors.New("RESPConfig.MaxIdle must not be negative")
+	}
+
+	pool := newRESPPool(config)
+
+	// Make sure the server is reachable.
+	_, err := pool.do("PING")
+	if err != nil {
+		pool.close()
+		return nil, fmt.Errorf("failed to ping %s: %w", config.Address, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		pool.close()
+	}()
+
+	prefix := botType.String() + ":context:"
+	if config.Namespace != "" {
+		prefix = config.Namespace + ":" + prefix
+	}
+
+	return &respUserContextStorage{
+		pool:      pool,
+		prefix:    prefix,
+		expiresIn: cacheConfig.ExpiresIn,
+	}, nil
+}
+
+type respUserContextStorage struct {
+	pool      *respPool
+	prefix    string
+	expiresIn time.Duration
+}
+
+var _ sarah.UserContextStorage = (*respUserContextStorage)(nil)
+
+func (storage *respUserContextStorage) key(senderKey string) string {
+	return storage.prefix + senderKey
+}
+
+// Get searches for user's stored state with given user key, and return it if any found.
+func (storage *respUserContextStorage) Get(key string) (*sarah.UserContext, error) {
+	reply, err := storage.pool.do("GET", storage.key(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user context: %w", err)
+	}
+
+	if reply == nil {
+		return nil, nil
+	}
+
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply type of %T", reply)
+	}
+
+	userContext, err := sarah.DecodeSerializableUserContext(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return userContext, nil
+}
+
+// Set stores given UserContext with the expiration of sarah.UserContext.ExpiresIn if given, or sarah.CacheConfig.ExpiresIn otherwise.
+// Only sarah.UserContext.Serializable is stored along with stacked parents, so ErrNonSerializableContext is returned when any of them lacks the field.
+func (storage *respUserContextStorage) Set(key string, userContext *sarah.UserContext) error {
+	encoded, err := sarah.EncodeSerializableUserContext(userContext)
+	if err != nil {
+		return err
+	}
+
+	expiresIn := storage.expiresIn
+	if userContext.ExpiresIn > 0 {
+		expiresIn = userContext.ExpiresIn
+	}
+
+	args := []string{"SET", storage.key(key), string(encoded)}
+	if expiresIn > 0 {
+		ms := expiresIn.Nanoseconds() / int64(time.Millisecond)
+		if ms < 1 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+
+	_, err = storage.pool.do(args...)
+	if err != nil {
+		return fmt.Errorf("failed to set user context: %w", err)
+	}
+	return nil
+}
+
+// Delete removes currently stored user's conversational context.
+// This does nothing if corresponding stored context is not found.
+func (storage *respUserContextStorage) Delete(key string) error {
+	_, err := storage.pool.do("DEL", storage.key(key))
+	if err != nil {
+		return fmt.Errorf("failed to delete user context: %w", err)
+	}
+	return nil
+}
+
+// Flush removes all stored UserContext under this storage's namespace.
+// Other keys on the same server are left untouched.
+func (storage *respUserContextStorage) Flush() error {
+	cursor := "0"
+	for {
+		reply, err := storage.pool.do("SCAN", cursor, "MATCH", storage.prefix+"*", "COUNT", "100")
+		if err != nil {
+			return fmt.Errorf("failed to scan user contexts: %w", err)
+		}
+
+		next, keys, err := parseScanReply(reply)
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			_, err := storage.pool.do(append([]string{"DEL"}, keys...)...)
+			if err != nil {
+				return fmt.Errorf("failed to delete user contexts: %w", err)
+			}
+		}
+
+		if next == "0" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func parseScanReply(reply interface{}) (string, []string, error) {
+	array, ok := reply.([]interface{})
+	if !ok || len(array) != 2 {
+		return "", nil, fmt.Errorf("unexpected SCAN reply: %#v", reply)
+	}
+
+	cursor, ok := array[0].([]byte)
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected SCAN cursor: %#v", array[0])
+	}
+
+	elems, ok := array[1].([]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected SCAN keys: %#v", array[1])
+	}
+
+	keys := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		b, ok := elem.([]byte)
+		if !ok {
+			return "", nil, fmt.Errorf("unexpected SCAN key: %#v", elem)
+		}
+		keys = append(keys, string(b))
+	}
+
+	return string(cursor), keys, nil
+}
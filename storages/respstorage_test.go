@@ -0,0 +1,232 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v3"
+	"testing"
+	"time"
+)
+
+func newTestRESPStorage(t *testing.T, ctx context.Context, server *respServer) *respUserContextStorage {
+	config := NewRESPConfig()
+	config.Address = server.address()
+
+	storage, err := NewRESPUserContextStorage(ctx, "slack", config, sarah.NewCacheConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	return storage.(*respUserContextStorage)
+}
+
+func TestNewRESPConfig(t *testing.T) {
+	config := NewRESPConfig()
+	if config.Address == "" {
+		t.Error("Default address is not set.")
+	}
+}
+
+func TestNewRESPUserContextStorage(t *testing.T) {
+	server := runRESPServer(t, "")
+	defer server.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := NewRESPConfig()
+	config.Address = ""
+	_, err := NewRESPUserContextStorage(ctx, "slack", config, sarah.NewCacheConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty address.")
+	}
+
+	config.Address = server.address()
+	_, err = NewRESPUserContextStorage(ctx, "", config, sarah.NewCacheConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty BotType.")
+	}
+
+	storage := newTestRESPStorage(t, ctx, server)
+	if storage.prefix != "sarah:slack:context:" {
+		t.Errorf("Unexpected prefix is set: %s.", storage.prefix)
+	}
+
+	server.close()
+	_, err = NewRESPUserContextStorage(ctx, "slack", config, sarah.NewCacheConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for unreachable server.")
+	}
+}
+
+func TestRESPUserContextStorage_CRUD(t *testing.T) {
+	server := runRESPServer(t, "")
+	defer server.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := newTestRESPStorage(t, ctx, server)
+
+	key := "C123|U456"
+	if empty, err := storage.Get(key); empty != nil || err != nil {
+		t.Fatalf("Nil should return on empty storage: %#v. %+v.", empty, err)
+	}
+
+	err := storage.Set(key, sarah.NewUserContext(func(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+		return nil, nil
+	}))
+	if err != ErrNonSerializableContext {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	err = storage.Set(key, sarah.NewSerializableUserContext("guess", &testArg{Answer: 3}))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	ttl := server.ttl(storage.key(key))
+	if ttl <= 0 || ttl > sarah.NewCacheConfig().ExpiresIn {
+		t.Errorf("Unexpected TTL is set: %s.", ttl)
+	}
+
+	stored, err := storage.Get(key)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+	if stored == nil || stored.Serializable.FuncIdentifier != "guess" {
+		t.Fatalf("Expected value is not stored: %#v.", stored)
+	}
+	arg := &testArg{}
+	_ = json.Unmarshal(stored.Serializable.Argument.(json.RawMessage), arg)
+	if arg.Answer != 3 {
+		t.Errorf("Unexpected argument is stored: %#v.", arg)
+	}
+
+	_ = storage.Delete(key)
+	if empty, _ := storage.Get(key); empty != nil {
+		t.Fatalf("Nil should return after deletion: %#v.", empty)
+	}
+}
+
+func TestRESPUserContextStorage_Expiration(t *testing.T) {
+	server := runRESPServer(t, "")
+	defer server.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := newTestRESPStorage(t, ctx, server)
+	storage.expiresIn = 10 * time.Millisecond
+
+	_ = storage.Set("key", sarah.NewSerializableUserContext("guess", &testArg{}))
+	time.Sleep(20 * time.Millisecond)
+
+	if stored, _ := storage.Get("key"); stored != nil {
+		t.Errorf("Expired context is returned: %#v.", stored)
+	}
+}
+
+func TestRESPUserContextStorage_Flush(t *testing.T) {
+	server := runRESPServer(t, "")
+	defer server.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := newTestRESPStorage(t, ctx, server)
+
+	_ = storage.Set("a", sarah.NewSerializableUserContext("guess", &testArg{}))
+	_ = storage.Set("b", sarah.NewSerializableUserContext("guess", &testArg{}))
+	server.set("sarah:gitter:context:c", "{}")
+
+	err := storage.Flush()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %+v.", err)
+	}
+
+	if server.has(storage.key("a")) || server.has(storage.key("b")) {
+		t.Error("Stored contexts are not flushed.")
+	}
+
+	if !server.has("sarah:gitter:context:c") {
+		t.Error("Other BotType's context must not be flushed.")
+	}
+}
+
+func TestRESPUserContextStorage_BrokenValue(t *testing.T) {
+	server := runRESPServer(t, "")
+	defer server.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := newTestRESPStorage(t, ctx, server)
+
+	server.set(storage.key("broken"), "broken")
+	_, err := storage.Get("broken")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func Test_parseScanReply(t *testing.T) {
+	tests := []struct {
+		reply  interface{}
+		cursor string
+		keys   []string
+		hasErr bool
+	}{
+		{
+			reply:  []interface{}{[]byte("10"), []interface{}{[]byte("foo"), []byte("bar")}},
+			cursor: "10",
+			keys:   []string{"foo", "bar"},
+		},
+		{
+			reply:  "OK",
+			hasErr: true,
+		},
+		{
+			reply:  []interface{}{int64(10), []interface{}{}},
+			hasErr: true,
+		},
+		{
+			reply:  []interface{}{[]byte("0"), "foo"},
+			hasErr: true,
+		},
+		{
+			reply:  []interface{}{[]byte("0"), []interface{}{int64(1)}},
+			hasErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		cursor, keys, err := parseScanReply(tt.reply)
+		if tt.hasErr {
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+			continue
+		}
+
+		if cursor != tt.cursor {
+			t.Errorf("Unexpected cursor is returned on test #%d: %s.", i, cursor)
+		}
+		if len(keys) != len(tt.keys) {
+			t.Errorf("Unexpected keys are returned on test #%d: %#v.", i, keys)
+		}
+	}
+}
+
+func TestRESPUserContextStorage_Set_WithExpiresIn(t *testing.T) {
+	server := runRESPServer(t, "")
+	defer server.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := newTestRESPStorage(t, ctx, server)
+
+	userContext := sarah.NewSerializableUserContext("guess", &testArg{})
+	userContext.ExpiresIn = 10 * time.Minute
+	_ = storage.Set("key", userContext)
+
+	ttl := server.ttl(storage.key("key"))
+	if ttl <= storage.expiresIn || ttl > userContext.ExpiresIn {
+		t.Errorf("UserContext.ExpiresIn is not honored: %s.", ttl)
+	}
+}
@@ -4,22 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/oklahomer/go-sarah/v3/retry"
 	"reflect"
 	"sync"
+	"time"
 )
 
 var (
 	// ErrTaskInsufficientArgument is returned when required parameters are not set.
 	ErrTaskInsufficientArgument = errors.New("one or more of required fields -- BotType, Identifier or Func -- are empty")
 
-	// ErrTaskScheduleNotGiven is returned when schedule is provided by neither ScheduledTaskPropsBuilder's parameter nor config.
+	// ErrTaskScheduleNotGiven is returned when schedule is provided by neither ScheduledTaskPropsBuilder's parameter nor config,
+	// and no event to trigger the task is given with ScheduledTaskPropsBuilder.OnEvent.
 	ErrTaskScheduleNotGiven = errors.New("task schedule is not set or given from config struct")
 )
 
 // ScheduledTaskResult is a struct that ScheduledTask returns on its execution.
+// When Broadcast is set, Content is sent to all of the targets via Broadcast instead of Destination.
+// Failure to send Content to some of the targets is recorded in the task's history as a failed execution, and is alerted via ScheduledTaskFailure.
 type ScheduledTaskResult struct {
 	Content     interface{}
 	Destination OutputDestination
+	Broadcast   []*BroadcastTarget
 }
 
 // taskFunc is a function type that represents scheduled task.
@@ -40,6 +46,18 @@ type DestinatedConfig interface {
 	DefaultDestination() OutputDestination
 }
 
+// ZonedConfig defines an interface that config with time zone MUST satisfy.
+// When a non-empty value is returned, this value takes priority over the one set with ScheduledTaskPropsBuilder.TimeZone.
+type ZonedConfig interface {
+	TimeZone() string
+}
+
+// RetriedConfig defines an interface that config with retry policy MUST satisfy.
+// When a non-nil policy is returned, this value takes priority over the one set with ScheduledTaskPropsBuilder.RetryPolicy.
+type RetriedConfig interface {
+	RetryPolicy() *retry.Policy
+}
+
 // ScheduledTask defines interface that all scheduled task MUST satisfy.
 // As long as a struct satisfies this interface, the struct can be registered as ScheduledTask via Runner.RegisterScheduledTask.
 type ScheduledTask interface {
@@ -60,8 +78,28 @@ type scheduledTask struct {
 	schedule           string
 	defaultDestination OutputDestination
 	configWrapper      *taskConfigWrapper
+	lockKey            string
+	lockTTL            time.Duration
+	overlapPolicy      OverlapPolicy
+	timeout            time.Duration
+	retryPolicy        *retry.Policy
+	catchUpPolicy      CatchUpPolicy
+	catchUpLimit       int
+	location           *time.Location
+	events             []string
+	calendar           string
+	holidayPolicy      HolidayPolicy
 }
 
+var _ LockableTask = (*scheduledTask)(nil)
+var _ OverlapControlledTask = (*scheduledTask)(nil)
+var _ TimeLimitedTask = (*scheduledTask)(nil)
+var _ RetryableTask = (*scheduledTask)(nil)
+var _ CatchUpTask = (*scheduledTask)(nil)
+var _ ZonedTask = (*scheduledTask)(nil)
+var _ EventTriggeredTask = (*scheduledTask)(nil)
+var _ CalendarTask = (*scheduledTask)(nil)
+
 // Identifier returns unique ID of this task.
 func (task *scheduledTask) Identifier() string {
 	return task.identifier
@@ -98,20 +136,91 @@ func (task *scheduledTask) DefaultDestination() OutputDestination {
 	return task.defaultDestination
 }
 
+// LockKey returns the key of the lock acquired on execution.
+func (task *scheduledTask) LockKey() string {
+	return task.lockKey
+}
+
+// LockTTL returns the lease duration of the lock acquired on execution.
+func (task *scheduledTask) LockTTL() time.Duration {
+	return task.lockTTL
+}
+
+// OverlapPolicy returns how this task behaves when the previous execution is still running.
+func (task *scheduledTask) OverlapPolicy() OverlapPolicy {
+	return task.overlapPolicy
+}
+
+// Timeout returns the duration after which the execution's context is canceled.
+func (task *scheduledTask) Timeout() time.Duration {
+	return task.timeout
+}
+
+// RetryPolicy returns the policy to retry the failed execution.
+func (task *scheduledTask) RetryPolicy() *retry.Policy {
+	return task.retryPolicy
+}
+
+// CatchUpPolicy returns how the executions missed during downtime or pause are caught up.
+func (task *scheduledTask) CatchUpPolicy() CatchUpPolicy {
+	return task.catchUpPolicy
+}
+
+// CatchUpLimit returns the maximum number of catch-up executions with CatchUpAll.
+func (task *scheduledTask) CatchUpLimit() int {
+	return task.catchUpLimit
+}
+
+// Location returns the time zone to interpret the schedule.
+func (task *scheduledTask) Location() *time.Location {
+	return task.location
+}
+
+// Events returns the names of the events that trigger this task.
+func (task *scheduledTask) Events() []string {
+	return task.events
+}
+
+// Calendar returns the name of the holiday calendar.
+func (task *scheduledTask) Calendar() string {
+	return task.calendar
+}
+
+// HolidayPolicy returns how the execution behaves on a holiday.
+func (task *scheduledTask) HolidayPolicy() HolidayPolicy {
+	return task.holidayPolicy
+}
+
 func buildScheduledTask(ctx context.Context, props *ScheduledTaskProps, watcher ConfigWatcher) (ScheduledTask, error) {
 	if props.config == nil {
-		// If config struct is not set, props MUST provide settings to set schedule.
-		if props.schedule == "" {
+		// If config struct is not set, props MUST provide settings to set schedule or events.
+		if props.schedule == "" && len(props.events) == 0 {
 			return nil, ErrTaskScheduleNotGiven
 		}
 
 		dest := props.defaultDestination // Can be nil because task response may return specific destination to send result to.
+		loc, err := loadTaskLocation(props.timeZone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load time zone for %s:%s: %w", props.botType, props.identifier, err)
+		}
+
 		return &scheduledTask{
 			identifier:         props.identifier,
 			taskFunc:           props.taskFunc,
 			schedule:           props.schedule,
 			defaultDestination: dest,
 			configWrapper:      nil,
+			lockKey:            props.lockKey,
+			lockTTL:            props.lockTTL,
+			overlapPolicy:      props.overlapPolicy,
+			timeout:            props.timeout,
+			retryPolicy:        props.retryPolicy,
+			catchUpPolicy:      props.catchUpPolicy,
+			catchUpLimit:       props.catchUpLimit,
+			location:           loc,
+			events:             props.events,
+			calendar:           props.calendar,
+			holidayPolicy:      props.holidayPolicy,
 		}, nil
 	}
 
@@ -157,7 +266,7 @@ func buildScheduledTask(ctx context.Context, props *ScheduledTaskProps, watcher
 			schedule = s
 		}
 	}
-	if schedule == "" {
+	if schedule == "" && len(props.events) == 0 {
 		return nil, ErrTaskScheduleNotGiven
 	}
 
@@ -170,6 +279,26 @@ func buildScheduledTask(ctx context.Context, props *ScheduledTaskProps, watcher
 		}
 	}
 
+	// Setup retry policy
+	retryPolicy := props.retryPolicy
+	if retriedConfig, ok := (cfg).(RetriedConfig); ok {
+		if p := retriedConfig.RetryPolicy(); p != nil {
+			retryPolicy = p
+		}
+	}
+
+	// Setup time zone
+	timeZone := props.timeZone
+	if zonedConfig, ok := (cfg).(ZonedConfig); ok {
+		if tz := zonedConfig.TimeZone(); tz != "" {
+			timeZone = tz
+		}
+	}
+	loc, err := loadTaskLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone for %s:%s: %w", props.botType, props.identifier, err)
+	}
+
 	return &scheduledTask{
 		identifier:         props.identifier,
 		taskFunc:           props.taskFunc,
@@ -179,6 +308,17 @@ func buildScheduledTask(ctx context.Context, props *ScheduledTaskProps, watcher
 			value: cfg,
 			mutex: locker,
 		},
+		lockKey:       props.lockKey,
+		lockTTL:       props.lockTTL,
+		overlapPolicy: props.overlapPolicy,
+		timeout:       props.timeout,
+		retryPolicy:   retryPolicy,
+		catchUpPolicy: props.catchUpPolicy,
+		catchUpLimit:  props.catchUpLimit,
+		location:      loc,
+		events:        props.events,
+		calendar:      props.calendar,
+		holidayPolicy: props.holidayPolicy,
 	}, nil
 }
 
@@ -191,6 +331,17 @@ type ScheduledTaskProps struct {
 	schedule           string
 	defaultDestination OutputDestination
 	config             TaskConfig
+	lockKey            string
+	lockTTL            time.Duration
+	overlapPolicy      OverlapPolicy
+	timeout            time.Duration
+	retryPolicy        *retry.Policy
+	catchUpPolicy      CatchUpPolicy
+	catchUpLimit       int
+	timeZone           string
+	events             []string
+	calendar           string
+	holidayPolicy      HolidayPolicy
 }
 
 // ScheduledTaskPropsBuilder helps to construct ScheduledTaskProps.
@@ -257,6 +408,95 @@ func (builder *ScheduledTaskPropsBuilder) ConfigurableFunc(config TaskConfig, fn
 	return builder
 }
 
+// LockKey is a setter to provide the key of the lock acquired before each scheduled execution when TaskLocker is registered.
+// Executions of tasks sharing the same key and the same scheduled time run only once across replicas.
+// When this is not set, the key returned by TaskLockKey is used.
+func (builder *ScheduledTaskPropsBuilder) LockKey(key string) *ScheduledTaskPropsBuilder {
+	builder.props.lockKey = key
+	return builder
+}
+
+// LockTTL is a setter to provide the lease duration of the lock acquired before each scheduled execution when TaskLocker is registered.
+// This should be longer than the clock skew among replicas. The lease is renewed while the execution runs.
+// When this is not set, Config.TaskLockTTL is used.
+func (builder *ScheduledTaskPropsBuilder) LockTTL(ttl time.Duration) *ScheduledTaskPropsBuilder {
+	builder.props.lockTTL = ttl
+	return builder
+}
+
+// OverlapPolicy is a setter to provide how a scheduled execution behaves when the previous execution is still running.
+// When this is not set, OverlapAllow is used and executions may run concurrently.
+func (builder *ScheduledTaskPropsBuilder) OverlapPolicy(policy OverlapPolicy) *ScheduledTaskPropsBuilder {
+	builder.props.overlapPolicy = policy
+	return builder
+}
+
+// Timeout is a setter to provide the duration after which the context passed to the task function is canceled.
+// The task function should refer to ctx.Done() to stop its long-running operation.
+// When this is not set, the execution is not time-limited.
+func (builder *ScheduledTaskPropsBuilder) Timeout(timeout time.Duration) *ScheduledTaskPropsBuilder {
+	builder.props.timeout = timeout
+	return builder
+}
+
+// RetryPolicy is a setter to provide the policy to retry the failed execution before it is counted as a failure.
+// Retrial stops when the context is canceled or when the next scheduled execution time comes,
+// and the context passed to the retried execution is canceled at that time so it does not overlap with the next execution.
+// A task without schedule retries for up to 10 minutes.
+// When this is not set, the failed execution is not retried.
+// The value can also be given by config struct that satisfies RetriedConfig.
+func (builder *ScheduledTaskPropsBuilder) RetryPolicy(policy *retry.Policy) *ScheduledTaskPropsBuilder {
+	builder.props.retryPolicy = policy
+	return builder
+}
+
+// CatchUp is a setter to provide how the executions missed during downtime or pause are caught up on Bot start and on ResumeTask.
+// This takes effect only when TaskRunStore is registered via RegisterTaskRunStore.
+// When this is not set, CatchUpNone is used and missed executions are not caught up.
+func (builder *ScheduledTaskPropsBuilder) CatchUp(policy CatchUpPolicy) *ScheduledTaskPropsBuilder {
+	builder.props.catchUpPolicy = policy
+	return builder
+}
+
+// CatchUpLimit is a setter to provide the maximum number of catch-up executions with CatchUpAll.
+// When this is not set, up to 10 executions are caught up.
+func (builder *ScheduledTaskPropsBuilder) CatchUpLimit(limit int) *ScheduledTaskPropsBuilder {
+	builder.props.catchUpLimit = limit
+	return builder
+}
+
+// TimeZone is a setter to provide the time zone to interpret the execution schedule such as "Asia/Tokyo."
+// When this is not set, Config.BotTimeZones for the BotType or Config.TimeZone is used.
+// The CRON_TZ= prefix of the schedule takes priority over this value.
+// The value can also be given by config struct that satisfies ZonedConfig.
+func (builder *ScheduledTaskPropsBuilder) TimeZone(timeZone string) *ScheduledTaskPropsBuilder {
+	builder.props.timeZone = timeZone
+	return builder
+}
+
+// OnEvent is a setter to provide the names of the events that trigger the task.
+// The task is executed whenever any of the events is published with PublishEvent, and the event is available via EventFromContext.
+// When this is set, the execution schedule can be omitted so the task is executed only on the events.
+func (builder *ScheduledTaskPropsBuilder) OnEvent(names ...string) *ScheduledTaskPropsBuilder {
+	builder.props.events = append(builder.props.events, names...)
+	return builder
+}
+
+// Calendar is a setter to provide the name of the holiday calendar that the scheduled executions refer to.
+// The calendar is read via ConfigWatcher with the given name as its ID and is reloaded on its update.
+// See CalendarTask for the format of the calendar.
+func (builder *ScheduledTaskPropsBuilder) Calendar(name string) *ScheduledTaskPropsBuilder {
+	builder.props.calendar = name
+	return builder
+}
+
+// OnHoliday is a setter to provide how the scheduled execution behaves when it lands on a holiday of the calendar given by Calendar.
+// When this is not set, HolidaySkip is used and the execution on a holiday is skipped.
+func (builder *ScheduledTaskPropsBuilder) OnHoliday(policy HolidayPolicy) *ScheduledTaskPropsBuilder {
+	builder.props.holidayPolicy = policy
+	return builder
+}
+
 // Build builds new ScheduledProps instance with provided values.
 func (builder *ScheduledTaskPropsBuilder) Build() (*ScheduledTaskProps, error) {
 	if builder.props.botType == "" ||
@@ -267,18 +507,23 @@ func (builder *ScheduledTaskPropsBuilder) Build() (*ScheduledTaskProps, error) {
 	}
 
 	taskConfig := builder.props.config
-	if taskConfig == nil && builder.props.schedule == "" {
+	triggered := builder.props.schedule != "" || len(builder.props.events) > 0
+	if taskConfig == nil && !triggered {
 		// Task Schedule can never be specified.
 		return nil, ErrTaskScheduleNotGiven
 	}
 
 	if taskConfig != nil {
-		if _, ok := (taskConfig).(ScheduledConfig); !ok && builder.props.schedule == "" {
+		if _, ok := (taskConfig).(ScheduledConfig); !ok && !triggered {
 			// Task Schedule can never be specified.
 			return nil, ErrTaskScheduleNotGiven
 		}
 	}
 
+	if _, err := loadTaskLocation(builder.props.timeZone); err != nil {
+		return nil, err
+	}
+
 	return builder.props, nil
 }
 
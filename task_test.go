@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/oklahomer/go-sarah/v3/retry"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 type DummyScheduledTask struct {
@@ -110,6 +113,150 @@ func TestScheduledTaskPropsBuilder_DefaultDestination(t *testing.T) {
 	}
 }
 
+func TestScheduledTaskPropsBuilder_LockKey(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.LockKey("shared")
+
+	if builder.props.lockKey != "shared" {
+		t.Errorf("Supplied lock key is not set: %s.", builder.props.lockKey)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_LockTTL(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.LockTTL(time.Hour)
+
+	if builder.props.lockTTL != time.Hour {
+		t.Errorf("Supplied lock TTL is not set: %s.", builder.props.lockTTL)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_OverlapPolicy(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.OverlapPolicy(OverlapSkip)
+
+	if builder.props.overlapPolicy != OverlapSkip {
+		t.Errorf("Supplied policy is not set: %s.", builder.props.overlapPolicy)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_Timeout(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.Timeout(time.Minute)
+
+	if builder.props.timeout != time.Minute {
+		t.Errorf("Supplied timeout is not set: %s.", builder.props.timeout)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_RetryPolicy(t *testing.T) {
+	policy := &retry.Policy{Trial: 3}
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.RetryPolicy(policy)
+
+	if builder.props.retryPolicy != policy {
+		t.Errorf("Supplied policy is not set: %#v.", builder.props.retryPolicy)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_CatchUp(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.CatchUp(CatchUpAll)
+
+	if builder.props.catchUpPolicy != CatchUpAll {
+		t.Errorf("Supplied policy is not set: %s.", builder.props.catchUpPolicy)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_CatchUpLimit(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.CatchUpLimit(5)
+
+	if builder.props.catchUpLimit != 5 {
+		t.Errorf("Supplied limit is not set: %d.", builder.props.catchUpLimit)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_TimeZone(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.TimeZone("Asia/Tokyo")
+
+	if builder.props.timeZone != "Asia/Tokyo" {
+		t.Errorf("Supplied time zone is not set: %s.", builder.props.timeZone)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_Build_WithInvalidTimeZone(t *testing.T) {
+	_, err := NewScheduledTaskPropsBuilder().
+		BotType("dummyBot").
+		Identifier("dummyTask").
+		Func(func(_ context.Context) ([]*ScheduledTaskResult, error) { return nil, nil }).
+		Schedule("@daily").
+		TimeZone("Invalid/Zone").
+		Build()
+
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestScheduledTaskPropsBuilder_OnEvent(t *testing.T) {
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.OnEvent("deployed").OnEvent("released", "rolled_back")
+
+	if !reflect.DeepEqual(builder.props.events, []string{"deployed", "released", "rolled_back"}) {
+		t.Errorf("Supplied events are not set: %#v.", builder.props.events)
+	}
+}
+
+func TestScheduledTaskPropsBuilder_Build_WithEventOnly(t *testing.T) {
+	props, err := NewScheduledTaskPropsBuilder().
+		BotType("dummyBot").
+		Identifier("dummyTask").
+		Func(func(_ context.Context) ([]*ScheduledTaskResult, error) { return nil, nil }).
+		OnEvent("deployed").
+		Build()
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	task, err := buildScheduledTask(context.TODO(), props, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if task.Schedule() != "" {
+		t.Errorf("Unexpected schedule is set: %s.", task.Schedule())
+	}
+	if !reflect.DeepEqual(taskEventNames(task), []string{"deployed"}) {
+		t.Errorf("Unexpected events are set: %#v.", taskEventNames(task))
+	}
+}
+
+func TestScheduledTaskPropsBuilder_Calendar(t *testing.T) {
+	props, err := NewScheduledTaskPropsBuilder().
+		BotType("dummyBot").
+		Identifier("dummyTask").
+		Func(func(_ context.Context) ([]*ScheduledTaskResult, error) { return nil, nil }).
+		Schedule("0 9 * * 1-5").
+		Calendar("holidays").
+		OnHoliday(HolidayShift).
+		Build()
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	task, err := buildScheduledTask(context.TODO(), props, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	name, policy := taskCalendar(task)
+	if name != "holidays" || policy != HolidayShift {
+		t.Errorf("Unexpected calendar setting is returned: %s, %s.", name, policy)
+	}
+}
+
 func TestScheduledTaskPropsBuilder_ConfigurableFunc(t *testing.T) {
 	config := &DummyScheduledTaskConfig{}
 	taskFunc := func(_ context.Context, c TaskConfig) ([]*ScheduledTaskResult, error) {
@@ -562,3 +709,110 @@ func Test_buildScheduledTask(t *testing.T) {
 //	time.Sleep(1 * time.Second)
 //	cancel()
 //}
+
+type DummyRetriedTaskConfig struct {
+	RetryPolicyValue *retry.Policy
+}
+
+func (config *DummyRetriedTaskConfig) RetryPolicy() *retry.Policy {
+	return config.RetryPolicyValue
+}
+
+func Test_buildScheduledTask_WithRetriedConfig(t *testing.T) {
+	propsPolicy := &retry.Policy{Trial: 2}
+	configPolicy := &retry.Policy{Trial: 5}
+	testSets := []struct {
+		configPolicy *retry.Policy
+		expected     *retry.Policy
+	}{
+		{configPolicy: nil, expected: propsPolicy},
+		{configPolicy: configPolicy, expected: configPolicy},
+	}
+
+	for i, testSet := range testSets {
+		props := &ScheduledTaskProps{
+			botType:     "botType",
+			identifier:  "retried",
+			taskFunc:    func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) { return nil, nil },
+			schedule:    "@daily",
+			config:      &DummyRetriedTaskConfig{},
+			retryPolicy: propsPolicy,
+		}
+		watcher := &DummyConfigWatcher{
+			ReadFunc: func(_ context.Context, _ BotType, _ string, cfg interface{}) error {
+				cfg.(*DummyRetriedTaskConfig).RetryPolicyValue = testSet.configPolicy
+				return nil
+			},
+		}
+
+		task, err := buildScheduledTask(context.TODO(), props, watcher)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test %d: %s.", i, err.Error())
+		}
+
+		if policy := task.(*scheduledTask).RetryPolicy(); policy != testSet.expected {
+			t.Errorf("Unexpected policy is set on test %d: %#v.", i, policy)
+		}
+	}
+}
+
+type DummyZonedTaskConfig struct {
+	TimeZoneValue string
+}
+
+func (config *DummyZonedTaskConfig) TimeZone() string {
+	return config.TimeZoneValue
+}
+
+func Test_buildScheduledTask_WithZonedConfig(t *testing.T) {
+	testSets := []struct {
+		propsTimeZone  string
+		configTimeZone string
+		expected       string
+		hasErr         bool
+	}{
+		{propsTimeZone: "", configTimeZone: "", expected: ""},
+		{propsTimeZone: "Asia/Tokyo", configTimeZone: "", expected: "Asia/Tokyo"},
+		{propsTimeZone: "Asia/Tokyo", configTimeZone: "Europe/Berlin", expected: "Europe/Berlin"},
+		{propsTimeZone: "", configTimeZone: "Invalid/Zone", hasErr: true},
+	}
+
+	for i, testSet := range testSets {
+		props := &ScheduledTaskProps{
+			botType:    "botType",
+			identifier: "zoned",
+			taskFunc:   func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) { return nil, nil },
+			schedule:   "@daily",
+			config:     &DummyZonedTaskConfig{},
+			timeZone:   testSet.propsTimeZone,
+		}
+		watcher := &DummyConfigWatcher{
+			ReadFunc: func(_ context.Context, _ BotType, _ string, cfg interface{}) error {
+				cfg.(*DummyZonedTaskConfig).TimeZoneValue = testSet.configTimeZone
+				return nil
+			},
+		}
+
+		task, err := buildScheduledTask(context.TODO(), props, watcher)
+		if testSet.hasErr {
+			if err == nil {
+				t.Errorf("Expected error is not returned on test %d.", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test %d: %s.", i, err.Error())
+		}
+
+		loc := task.(*scheduledTask).Location()
+		if testSet.expected == "" {
+			if loc != nil {
+				t.Errorf("Location should be nil on test %d: %s.", i, loc)
+			}
+			continue
+		}
+		if loc == nil || loc.String() != testSet.expected {
+			t.Errorf("Unexpected location is set on test %d: %s.", i, loc)
+		}
+	}
+}
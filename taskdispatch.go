@@ -0,0 +1,92 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"github.com/oklahomer/go-sarah/v3/workers"
+	"time"
+)
+
+// TaskOverflowPolicy defines how a scheduled execution behaves when it cannot be enqueued because the worker's queue is full.
+// This is referred only when Config.DispatchTasks is true.
+type TaskOverflowPolicy string
+
+const (
+	// TaskOverflowSkip skips the execution that cannot be enqueued.
+	// The skipped execution is counted in TaskStatus.Skipped.
+	// This is the default policy.
+	TaskOverflowSkip TaskOverflowPolicy = "skip"
+
+	// TaskOverflowWait keeps trying to enqueue the execution until the worker accepts it or the Bot stops.
+	TaskOverflowWait TaskOverflowPolicy = "wait"
+)
+
+// TaskQueueGaugeName is the name of the gauge in workers.Stats.QueueGauges that reports the number of scheduled executions waiting in the worker's queue.
+const TaskQueueGaugeName = "scheduled_tasks"
+
+// WithTaskQueueGauge creates and returns workers.WorkerOption to report the number of scheduled executions waiting in the worker's queue
+// via workers.Stats.QueueGauges alongside other stats.
+// The worker created by go-sarah's core has this option; pass this to workers.Run when the worker is registered via RegisterWorker or RegisterTaskWorker.
+//
+//  worker, err := workers.Run(ctx, workers.NewConfig(), sarah.WithTaskQueueGauge(), workers.WithReporter(myReporter))
+//  sarah.RegisterTaskWorker(worker)
+func WithTaskQueueGauge() workers.WorkerOption {
+	return workers.WithQueueGauge(TaskQueueGaugeName, func() int {
+		return runnerStatus.queuedTaskCount()
+	})
+}
+
+// taskEnqueueInterval is the interval to retry enqueueing with TaskOverflowWait.
+var taskEnqueueInterval = 100 * time.Millisecond
+
+// dispatchScheduledTask executes the given task on the worker when Config.DispatchTasks is true.
+// Otherwise the task is executed on the calling goroutine.
+// Nothing is executed while the task is paused by PauseTask.
+// scheduledAt is the time the execution is scheduled at, and is zero for the execution triggered by an event.
+func (r *runner) dispatchScheduledTask(ctx context.Context, bot Bot, task ScheduledTask, scheduledAt time.Time) {
+	if pausedTasks.paused(bot.BotType(), task.Identifier()) {
+		log.Debugf("Skipping scheduled task %s since it is paused.", task.Identifier())
+		return
+	}
+
+	if !r.config.DispatchTasks {
+		r.runGuardedScheduledTask(ctx, bot, task, scheduledAt)
+		return
+	}
+
+	worker := r.taskWorker
+	if worker == nil {
+		worker = r.worker
+	}
+
+	job := func() {
+		runnerStatus.addQueuedTasks(-1)
+		r.runGuardedScheduledTask(ctx, bot, task, scheduledAt)
+	}
+	for {
+		// Increment before enqueueing so the counter does not go negative when the job starts right away.
+		runnerStatus.addQueuedTasks(1)
+		err := worker.Enqueue(job)
+		if err == nil {
+			return
+		}
+		runnerStatus.addQueuedTasks(-1)
+
+		if !errors.Is(err, workers.ErrQueueOverflow) || r.config.TaskOverflowPolicy != TaskOverflowWait {
+			log.Errorf("Failed to enqueue scheduled task %s: %+v", task.Identifier(), err)
+			runnerStatus.taskHistory(bot.BotType(), task.Identifier(), r.config.TaskHistorySize).skip()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Infof("Stop enqueueing scheduled task %s due to context cancellation.", task.Identifier())
+			return
+
+		case <-time.After(taskEnqueueInterval):
+			// Try again
+
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v3/workers"
+	"testing"
+	"time"
+)
+
+func Test_runner_dispatchScheduledTask(t *testing.T) {
+	testSets := []struct {
+		dispatch   bool
+		taskWorker bool
+	}{
+		{dispatch: false, taskWorker: false},
+		{dispatch: true, taskWorker: false},
+		{dispatch: true, taskWorker: true},
+	}
+
+	for i, testSet := range testSets {
+		SetupAndRun(func() {
+			executed := false
+			task := &scheduledTask{
+				identifier: "dispatched",
+				taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+					executed = true
+					return nil, nil
+				},
+			}
+
+			var jobs []func()
+			enqueue := func(job func()) error {
+				jobs = append(jobs, job)
+				return nil
+			}
+			sharedEnqueued := false
+			r := &runner{
+				config: &Config{
+					TaskHistorySize: 3,
+					DispatchTasks:   testSet.dispatch,
+				},
+				alerters: &alerters{},
+				worker: &DummyWorker{
+					EnqueueFunc: func(job func()) error {
+						sharedEnqueued = true
+						return enqueue(job)
+					},
+				},
+			}
+			if testSet.taskWorker {
+				r.taskWorker = &DummyWorker{EnqueueFunc: enqueue}
+			}
+
+			r.dispatchScheduledTask(context.TODO(), &DummyBot{BotTypeValue: "dummy"}, task, time.Now())
+
+			if !testSet.dispatch {
+				if !executed || len(jobs) != 0 {
+					t.Errorf("Task should be executed without worker on test %d.", i)
+				}
+				return
+			}
+
+			if executed || len(jobs) != 1 {
+				t.Fatalf("Task should be enqueued on test %d.", i)
+			}
+			if sharedEnqueued == testSet.taskWorker {
+				t.Errorf("Unexpected worker is used on test %d.", i)
+			}
+			if size := CurrentStatus().TaskQueueSize; size != 1 {
+				t.Errorf("Unexpected queue size is reported on test %d: %d.", i, size)
+			}
+
+			jobs[0]()
+
+			if !executed {
+				t.Errorf("Enqueued task is not executed on test %d.", i)
+			}
+			if size := CurrentStatus().TaskQueueSize; size != 0 {
+				t.Errorf("Unexpected queue size is reported on test %d: %d.", i, size)
+			}
+		})
+	}
+}
+
+func Test_runner_dispatchScheduledTask_WithOverflow(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		task := &scheduledTask{identifier: "overflow"}
+		enqueued := 0
+		r := &runner{
+			config: &Config{
+				TaskHistorySize:    3,
+				DispatchTasks:      true,
+				TaskOverflowPolicy: TaskOverflowSkip,
+			},
+			worker: &DummyWorker{
+				EnqueueFunc: func(_ func()) error {
+					enqueued++
+					return workers.ErrQueueOverflow
+				},
+			},
+		}
+
+		r.dispatchScheduledTask(context.TODO(), &DummyBot{BotTypeValue: botType}, task, time.Now())
+
+		if enqueued != 1 {
+			t.Errorf("Enqueue should not be retried: %d.", enqueued)
+		}
+		if skipped := runnerStatus.findTaskHistory(botType, task.identifier).snapshot().Skipped; skipped != 1 {
+			t.Errorf("Skipped execution is not counted: %d.", skipped)
+		}
+		if size := CurrentStatus().TaskQueueSize; size != 0 {
+			t.Errorf("Unexpected queue size is reported: %d.", size)
+		}
+	})
+}
+
+func Test_runner_dispatchScheduledTask_WithOverflowWait(t *testing.T) {
+	defaultInterval := taskEnqueueInterval
+	defer func() {
+		taskEnqueueInterval = defaultInterval
+	}()
+	taskEnqueueInterval = time.Millisecond
+
+	testSets := []struct {
+		err      error
+		canceled bool
+		enqueued int
+	}{
+		{
+			// Accepted on the third trial
+			err:      nil,
+			enqueued: 3,
+		},
+		{
+			// Stop on context cancellation
+			err:      workers.ErrQueueOverflow,
+			canceled: true,
+			enqueued: 2,
+		},
+		{
+			// Other errors stop retrial
+			err:      errors.New("dummy"),
+			enqueued: 2,
+		},
+	}
+
+	for i, testSet := range testSets {
+		SetupAndRun(func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			enqueued := 0
+			r := &runner{
+				config: &Config{
+					TaskHistorySize:    3,
+					DispatchTasks:      true,
+					TaskOverflowPolicy: TaskOverflowWait,
+				},
+				worker: &DummyWorker{
+					EnqueueFunc: func(_ func()) error {
+						enqueued++
+						if enqueued == 1 {
+							return workers.ErrQueueOverflow
+						}
+						if enqueued == 2 && testSet.canceled {
+							cancel()
+						}
+						if enqueued < 3 && testSet.err == nil {
+							return workers.ErrQueueOverflow
+						}
+						return testSet.err
+					},
+				},
+			}
+
+			r.dispatchScheduledTask(ctx, &DummyBot{BotTypeValue: "dummy"}, &scheduledTask{identifier: "overflow"}, time.Now())
+
+			if enqueued != testSet.enqueued {
+				t.Errorf("Unexpected number of trials on test %d: %d.", i, enqueued)
+			}
+		})
+	}
+}
+
+type DummyWorkerReporter struct {
+	ReportFunc func(context.Context, *workers.Stats)
+}
+
+func (r *DummyWorkerReporter) Report(ctx context.Context, stats *workers.Stats) {
+	r.ReportFunc(ctx, stats)
+}
+
+func TestWithTaskQueueGauge(t *testing.T) {
+	SetupAndRun(func() {
+		reported := make(chan *workers.Stats, 1)
+		config := workers.NewConfig()
+		config.SuperviseInterval = time.Millisecond
+		reporter := &DummyWorkerReporter{
+			ReportFunc: func(_ context.Context, stats *workers.Stats) {
+				select {
+				case reported <- stats:
+				default:
+				}
+			},
+		}
+
+		runnerStatus.addQueuedTasks(2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err := workers.Run(ctx, config, WithTaskQueueGauge(), workers.WithReporter(reporter))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		select {
+		case stats := <-reported:
+			if size := stats.QueueGauges[TaskQueueGaugeName]; size != 2 {
+				t.Errorf("Unexpected task queue size is reported: %d.", size)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatal("Stats are not reported.")
+
+		}
+	})
+}
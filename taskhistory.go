@@ -0,0 +1,216 @@
+package sarah
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"sync"
+	"time"
+)
+
+// TaskExecution represents the result of one scheduled task execution.
+type TaskExecution struct {
+	// StartedAt is the time the execution started.
+	StartedAt time.Time
+
+	// Duration is the time the execution took.
+	Duration time.Duration
+
+	// Err is the error returned by ScheduledTask.Execute, or *BroadcastError when the results are not broadcast to some of the targets.
+	// This is nil when the execution succeeded.
+	Err error
+
+	// ResultCount is the number of ScheduledTaskResult returned by the execution.
+	ResultCount int
+}
+
+// Failed tells if the execution failed.
+func (e *TaskExecution) Failed() bool {
+	return e.Err != nil
+}
+
+// TaskStatus represents the execution status of a scheduled task.
+type TaskStatus struct {
+	// Identifier is the identifier of the ScheduledTask.
+	Identifier string
+
+	// Executions contains the recent executions in chronological order.
+	// The number of stored executions is limited by Config.TaskHistorySize.
+	Executions []TaskExecution
+
+	// ConsecutiveFailures is the number of failures since the last successful execution.
+	ConsecutiveFailures int
+
+	// Skipped is the number of scheduled executions skipped by OverlapPolicy, by TaskOverflowSkip, or on holidays by HolidayPolicy.
+	Skipped int
+
+	// NextRun is the next scheduled execution time in the task's time zone.
+	// This is zero when the task is not scheduled.
+	NextRun time.Time
+
+	// Paused tells if the scheduled executions are suspended by PauseTask.
+	Paused bool
+}
+
+// ScheduledTaskFailure is passed to the registered Alerters when a scheduled task fails consecutively.
+// A partial failure to broadcast the results is also counted as a failure.
+// The number of failures to trigger this alert is configured by Config.TaskFailureAlertThreshold.
+type ScheduledTaskFailure struct {
+	Identifier          string
+	ConsecutiveFailures int
+	Err                 error
+}
+
+var _ error = (*ScheduledTaskFailure)(nil)
+
+// Error returns the description of the failure.
+func (f *ScheduledTaskFailure) Error() string {
+	return fmt.Sprintf("scheduled task %s failed %d time(s) in a row: %s", f.Identifier, f.ConsecutiveFailures, f.Err.Error())
+}
+
+// Unwrap returns the error returned by the last execution.
+func (f *ScheduledTaskFailure) Unwrap() error {
+	return f.Err
+}
+
+// ScheduledTaskRecovery is passed to the registered Alerters when a scheduled task succeeds after ScheduledTaskFailure is notified.
+type ScheduledTaskRecovery struct {
+	Identifier string
+	Failures   int
+}
+
+var _ error = (*ScheduledTaskRecovery)(nil)
+
+// Error returns the description of the recovery.
+func (r *ScheduledTaskRecovery) Error() string {
+	return fmt.Sprintf("scheduled task %s recovered after %d failure(s)", r.Identifier, r.Failures)
+}
+
+// ScheduledTaskHistory returns the recent executions of the scheduled task in chronological order.
+// This returns nil when the task has never been executed.
+func ScheduledTaskHistory(botType BotType, taskID string) []TaskExecution {
+	history := runnerStatus.findTaskHistory(botType, taskID)
+	if history == nil {
+		return nil
+	}
+	return history.snapshot().Executions
+}
+
+type taskHistory struct {
+	identifier          string
+	executions          []TaskExecution
+	next                int
+	size                int
+	consecutiveFailures int
+	alerted             bool
+	skipped             int
+	schedule            cron.Schedule
+	location            *time.Location
+	mutex               sync.RWMutex
+}
+
+func newTaskHistory(identifier string, size int) *taskHistory {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &taskHistory{
+		identifier: identifier,
+		executions: make([]TaskExecution, 0, size),
+		size:       size,
+	}
+}
+
+// record stores the execution and returns the notice to be sent to Alerters if any.
+// ScheduledTaskFailure is returned only once when the consecutive failures reach the given threshold,
+// and ScheduledTaskRecovery is returned when the task succeeds after the failure is notified.
+// A threshold of zero disables the notification.
+func (h *taskHistory) record(execution *TaskExecution, threshold int) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.executions) < h.size {
+		h.executions = append(h.executions, *execution)
+	} else {
+		h.executions[h.next] = *execution
+	}
+	h.next = (h.next + 1) % h.size
+
+	if !execution.Failed() {
+		failures := h.consecutiveFailures
+		alerted := h.alerted
+		h.consecutiveFailures = 0
+		h.alerted = false
+		if alerted {
+			return &ScheduledTaskRecovery{
+				Identifier: h.identifier,
+				Failures:   failures,
+			}
+		}
+		return nil
+	}
+
+	h.consecutiveFailures++
+	if threshold <= 0 || h.alerted || h.consecutiveFailures < threshold {
+		return nil
+	}
+
+	h.alerted = true
+	return &ScheduledTaskFailure{
+		Identifier:          h.identifier,
+		ConsecutiveFailures: h.consecutiveFailures,
+		Err:                 execution.Err,
+	}
+}
+
+// skip counts the execution skipped by OverlapPolicy, by TaskOverflowSkip, or on a holiday.
+func (h *taskHistory) skip() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.skipped++
+}
+
+// setSchedule stores the task's schedule and time zone to report the next execution time.
+func (h *taskHistory) setSchedule(schedule cron.Schedule, location *time.Location) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.schedule = schedule
+	h.location = location
+}
+
+func (h *taskHistory) snapshot() TaskStatus {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	executions := make([]TaskExecution, 0, len(h.executions))
+	if len(h.executions) < h.size {
+		executions = append(executions, h.executions...)
+	} else {
+		executions = append(executions, h.executions[h.next:]...)
+		executions = append(executions, h.executions[:h.next]...)
+	}
+
+	var nextRun time.Time
+	if h.schedule != nil {
+		loc := h.location
+		if spec, ok := h.schedule.(*cron.SpecSchedule); ok {
+			// Respect the CRON_TZ= prefix of the schedule.
+			loc = spec.Location
+		}
+
+		now := time.Now()
+		if loc != nil {
+			now = now.In(loc)
+		}
+		nextRun = h.schedule.Next(now)
+	}
+
+	return TaskStatus{
+		Identifier:          h.identifier,
+		Executions:          executions,
+		ConsecutiveFailures: h.consecutiveFailures,
+		Skipped:             h.skipped,
+		NextRun:             nextRun,
+	}
+}
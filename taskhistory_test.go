@@ -0,0 +1,147 @@
+package sarah
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskExecution_Failed(t *testing.T) {
+	if (&TaskExecution{}).Failed() {
+		t.Error("Execution without error should not be failed.")
+	}
+
+	if !(&TaskExecution{Err: errors.New("dummy")}).Failed() {
+		t.Error("Execution with error should be failed.")
+	}
+}
+
+func TestScheduledTaskFailure(t *testing.T) {
+	err := errors.New("dummy")
+	failure := &ScheduledTaskFailure{
+		Identifier:          "report",
+		ConsecutiveFailures: 3,
+		Err:                 err,
+	}
+
+	if !errors.Is(failure, err) {
+		t.Error("Original error should be unwrapped.")
+	}
+
+	if !strings.Contains(failure.Error(), "report") {
+		t.Errorf("Identifier is not included: %s.", failure.Error())
+	}
+}
+
+func TestScheduledTaskRecovery_Error(t *testing.T) {
+	recovery := &ScheduledTaskRecovery{
+		Identifier: "report",
+		Failures:   3,
+	}
+
+	if !strings.Contains(recovery.Error(), "report") {
+		t.Errorf("Identifier is not included: %s.", recovery.Error())
+	}
+}
+
+func TestScheduledTaskHistory(t *testing.T) {
+	SetupAndRun(func() {
+		if history := ScheduledTaskHistory("dummy", "report"); history != nil {
+			t.Errorf("Nil should be returned for unknown task: %#v.", history)
+		}
+
+		runnerStatus.taskHistory("dummy", "report", 3).record(&TaskExecution{ResultCount: 1}, 0)
+
+		history := ScheduledTaskHistory("dummy", "report")
+		if len(history) != 1 || history[0].ResultCount != 1 {
+			t.Errorf("Unexpected history is returned: %#v.", history)
+		}
+	})
+}
+
+func Test_newTaskHistory(t *testing.T) {
+	history := newTaskHistory("report", 0)
+	if history.size != 1 {
+		t.Errorf("Size should be at least 1: %d.", history.size)
+	}
+}
+
+func Test_taskHistory_record(t *testing.T) {
+	history := newTaskHistory("report", 3)
+	startedAt := time.Now()
+	for i := 0; i < 5; i++ {
+		history.record(&TaskExecution{StartedAt: startedAt.Add(time.Duration(i) * time.Second), ResultCount: i}, 0)
+	}
+
+	executions := history.snapshot().Executions
+	if len(executions) != 3 {
+		t.Fatalf("Unexpected number of executions are stored: %d.", len(executions))
+	}
+
+	for i, execution := range executions {
+		if execution.ResultCount != i+2 {
+			t.Errorf("Executions are not in chronological order: %#v.", executions)
+		}
+	}
+}
+
+func Test_taskHistory_record_Alert(t *testing.T) {
+	err := errors.New("dummy")
+	testSets := []struct {
+		threshold int
+		failures  int
+		notified  bool
+	}{
+		{threshold: 0, failures: 5, notified: false},
+		{threshold: 3, failures: 2, notified: false},
+		{threshold: 3, failures: 3, notified: true},
+		{threshold: 3, failures: 5, notified: true},
+	}
+
+	for i, testSet := range testSets {
+		history := newTaskHistory("report", 10)
+
+		var notices []error
+		for j := 0; j < testSet.failures; j++ {
+			if notice := history.record(&TaskExecution{Err: err}, testSet.threshold); notice != nil {
+				notices = append(notices, notice)
+			}
+		}
+
+		if history.snapshot().ConsecutiveFailures != testSet.failures {
+			t.Errorf("Unexpected number of failures on test %d: %d.", i, history.snapshot().ConsecutiveFailures)
+		}
+
+		if !testSet.notified {
+			if len(notices) != 0 {
+				t.Errorf("Failure should not be notified on test %d: %#v.", i, notices)
+			}
+			if notice := history.record(&TaskExecution{}, testSet.threshold); notice != nil {
+				t.Errorf("Recovery should not be notified on test %d: %#v.", i, notice)
+			}
+			continue
+		}
+
+		if len(notices) != 1 {
+			t.Fatalf("Failure should be notified only once on test %d: %#v.", i, notices)
+		}
+
+		if _, ok := notices[0].(*ScheduledTaskFailure); !ok {
+			t.Errorf("Unexpected notice is returned on test %d: %#v.", i, notices[0])
+		}
+
+		notice := history.record(&TaskExecution{}, testSet.threshold)
+		recovery, ok := notice.(*ScheduledTaskRecovery)
+		if !ok {
+			t.Fatalf("Recovery should be notified on test %d: %#v.", i, notice)
+		}
+		if recovery.Failures != testSet.failures {
+			t.Errorf("Unexpected number of failures on test %d: %d.", i, recovery.Failures)
+		}
+
+		if history.snapshot().ConsecutiveFailures != 0 {
+			t.Errorf("Failures should be reset on test %d.", i)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package sarah
+
+import (
+	"context"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"sync"
+	"time"
+)
+
+// TaskLocker defines an interface that prevents the same scheduled task from running on multiple replicas.
+// When a TaskLocker is registered via RegisterTaskLocker, go-sarah's core acquires the lock before every scheduled execution
+// and skips the execution when another replica holds the lock.
+//
+// The lock is acquired per scheduled occurrence: the key given to TryLock is suffixed with the scheduled time,
+// so a task that fires more often than the lease duration is not blocked by the lock of its previous occurrence.
+// The lock is a lease that is renewed via Renew while the execution runs.
+// The acquired lock is kept until its lease expires even after the execution succeeds,
+// so a replica whose cron fires slightly later does not execute the same occurrence again.
+// The lock is released when the execution fails so another replica can take over.
+//
+// NewMemoryTaskLocker provides an in-memory implementation, and the storages package provides a file-lock implementation for a shared volume.
+// This is simple enough to be backed by a database table with key, owner, and expiration columns.
+type TaskLocker interface {
+	// TryLock acquires the lock for the given key for the duration of ttl.
+	// This returns false without an error when the lock is held and not expired, even if this TaskLocker holds it.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Renew extends the lease of the lock for the given key to the duration of ttl from now.
+	// This returns false without an error when this TaskLocker no longer holds the lock.
+	Renew(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Unlock releases the lock for the given key if this TaskLocker holds it.
+	Unlock(ctx context.Context, key string) error
+}
+
+// LockableTask is an optional interface that ScheduledTask implementation may satisfy to customize the lock acquired on its execution.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the values given to ScheduledTaskPropsBuilder.LockKey and LockTTL.
+type LockableTask interface {
+	// LockKey returns the base key of the lock, which is suffixed with the scheduled time on each execution.
+	// An empty string means the default key returned by TaskLockKey.
+	LockKey() string
+
+	// LockTTL returns the lease duration of the lock.
+	// Zero means the default duration of Config.TaskLockTTL.
+	LockTTL() time.Duration
+}
+
+// defaultTaskLockTTL is the lease duration used when neither Config.TaskLockTTL nor LockableTask.LockTTL is given.
+const defaultTaskLockTTL = 30 * time.Second
+
+// TaskLockKey returns the default lock key for the given BotType and scheduled task identifier.
+func TaskLockKey(botType BotType, taskID string) string {
+	return fmt.Sprintf("%s:%s", botType.String(), taskID)
+}
+
+// occurrenceLockKey returns the lock key for the execution scheduled at the given time.
+// Replicas fire the same occurrence at the same scheduled time, so they compete for the same key.
+func occurrenceLockKey(key string, scheduledAt time.Time) string {
+	return fmt.Sprintf("%s@%s", key, scheduledAt.UTC().Format(time.RFC3339))
+}
+
+// occurrenceLookback is how far scheduledOccurrence looks back from the fired time to find the scheduled occurrence.
+const occurrenceLookback = time.Hour
+
+// scheduledOccurrence returns the latest time the given schedule computes at or before firedAt.
+// The scheduler may fire a little late, and the delay differs among replicas,
+// so the occurrence computed from the schedule is used instead of the fired time to let every replica compete for the same lock key.
+// The fired time truncated to seconds is returned when no occurrence is found within occurrenceLookback.
+func scheduledOccurrence(schedule cron.Schedule, firedAt time.Time) time.Time {
+	var occurrence time.Time
+	for next := schedule.Next(firedAt.Add(-occurrenceLookback)); !next.IsZero() && !next.After(firedAt); next = schedule.Next(next) {
+		occurrence = next
+	}
+
+	if occurrence.IsZero() {
+		return firedAt.Truncate(time.Second)
+	}
+	return occurrence
+}
+
+// taskLock returns the lock key and the lease duration for the given task.
+func taskLock(botType BotType, task ScheduledTask, defaultTTL time.Duration) (string, time.Duration) {
+	key := TaskLockKey(botType, task.Identifier())
+	ttl := defaultTTL
+	if ttl <= 0 {
+		ttl = defaultTaskLockTTL
+	}
+	if lockable, ok := task.(LockableTask); ok {
+		if k := lockable.LockKey(); k != "" {
+			key = k
+		}
+		if t := lockable.LockTTL(); t > 0 {
+			ttl = t
+		}
+	}
+	return key, ttl
+}
+
+// memoryTaskLocker is a TaskLocker implementation that works within a process.
+type memoryTaskLocker struct {
+	locks map[string]time.Time
+	now   func() time.Time
+	mutex sync.Mutex
+}
+
+var _ TaskLocker = (*memoryTaskLocker)(nil)
+
+// NewMemoryTaskLocker creates and returns new TaskLocker implementation that holds locks in memory.
+// This only prevents duplicate executions within a process, such as the ones triggered by multiple runners in tests.
+// Use a shared implementation such as storages.NewFileTaskLocker to coordinate replicas.
+func NewMemoryTaskLocker() TaskLocker {
+	return &memoryTaskLocker{
+		locks: map[string]time.Time{},
+		now:   time.Now,
+	}
+}
+
+// TryLock acquires the lock for the given key for the duration of ttl.
+// Expired locks are removed at the same time since a lock is acquired for each scheduled occurrence.
+func (l *memoryTaskLocker) TryLock(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	for k, expiresAt := range l.locks {
+		if !now.Before(expiresAt) {
+			delete(l.locks, k)
+		}
+	}
+
+	if _, ok := l.locks[key]; ok {
+		return false, nil
+	}
+
+	l.locks[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Renew extends the lease of the lock for the given key.
+func (l *memoryTaskLocker) Renew(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	if expiresAt, ok := l.locks[key]; !ok || !now.Before(expiresAt) {
+		return false, nil
+	}
+
+	l.locks[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Unlock releases the lock for the given key.
+func (l *memoryTaskLocker) Unlock(_ context.Context, key string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.locks, key)
+	return nil
+}
@@ -0,0 +1,348 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/robfig/cron/v3"
+	"testing"
+	"time"
+)
+
+type DummyTaskLocker struct {
+	TryLockFunc func(context.Context, string, time.Duration) (bool, error)
+	RenewFunc   func(context.Context, string, time.Duration) (bool, error)
+	UnlockFunc  func(context.Context, string) error
+}
+
+var _ TaskLocker = (*DummyTaskLocker)(nil)
+
+func (l *DummyTaskLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.TryLockFunc(ctx, key, ttl)
+}
+
+func (l *DummyTaskLocker) Renew(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.RenewFunc(ctx, key, ttl)
+}
+
+func (l *DummyTaskLocker) Unlock(ctx context.Context, key string) error {
+	return l.UnlockFunc(ctx, key)
+}
+
+func TestTaskLockKey(t *testing.T) {
+	key := TaskLockKey("slack", "morning")
+	if key != "slack:morning" {
+		t.Errorf("Unexpected key is returned: %s.", key)
+	}
+}
+
+func Test_occurrenceLockKey(t *testing.T) {
+	scheduledAt := time.Date(2027, 1, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	key := occurrenceLockKey("slack:morning", scheduledAt)
+	if key != "slack:morning@2027-01-01T00:00:00Z" {
+		t.Errorf("Unexpected key is returned: %s.", key)
+	}
+}
+
+func Test_scheduledOccurrence(t *testing.T) {
+	occurrence := time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC)
+	testSets := []struct {
+		spec     string
+		firedAt  time.Time
+		expected time.Time
+	}{
+		{spec: "0 9 * * *", firedAt: occurrence, expected: occurrence},
+		{spec: "0 9 * * *", firedAt: occurrence.Add(1500 * time.Millisecond), expected: occurrence},
+		{spec: "* * * * *", firedAt: occurrence.Add(30 * time.Second), expected: occurrence},
+		{
+			// No occurrence is found within the lookback.
+			spec:     "0 9 * * *",
+			firedAt:  occurrence.Add(occurrenceLookback + 1500*time.Millisecond),
+			expected: occurrence.Add(occurrenceLookback + time.Second),
+		},
+	}
+
+	for i, testSet := range testSets {
+		schedule, err := cron.ParseStandard(testSet.spec)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test %d: %s.", i, err.Error())
+		}
+
+		if o := scheduledOccurrence(schedule, testSet.firedAt); !o.Equal(testSet.expected) {
+			t.Errorf("Unexpected occurrence is returned on test %d: %s.", i, o)
+		}
+	}
+}
+
+func Test_taskLock(t *testing.T) {
+	testSets := []struct {
+		task       ScheduledTask
+		defaultTTL time.Duration
+		key        string
+		ttl        time.Duration
+	}{
+		{
+			task:       &DummyScheduledTask{IdentifierValue: "morning"},
+			defaultTTL: time.Minute,
+			key:        "dummy:morning",
+			ttl:        time.Minute,
+		},
+		{
+			task:       &DummyScheduledTask{IdentifierValue: "morning"},
+			defaultTTL: 0,
+			key:        "dummy:morning",
+			ttl:        defaultTaskLockTTL,
+		},
+		{
+			task:       &scheduledTask{identifier: "morning", lockKey: "shared", lockTTL: time.Hour},
+			defaultTTL: time.Minute,
+			key:        "shared",
+			ttl:        time.Hour,
+		},
+		{
+			task:       &scheduledTask{identifier: "morning"},
+			defaultTTL: time.Minute,
+			key:        "dummy:morning",
+			ttl:        time.Minute,
+		},
+	}
+
+	for i, testSet := range testSets {
+		key, ttl := taskLock("dummy", testSet.task, testSet.defaultTTL)
+		if key != testSet.key {
+			t.Errorf("Unexpected key is returned on test %d: %s.", i, key)
+		}
+		if ttl != testSet.ttl {
+			t.Errorf("Unexpected TTL is returned on test %d: %s.", i, ttl)
+		}
+	}
+}
+
+func TestMemoryTaskLocker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	locker := NewMemoryTaskLocker().(*memoryTaskLocker)
+	locker.now = func() time.Time {
+		return now
+	}
+
+	locked, err := locker.TryLock(ctx, "key", time.Minute)
+	if err != nil || !locked {
+		t.Fatalf("Lock should be acquired: %t, %+v.", locked, err)
+	}
+
+	if locked, _ := locker.TryLock(ctx, "key", time.Minute); locked {
+		t.Error("Lock should not be acquired while the lease is valid.")
+	}
+
+	now = now.Add(30 * time.Second)
+	if renewed, _ := locker.Renew(ctx, "key", time.Minute); !renewed {
+		t.Error("Lease should be renewed while it is valid.")
+	}
+
+	now = now.Add(45 * time.Second)
+	if locked, _ := locker.TryLock(ctx, "key", time.Minute); locked {
+		t.Error("Lock should not be acquired while the renewed lease is valid.")
+	}
+
+	if locked, _ := locker.TryLock(ctx, "another", time.Minute); !locked {
+		t.Error("Lock with another key should be acquired.")
+	}
+
+	now = now.Add(time.Minute)
+	if renewed, _ := locker.Renew(ctx, "key", time.Minute); renewed {
+		t.Error("Expired lease should not be renewed.")
+	}
+	if locked, _ := locker.TryLock(ctx, "key", time.Minute); !locked {
+		t.Error("Lock should be acquired after the lease expires.")
+	}
+	if _, ok := locker.locks["another"]; ok {
+		t.Error("Expired lock should be removed.")
+	}
+
+	_ = locker.Unlock(ctx, "key")
+	if locked, _ := locker.TryLock(ctx, "key", time.Minute); !locked {
+		t.Error("Lock should be acquired after unlock.")
+	}
+}
+
+func Test_runner_runLockedScheduledTask(t *testing.T) {
+	SetupAndRun(func() {
+		taskErr := errors.New("task error")
+		testSets := []struct {
+			locked   bool
+			lockErr  error
+			taskErr  error
+			executed bool
+			unlocked bool
+		}{
+			{locked: true, executed: true},
+			{locked: true, taskErr: taskErr, executed: true, unlocked: true},
+			{locked: false},
+			{lockErr: errors.New("lock error")},
+		}
+
+		for i, testSet := range testSets {
+			executed := false
+			unlocked := false
+			task := &DummyScheduledTask{
+				IdentifierValue: "morning",
+				ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+					executed = true
+					return nil, testSet.taskErr
+				},
+			}
+			r := &runner{
+				config:   &Config{TaskLockTTL: time.Minute},
+				alerters: &alerters{},
+				taskLocker: &DummyTaskLocker{
+					TryLockFunc: func(_ context.Context, key string, ttl time.Duration) (bool, error) {
+						if key != "dummy:morning@2027-01-01T09:00:00Z" || ttl != time.Minute {
+							t.Errorf("Unexpected lock is requested on test %d: %s, %s.", i, key, ttl)
+						}
+						return testSet.locked, testSet.lockErr
+					},
+					UnlockFunc: func(_ context.Context, _ string) error {
+						unlocked = true
+						return nil
+					},
+				},
+			}
+
+			r.runLockedScheduledTask(context.TODO(), &DummyBot{BotTypeValue: "dummy"}, task, time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC))
+
+			if executed != testSet.executed {
+				t.Errorf("Unexpected execution on test %d: %t.", i, executed)
+			}
+			if unlocked != testSet.unlocked {
+				t.Errorf("Unexpected unlock on test %d: %t.", i, unlocked)
+			}
+		}
+	})
+}
+
+func Test_runner_runLockedScheduledTask_WithoutLocker(t *testing.T) {
+	SetupAndRun(func() {
+		executed := false
+		task := &DummyScheduledTask{
+			IdentifierValue: "morning",
+			ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				executed = true
+				return nil, nil
+			},
+		}
+		r := &runner{
+			config:   &Config{},
+			alerters: &alerters{},
+		}
+
+		r.runLockedScheduledTask(context.TODO(), &DummyBot{BotTypeValue: "dummy"}, task, time.Now())
+
+		if !executed {
+			t.Error("Task should be executed without TaskLocker.")
+		}
+	})
+}
+
+func Test_runner_runLockedScheduledTask_FrequentTask(t *testing.T) {
+	SetupAndRun(func() {
+		executed := 0
+		task := &DummyScheduledTask{
+			IdentifierValue: "every_second",
+			ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				executed++
+				return nil, nil
+			},
+		}
+		// The task fires every second while the lease lasts for a minute.
+		r := &runner{
+			config:     &Config{TaskLockTTL: time.Minute},
+			alerters:   &alerters{},
+			taskLocker: NewMemoryTaskLocker(),
+		}
+		bot := &DummyBot{BotTypeValue: "dummy"}
+		scheduledAt := time.Now().Truncate(time.Second)
+
+		r.runLockedScheduledTask(context.TODO(), bot, task, scheduledAt)
+		r.runLockedScheduledTask(context.TODO(), bot, task, scheduledAt.Add(time.Second))
+		if executed != 2 {
+			t.Errorf("Each occurrence should be executed: %d.", executed)
+		}
+
+		// Another replica firing the same occurrence skips the execution.
+		r.runLockedScheduledTask(context.TODO(), bot, task, scheduledAt.Add(time.Second))
+		if executed != 2 {
+			t.Errorf("The same occurrence should not be executed twice: %d.", executed)
+		}
+	})
+}
+
+func Test_runner_dispatchScheduledOccurrence_DelayedFire(t *testing.T) {
+	SetupAndRun(func() {
+		executed := 0
+		task := &scheduledTask{
+			identifier: "morning",
+			schedule:   "0 9 * * *",
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				executed++
+				return nil, nil
+			},
+		}
+		schedule, _ := parseTaskSchedule(task, time.UTC)
+		locker := NewMemoryTaskLocker()
+		bot := &DummyBot{BotTypeValue: "dummy"}
+		occurrence := time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC)
+
+		// Two replicas share the locker, and the latter fires across the second boundary.
+		for _, firedAt := range []time.Time{occurrence.Add(200 * time.Millisecond), occurrence.Add(1400 * time.Millisecond)} {
+			r := &runner{
+				config:     &Config{TaskHistorySize: 3, TaskLockTTL: time.Minute},
+				alerters:   &alerters{},
+				taskLocker: locker,
+			}
+			r.dispatchScheduledOccurrence(context.TODO(), bot, task, time.UTC, scheduledOccurrence(schedule, firedAt))
+		}
+
+		if executed != 1 {
+			t.Errorf("The same occurrence should be executed only once: %d.", executed)
+		}
+	})
+}
+
+func Test_runner_runLockedScheduledTask_Renew(t *testing.T) {
+	SetupAndRun(func() {
+		renewed := make(chan string, 10)
+		task := &DummyScheduledTask{
+			IdentifierValue: "long",
+			ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				time.Sleep(50 * time.Millisecond)
+				return nil, nil
+			},
+		}
+		r := &runner{
+			config:   &Config{TaskLockTTL: 20 * time.Millisecond},
+			alerters: &alerters{},
+			taskLocker: &DummyTaskLocker{
+				TryLockFunc: func(_ context.Context, _ string, _ time.Duration) (bool, error) {
+					return true, nil
+				},
+				RenewFunc: func(_ context.Context, key string, _ time.Duration) (bool, error) {
+					renewed <- key
+					return true, nil
+				},
+			},
+		}
+
+		r.runLockedScheduledTask(context.TODO(), &DummyBot{BotTypeValue: "dummy"}, task, time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC))
+
+		select {
+		case key := <-renewed:
+			if key != "dummy:long@2027-01-01T09:00:00Z" {
+				t.Errorf("Unexpected key is renewed: %s.", key)
+			}
+
+		default:
+			t.Error("Lease is not renewed while the task runs.")
+
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package sarah
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"sort"
+	"sync"
+)
+
+var pausedTasks = &pauseRegistry{}
+
+// TaskPauseStore defines an interface that stores the paused state of scheduled tasks.
+// When a TaskPauseStore is registered via RegisterTaskPauseStore, PauseTask and ResumeTask store the state,
+// and the paused tasks are restored on Bot start so a process restart does not resume them.
+//
+// The storages package provides a file-based implementation.
+type TaskPauseStore interface {
+	// PausedTasks returns the identifiers of the paused tasks of the given BotType.
+	PausedTasks(ctx context.Context, botType BotType) ([]string, error)
+
+	// SetPaused stores the paused state of the given task.
+	SetPaused(ctx context.Context, botType BotType, taskID string, paused bool) error
+}
+
+// PauseTask suspends the scheduled executions of the registered task until ResumeTask is called.
+// The task stays registered along with its configuration subscription, so a configuration update does not resume the task.
+// The paused task can still be executed on demand via TriggerTask.
+//
+// ErrTaskNotFound is returned when the task is not registered or the Bot is not running.
+// When TaskPauseStore is registered, an error is returned if the state cannot be stored.
+func PauseTask(botType BotType, taskID string) error {
+	return pausedTasks.set(context.Background(), botType, taskID, true)
+}
+
+// ResumeTask resumes the scheduled executions of the task paused by PauseTask.
+// When TaskRunStore is registered, the executions missed while the task is paused are caught up asynchronously in accordance with the task's CatchUpPolicy.
+//
+// ErrTaskNotFound is returned when the task is not registered or the Bot is not running.
+// When TaskPauseStore is registered, an error is returned if the state cannot be stored.
+func ResumeTask(botType BotType, taskID string) error {
+	wasPaused := pausedTasks.paused(botType, taskID)
+	err := pausedTasks.set(context.Background(), botType, taskID, false)
+	if err != nil {
+		return err
+	}
+
+	if catchUp := triggerableTasks.findCatchUp(botType, taskID); wasPaused && catchUp != nil {
+		go catchUp()
+	}
+	return nil
+}
+
+// ListTasks returns the status of the scheduled tasks that are currently registered to the running Bot of the given BotType.
+// The returned values are sorted by their identifiers, and TaskStatus.Paused tells if each task is paused.
+func ListTasks(botType BotType) []TaskStatus {
+	ids := triggerableTasks.identifiers(botType)
+	sort.Strings(ids)
+
+	var tasks []TaskStatus
+	for _, id := range ids {
+		status := TaskStatus{Identifier: id}
+		if history := runnerStatus.findTaskHistory(botType, id); history != nil {
+			status = history.snapshot()
+		}
+		status.Paused = pausedTasks.paused(botType, id)
+		tasks = append(tasks, status)
+	}
+	return tasks
+}
+
+// pauseRegistry holds the paused state of scheduled tasks.
+// The state is held by BotType and task identifier so it outlives the task's rebuild on configuration update.
+type pauseRegistry struct {
+	tasks map[BotType]map[string]struct{}
+	store TaskPauseStore
+	mutex sync.RWMutex
+}
+
+// setStore replaces the TaskPauseStore to store the state.
+func (r *pauseRegistry) setStore(store TaskPauseStore) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.store = store
+}
+
+// restore loads the paused tasks of the given BotType from the registered TaskPauseStore.
+func (r *pauseRegistry) restore(ctx context.Context, botType BotType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.store == nil {
+		return
+	}
+
+	ids, err := r.store.PausedTasks(ctx, botType)
+	if err != nil {
+		log.Errorf("Failed to restore paused tasks for %s: %+v", botType, err)
+		return
+	}
+
+	for _, id := range ids {
+		r.mark(botType, id, true)
+	}
+}
+
+func (r *pauseRegistry) set(ctx context.Context, botType BotType, taskID string, paused bool) error {
+	if triggerableTasks.find(botType, taskID) == nil {
+		return ErrTaskNotFound
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.store != nil {
+		err := r.store.SetPaused(ctx, botType, taskID, paused)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mark(botType, taskID, paused)
+	return nil
+}
+
+// mark updates the in-memory state.
+// The caller must hold the lock.
+func (r *pauseRegistry) mark(botType BotType, taskID string, paused bool) {
+	if !paused {
+		delete(r.tasks[botType], taskID)
+		return
+	}
+
+	if r.tasks == nil {
+		r.tasks = map[BotType]map[string]struct{}{}
+	}
+
+	tasks, ok := r.tasks[botType]
+	if !ok {
+		tasks = map[string]struct{}{}
+		r.tasks[botType] = tasks
+	}
+	tasks[taskID] = struct{}{}
+}
+
+func (r *pauseRegistry) paused(botType BotType, taskID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, ok := r.tasks[botType][taskID]
+	return ok
+}
@@ -0,0 +1,159 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type DummyTaskPauseStore struct {
+	PausedTasksFunc func(context.Context, BotType) ([]string, error)
+	SetPausedFunc   func(context.Context, BotType, string, bool) error
+}
+
+func (s *DummyTaskPauseStore) PausedTasks(ctx context.Context, botType BotType) ([]string, error) {
+	return s.PausedTasksFunc(ctx, botType)
+}
+
+func (s *DummyTaskPauseStore) SetPaused(ctx context.Context, botType BotType, taskID string, paused bool) error {
+	return s.SetPausedFunc(ctx, botType, taskID, paused)
+}
+
+func TestPauseTask(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		executed := 0
+		newTask := func() *scheduledTask {
+			return &scheduledTask{
+				identifier: "report",
+				taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+					executed++
+					return nil, nil
+				},
+			}
+		}
+		r := &runner{
+			config:   &Config{TaskHistorySize: 3},
+			alerters: &alerters{},
+		}
+		bot := &DummyBot{BotTypeValue: botType}
+
+		if err := PauseTask(botType, "report"); err != ErrTaskNotFound {
+			t.Errorf("Expected error is not returned for unregistered task: %#v.", err)
+		}
+
+		task := newTask()
+		triggerableTasks.add(botType, task.identifier, r.triggerScheduledTask(context.TODO(), bot, task), nil)
+
+		if err := PauseTask(botType, task.identifier); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		r.dispatchScheduledTask(context.TODO(), bot, task, time.Now())
+		if executed != 0 {
+			t.Errorf("Paused task should not be executed: %d.", executed)
+		}
+
+		// The task rebuilt on configuration update stays paused.
+		rebuilt := newTask()
+		triggerableTasks.add(botType, rebuilt.identifier, r.triggerScheduledTask(context.TODO(), bot, rebuilt), nil)
+		r.dispatchScheduledTask(context.TODO(), bot, rebuilt, time.Now())
+		if executed != 0 {
+			t.Errorf("Rebuilt task should not be executed: %d.", executed)
+		}
+
+		if err := ResumeTask(botType, task.identifier); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		r.dispatchScheduledTask(context.TODO(), bot, rebuilt, time.Now())
+		if executed != 1 {
+			t.Errorf("Resumed task should be executed: %d.", executed)
+		}
+	})
+}
+
+func TestPauseTask_WithStore(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		triggerableTasks.add(botType, "report", func(_ bool) ([]*ScheduledTaskResult, error) { return nil, nil }, nil)
+
+		var stored []bool
+		storeErr := errors.New("dummy")
+		failing := false
+		pausedTasks.setStore(&DummyTaskPauseStore{
+			SetPausedFunc: func(_ context.Context, _ BotType, _ string, paused bool) error {
+				if failing {
+					return storeErr
+				}
+				stored = append(stored, paused)
+				return nil
+			},
+		})
+
+		if err := PauseTask(botType, "report"); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if err := ResumeTask(botType, "report"); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(stored) != 2 || !stored[0] || stored[1] {
+			t.Errorf("Paused state is not stored: %#v.", stored)
+		}
+
+		failing = true
+		if err := PauseTask(botType, "report"); err != storeErr {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+		if pausedTasks.paused(botType, "report") {
+			t.Error("Task should not be paused when the state is not stored.")
+		}
+	})
+}
+
+func Test_pauseRegistry_restore(t *testing.T) {
+	registry := &pauseRegistry{}
+	registry.restore(context.TODO(), "dummy")
+
+	registry.setStore(&DummyTaskPauseStore{
+		PausedTasksFunc: func(_ context.Context, botType BotType) ([]string, error) {
+			return []string{"report"}, nil
+		},
+	})
+	registry.restore(context.TODO(), "dummy")
+
+	if !registry.paused("dummy", "report") {
+		t.Error("Stored state is not restored.")
+	}
+	if registry.paused("dummy", "other") {
+		t.Error("Unexpected task is paused.")
+	}
+}
+
+func TestListTasks(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		trigger := func(_ bool) ([]*ScheduledTaskResult, error) { return nil, nil }
+		triggerableTasks.add(botType, "b", trigger, nil)
+		triggerableTasks.add(botType, "a", trigger, nil)
+		runnerStatus.taskHistory(botType, "a", 1).skip()
+
+		if err := PauseTask(botType, "b"); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		tasks := ListTasks(botType)
+		if len(tasks) != 2 {
+			t.Fatalf("Unexpected number of tasks are returned: %d.", len(tasks))
+		}
+		if tasks[0].Identifier != "a" || tasks[0].Paused || tasks[0].Skipped != 1 {
+			t.Errorf("Unexpected status is returned: %#v.", tasks[0])
+		}
+		if tasks[1].Identifier != "b" || !tasks[1].Paused {
+			t.Errorf("Unexpected status is returned: %#v.", tasks[1])
+		}
+
+		if tasks := ListTasks("unknown"); len(tasks) != 0 {
+			t.Errorf("No task should be returned: %#v.", tasks)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package sarah
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v3/retry"
+	"time"
+)
+
+// RetryableTask is an optional interface that ScheduledTask implementation may satisfy to retry its failed execution.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the value given to ScheduledTaskPropsBuilder.RetryPolicy or RetriedConfig.
+//
+// The retrial must finish before the next scheduled execution, so the context passed to the retried execution is canceled when the next schedule comes.
+// A task without schedule, which runs only on events, retries for up to eventTaskRetryWindow.
+type RetryableTask interface {
+	// RetryPolicy returns the policy to retry the failed execution.
+	// Nil means no retrial.
+	RetryPolicy() *retry.Policy
+}
+
+func taskRetryPolicy(task ScheduledTask) *retry.Policy {
+	if retryable, ok := task.(RetryableTask); ok {
+		return retryable.RetryPolicy()
+	}
+	return nil
+}
+
+// eventTaskRetryWindow is the duration to retry the failed execution of a task that has no next scheduled execution.
+const eventTaskRetryWindow = 10 * time.Minute
+
+// nextScheduledRun returns the next execution time of the given task after now.
+// The schedule is interpreted in the task's time zone or defaultLocation.
+// Zero time is returned when the schedule cannot be parsed.
+func nextScheduledRun(task ScheduledTask, defaultLocation *time.Location, now time.Time) time.Time {
+	schedule, err := parseTaskSchedule(task, defaultLocation)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(now)
+}
+
+// executeTaskWithRetry executes the given task and retries in accordance with the task's retry policy.
+// Each trial runs with its own timeout when the task is TimeLimitedTask.
+// Retrial stops when ctx is canceled or when retryUntil comes, and the retried trial's context is canceled at retryUntil so the retrial does not overlap with the next schedule.
+// When retryUntil is zero, retrial stops after eventTaskRetryWindow.
+func executeTaskWithRetry(ctx context.Context, task ScheduledTask, retryUntil time.Time) ([]*ScheduledTaskResult, error) {
+	execute := func(taskCtx context.Context) ([]*ScheduledTaskResult, error) {
+		if timeout := taskTimeout(task); timeout > 0 {
+			var cancel context.CancelFunc
+			taskCtx, cancel = context.WithTimeout(taskCtx, timeout)
+			defer cancel()
+		}
+		return task.Execute(taskCtx)
+	}
+
+	policy := taskRetryPolicy(task)
+	if policy == nil || policy.Trial <= 1 {
+		return execute(ctx)
+	}
+
+	if retryUntil.IsZero() {
+		retryUntil = time.Now().Add(eventTaskRetryWindow)
+	}
+	retryCtx, cancel := context.WithDeadline(ctx, retryUntil)
+	defer cancel()
+
+	trial := 0
+	var results []*ScheduledTaskResult
+	err := retry.WithPolicyContext(retryCtx, policy, func() error {
+		trial++
+
+		// The first trial is the scheduled execution itself, so the deadline only applies to the retried ones.
+		trialCtx := ctx
+		if trial > 1 {
+			if err := retryCtx.Err(); err != nil {
+				// Do not start the retrial after the deadline.
+				return err
+			}
+			trialCtx = retryCtx
+		}
+
+		var e error
+		results, e = execute(trialCtx)
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
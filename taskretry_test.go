@@ -0,0 +1,185 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v3/retry"
+	"testing"
+	"time"
+)
+
+func Test_taskRetryPolicy(t *testing.T) {
+	if policy := taskRetryPolicy(&DummyScheduledTask{}); policy != nil {
+		t.Errorf("Nil should be returned by default: %#v.", policy)
+	}
+
+	expected := &retry.Policy{Trial: 3}
+	if policy := taskRetryPolicy(&scheduledTask{retryPolicy: expected}); policy != expected {
+		t.Errorf("Unexpected policy is returned: %#v.", policy)
+	}
+}
+
+func Test_nextScheduledRun(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	testSets := []struct {
+		schedule string
+		expected time.Time
+	}{
+		{schedule: "0 * * * *", expected: time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{schedule: "@every 1m", expected: time.Date(2020, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{schedule: "invalid", expected: time.Time{}},
+	}
+
+	for i, testSet := range testSets {
+		next := nextScheduledRun(&DummyScheduledTask{ScheduleValue: testSet.schedule}, time.UTC, now)
+		if !next.Equal(testSet.expected) {
+			t.Errorf("Unexpected time is returned on test %d: %s.", i, next)
+		}
+	}
+}
+
+func Test_executeTaskWithRetry(t *testing.T) {
+	testSets := []struct {
+		policy     *retry.Policy
+		retryUntil time.Time
+		failures   int
+		trials     int
+		hasErr     bool
+	}{
+		{
+			// No retry policy
+			policy:   nil,
+			failures: 1,
+			trials:   1,
+			hasErr:   true,
+		},
+		{
+			// Succeed on retrial
+			policy:   &retry.Policy{Trial: 3},
+			failures: 2,
+			trials:   3,
+			hasErr:   false,
+		},
+		{
+			// All trials fail
+			policy:   &retry.Policy{Trial: 2},
+			failures: 5,
+			trials:   2,
+			hasErr:   true,
+		},
+		{
+			// Retrial stops when the next schedule comes
+			policy:     &retry.Policy{Trial: 3, Interval: time.Minute},
+			retryUntil: time.Now().Add(10 * time.Millisecond),
+			failures:   5,
+			trials:     1,
+			hasErr:     true,
+		},
+	}
+
+	for i, testSet := range testSets {
+		trials := 0
+		task := &scheduledTask{
+			identifier:  "retried",
+			retryPolicy: testSet.policy,
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				trials++
+				if trials <= testSet.failures {
+					return nil, errors.New("dummy")
+				}
+				return []*ScheduledTaskResult{{Content: "ok"}}, nil
+			},
+		}
+
+		results, err := executeTaskWithRetry(context.TODO(), task, testSet.retryUntil)
+		if testSet.hasErr && err == nil {
+			t.Errorf("Expected error is not returned on test %d.", i)
+		}
+		if !testSet.hasErr && (err != nil || len(results) != 1) {
+			t.Errorf("Unexpected result is returned on test %d: %#v, %#v.", i, results, err)
+		}
+		if trials != testSet.trials {
+			t.Errorf("Unexpected number of trials on test %d: %d.", i, trials)
+		}
+	}
+}
+
+func Test_executeTaskWithRetry_WithCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	trials := 0
+	task := &scheduledTask{
+		identifier:  "retried",
+		retryPolicy: &retry.Policy{Trial: 3, Interval: time.Minute},
+		taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+			trials++
+			cancel()
+			return nil, errors.New("dummy")
+		},
+	}
+
+	_, err := executeTaskWithRetry(ctx, task, time.Time{})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+	if trials != 1 {
+		t.Errorf("Retrial should stop on context cancellation: %d.", trials)
+	}
+}
+
+func Test_executeTaskWithRetry_WithDeadline(t *testing.T) {
+	trials := 0
+	var deadlines []bool
+	task := &scheduledTask{
+		identifier:  "retried",
+		retryPolicy: &retry.Policy{Trial: 3},
+		taskFunc: func(ctx context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+			trials++
+			_, ok := ctx.Deadline()
+			deadlines = append(deadlines, ok)
+			if trials == 1 {
+				return nil, errors.New("dummy")
+			}
+
+			// The retried trial is canceled when the next schedule comes.
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	_, err := executeTaskWithRetry(context.TODO(), task, time.Now().Add(20*time.Millisecond))
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+	if trials != 2 {
+		t.Errorf("Retrial should not start after the deadline: %d.", trials)
+	}
+	if len(deadlines) != 2 || deadlines[0] || !deadlines[1] {
+		t.Errorf("Deadline should be applied only to the retried trial: %#v.", deadlines)
+	}
+}
+
+func Test_executeTaskWithRetry_WithoutSchedule(t *testing.T) {
+	var deadline time.Time
+	trials := 0
+	task := &scheduledTask{
+		identifier:  "event",
+		retryPolicy: &retry.Policy{Trial: 2},
+		taskFunc: func(ctx context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+			trials++
+			if trials == 1 {
+				return nil, errors.New("dummy")
+			}
+			deadline, _ = ctx.Deadline()
+			return nil, nil
+		},
+	}
+
+	startedAt := time.Now()
+	_, err := executeTaskWithRetry(context.TODO(), task, time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if deadline.Before(startedAt.Add(eventTaskRetryWindow)) || deadline.After(time.Now().Add(eventTaskRetryWindow)) {
+		t.Errorf("Retrial of the task without schedule should be limited: %s.", deadline)
+	}
+}
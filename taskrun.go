@@ -0,0 +1,206 @@
+package sarah
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"time"
+)
+
+// TaskRunStore defines an interface that stores the last successfully executed occurrence of each scheduled task.
+// When a TaskRunStore is registered via RegisterTaskRunStore, go-sarah's core records the scheduled time of every successful execution fired by the schedule or by catch-up,
+// and compares the stored time with the task's schedule on Bot start and on ResumeTask to catch up the missed executions.
+// Failed executions are not recorded, so the scheduled executions after the last successful one are considered missed.
+// The executions triggered via TriggerTask or by events are not recorded either, so they do not prevent the missed occurrences from being caught up.
+// How the missed executions are caught up is defined by each task's CatchUpPolicy.
+//
+// Each catch-up execution acquires the lock of its missed occurrence via TaskLocker in the same way as the scheduled execution,
+// so replicas sharing a store do not catch up the same occurrence twice.
+// The storages package provides a file-based implementation.
+type TaskRunStore interface {
+	// LastRun returns the last successful execution time of the given task.
+	// Zero time is returned without an error when no execution is recorded.
+	LastRun(ctx context.Context, botType BotType, taskID string) (time.Time, error)
+
+	// SetLastRun stores the last successful execution time of the given task.
+	SetLastRun(ctx context.Context, botType BotType, taskID string, ranAt time.Time) error
+}
+
+// CatchUpPolicy defines how the executions missed during downtime or pause are caught up on Bot start and on ResumeTask.
+// This is referred only when TaskRunStore is registered.
+type CatchUpPolicy int
+
+const (
+	// CatchUpNone does not execute the missed executions.
+	// This is the default policy.
+	CatchUpNone CatchUpPolicy = iota
+
+	// CatchUpOnce executes the task once for the latest missed execution when one or more executions are missed.
+	CatchUpOnce
+
+	// CatchUpAll executes the task as many times as the missed executions up to CatchUpTask.CatchUpLimit.
+	// When more executions are missed, the latest ones are caught up.
+	CatchUpAll
+)
+
+// String returns the stringified form of the policy.
+func (p CatchUpPolicy) String() string {
+	switch p {
+	case CatchUpNone:
+		return "none"
+
+	case CatchUpOnce:
+		return "once"
+
+	case CatchUpAll:
+		return "all"
+
+	default:
+		return "unknown"
+
+	}
+}
+
+// defaultCatchUpLimit is the maximum number of catch-up executions with CatchUpAll when no limit is given.
+const defaultCatchUpLimit = 10
+
+// CatchUpTask is an optional interface that ScheduledTask implementation may satisfy to catch up the executions missed during downtime or pause.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the values given to ScheduledTaskPropsBuilder.CatchUp and CatchUpLimit.
+type CatchUpTask interface {
+	// CatchUpPolicy returns how the missed executions are caught up.
+	CatchUpPolicy() CatchUpPolicy
+
+	// CatchUpLimit returns the maximum number of catch-up executions with CatchUpAll.
+	// Zero means the default limit.
+	CatchUpLimit() int
+}
+
+// taskCatchUp returns the catch-up policy and the maximum number of catch-up executions for the given task.
+func taskCatchUp(task ScheduledTask) (CatchUpPolicy, int) {
+	catchUp, ok := task.(CatchUpTask)
+	if !ok {
+		return CatchUpNone, 0
+	}
+
+	switch catchUp.CatchUpPolicy() {
+	case CatchUpOnce:
+		return CatchUpOnce, 1
+
+	case CatchUpAll:
+		limit := catchUp.CatchUpLimit()
+		if limit <= 0 {
+			limit = defaultCatchUpLimit
+		}
+		return CatchUpAll, limit
+
+	default:
+		return CatchUpNone, 0
+
+	}
+}
+
+// missedScheduledRuns returns the task's scheduled times after lastRun and until now in chronological order.
+// The schedule is interpreted in the task's time zone or defaultLocation.
+// When more times are found than the given limit, the latest ones are returned.
+func missedScheduledRuns(task ScheduledTask, defaultLocation *time.Location, lastRun time.Time, now time.Time, limit int) []time.Time {
+	schedule, err := parseTaskSchedule(task, defaultLocation)
+	if err != nil || limit <= 0 {
+		return nil
+	}
+
+	var missed []time.Time
+	for next := schedule.Next(lastRun); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		missed = append(missed, next)
+		if len(missed) > limit {
+			missed = missed[1:]
+		}
+	}
+	return missed
+}
+
+// catchUpScheduledTask executes the given task as many times as the task's CatchUpPolicy requires.
+// Each execution acquires the lock of the missed occurrence via TaskLocker.
+// When no execution is recorded for the task yet, the current time is stored so the following downtime can be detected.
+// A task triggered only by events has no schedule to catch up.
+func (r *runner) catchUpScheduledTask(ctx context.Context, bot Bot, task ScheduledTask) {
+	if r.taskRunStore == nil || task.Schedule() == "" {
+		return
+	}
+
+	lastRun, err := r.taskRunStore.LastRun(ctx, bot.BotType(), task.Identifier())
+	if err != nil {
+		log.Errorf("Failed to get last run of scheduled task %s: %+v", task.Identifier(), err)
+		return
+	}
+
+	now := time.Now()
+	if lastRun.IsZero() {
+		err = r.taskRunStore.SetLastRun(ctx, bot.BotType(), task.Identifier(), now)
+		if err != nil {
+			log.Errorf("Failed to store last run of scheduled task %s: %+v", task.Identifier(), err)
+		}
+		return
+	}
+
+	policy, limit := taskCatchUp(task)
+	if policy == CatchUpNone || pausedTasks.paused(bot.BotType(), task.Identifier()) {
+		return
+	}
+
+	missed := missedScheduledRuns(task, r.botLocation(bot.BotType()), lastRun, now, limit)
+	if len(missed) == 0 {
+		return
+	}
+
+	log.Infof("Catching up %d missed execution(s) of scheduled task %s with policy %s.", len(missed), task.Identifier(), policy)
+	catchUp := func() {
+		for _, scheduledAt := range missed {
+			if ctx.Err() != nil {
+				return
+			}
+			_, _ = r.runLockedScheduledTask(ctx, bot, task, scheduledAt)
+		}
+	}
+
+	overlapPolicy := taskOverlapPolicy(task)
+	if overlapPolicy == OverlapAllow {
+		catchUp()
+		return
+	}
+
+	// Share the guard with the scheduled executions so catch-up does not overlap with them.
+	executed := r.taskGuards.get(bot.BotType(), task.Identifier()).run(overlapPolicy, catchUp)
+	if !executed {
+		log.Infof("Skipping catch-up of scheduled task %s since another execution is running.", task.Identifier())
+	}
+}
+
+// catchUpOnResume returns a function that catches up the executions of the given task missed while the task is paused.
+func (r *runner) catchUpOnResume(botCtx context.Context, bot Bot, task ScheduledTask) func() {
+	return func() {
+		if botCtx.Err() != nil {
+			return
+		}
+		r.catchUpScheduledTask(botCtx, bot, task)
+	}
+}
+
+// recordLastRun stores the scheduled time of the given task's executed occurrence when TaskRunStore is registered.
+// The stored time is not rewound when an older occurrence finishes later than a newer one.
+func (r *runner) recordLastRun(ctx context.Context, bot Bot, task ScheduledTask, scheduledAt time.Time) {
+	if r.taskRunStore == nil {
+		return
+	}
+
+	lastRun, err := r.taskRunStore.LastRun(ctx, bot.BotType(), task.Identifier())
+	if err != nil {
+		log.Errorf("Failed to get last run of scheduled task %s: %+v", task.Identifier(), err)
+		return
+	} else if !scheduledAt.After(lastRun) {
+		return
+	}
+
+	err = r.taskRunStore.SetLastRun(ctx, bot.BotType(), task.Identifier(), scheduledAt)
+	if err != nil {
+		log.Errorf("Failed to store last run of scheduled task %s: %+v", task.Identifier(), err)
+	}
+}
@@ -0,0 +1,355 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type DummyTaskRunStore struct {
+	LastRunFunc    func(context.Context, BotType, string) (time.Time, error)
+	SetLastRunFunc func(context.Context, BotType, string, time.Time) error
+}
+
+func (s *DummyTaskRunStore) LastRun(ctx context.Context, botType BotType, taskID string) (time.Time, error) {
+	return s.LastRunFunc(ctx, botType, taskID)
+}
+
+func (s *DummyTaskRunStore) SetLastRun(ctx context.Context, botType BotType, taskID string, ranAt time.Time) error {
+	return s.SetLastRunFunc(ctx, botType, taskID, ranAt)
+}
+
+func TestCatchUpPolicy_String(t *testing.T) {
+	testSets := []struct {
+		policy   CatchUpPolicy
+		expected string
+	}{
+		{policy: CatchUpNone, expected: "none"},
+		{policy: CatchUpOnce, expected: "once"},
+		{policy: CatchUpAll, expected: "all"},
+		{policy: CatchUpPolicy(100), expected: "unknown"},
+	}
+
+	for _, testSet := range testSets {
+		if testSet.policy.String() != testSet.expected {
+			t.Errorf("Unexpected string is returned: %s.", testSet.policy.String())
+		}
+	}
+}
+
+func Test_taskCatchUp(t *testing.T) {
+	testSets := []struct {
+		task   ScheduledTask
+		policy CatchUpPolicy
+		limit  int
+	}{
+		{task: &DummyScheduledTask{}, policy: CatchUpNone, limit: 0},
+		{task: &scheduledTask{catchUpPolicy: CatchUpNone, catchUpLimit: 5}, policy: CatchUpNone, limit: 0},
+		{task: &scheduledTask{catchUpPolicy: CatchUpOnce, catchUpLimit: 5}, policy: CatchUpOnce, limit: 1},
+		{task: &scheduledTask{catchUpPolicy: CatchUpAll, catchUpLimit: 5}, policy: CatchUpAll, limit: 5},
+		{task: &scheduledTask{catchUpPolicy: CatchUpAll}, policy: CatchUpAll, limit: defaultCatchUpLimit},
+	}
+
+	for i, testSet := range testSets {
+		policy, limit := taskCatchUp(testSet.task)
+		if policy != testSet.policy {
+			t.Errorf("Unexpected policy is returned on test %d: %s.", i, policy)
+		}
+		if limit != testSet.limit {
+			t.Errorf("Unexpected limit is returned on test %d: %d.", i, limit)
+		}
+	}
+}
+
+func Test_missedScheduledRuns(t *testing.T) {
+	lastRun := time.Date(2020, 1, 1, 9, 0, 1, 0, time.UTC)
+	testSets := []struct {
+		schedule string
+		now      time.Time
+		limit    int
+		expected []int
+	}{
+		{schedule: "0 9 * * *", now: time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC), limit: 10, expected: nil},
+		{schedule: "0 9 * * *", now: time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC), limit: 10, expected: []int{2}},
+		{schedule: "0 9 * * *", now: time.Date(2020, 1, 4, 10, 0, 0, 0, time.UTC), limit: 10, expected: []int{2, 3, 4}},
+		{schedule: "0 9 * * *", now: time.Date(2020, 1, 4, 10, 0, 0, 0, time.UTC), limit: 2, expected: []int{3, 4}},
+		{schedule: "invalid", now: time.Date(2020, 1, 4, 10, 0, 0, 0, time.UTC), limit: 10, expected: nil},
+	}
+
+	for i, testSet := range testSets {
+		task := &DummyScheduledTask{ScheduleValue: testSet.schedule}
+		missed := missedScheduledRuns(task, time.UTC, lastRun, testSet.now, testSet.limit)
+		if len(missed) != len(testSet.expected) {
+			t.Errorf("Unexpected number is returned on test %d: %d.", i, len(missed))
+			continue
+		}
+		for j, day := range testSet.expected {
+			if !missed[j].Equal(time.Date(2020, 1, day, 9, 0, 0, 0, time.UTC)) {
+				t.Errorf("Unexpected time is returned on test %d: %s.", i, missed[j])
+			}
+		}
+	}
+}
+
+func Test_runner_catchUpScheduledTask(t *testing.T) {
+	now := time.Now()
+	testSets := []struct {
+		lastRun  time.Time
+		policy   CatchUpPolicy
+		limit    int
+		executed int
+		stored   bool
+	}{
+		{
+			// Nothing is recorded yet, so the current time is stored as a baseline.
+			lastRun:  time.Time{},
+			policy:   CatchUpAll,
+			executed: 0,
+			stored:   true,
+		},
+		{
+			lastRun:  now.Add(-3*time.Hour - time.Minute),
+			policy:   CatchUpNone,
+			executed: 0,
+			stored:   false,
+		},
+		{
+			lastRun:  now.Add(-3*time.Hour - time.Minute),
+			policy:   CatchUpOnce,
+			executed: 1,
+			stored:   true,
+		},
+		{
+			lastRun:  now.Add(-3*time.Hour - time.Minute),
+			policy:   CatchUpAll,
+			executed: 3,
+			stored:   true,
+		},
+		{
+			lastRun:  now.Add(-3*time.Hour - time.Minute),
+			policy:   CatchUpAll,
+			limit:    2,
+			executed: 2,
+			stored:   true,
+		},
+	}
+
+	for i, testSet := range testSets {
+		SetupAndRun(func() {
+			executed := 0
+			task := &scheduledTask{
+				identifier:    "report",
+				schedule:      "@hourly",
+				catchUpPolicy: testSet.policy,
+				catchUpLimit:  testSet.limit,
+				taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+					executed++
+					return nil, nil
+				},
+			}
+			stored := false
+			r := &runner{
+				config:   &Config{TaskHistorySize: 3},
+				alerters: &alerters{},
+				taskRunStore: &DummyTaskRunStore{
+					LastRunFunc: func(_ context.Context, _ BotType, _ string) (time.Time, error) {
+						return testSet.lastRun, nil
+					},
+					SetLastRunFunc: func(_ context.Context, _ BotType, _ string, _ time.Time) error {
+						stored = true
+						return nil
+					},
+				},
+			}
+
+			r.catchUpScheduledTask(context.TODO(), &DummyBot{BotTypeValue: "dummy"}, task)
+
+			if executed != testSet.executed {
+				t.Errorf("Unexpected number of executions on test %d: %d.", i, executed)
+			}
+			if stored != testSet.stored {
+				t.Errorf("Unexpected storing state on test %d: %t.", i, stored)
+			}
+		})
+	}
+}
+
+func Test_runner_catchUpScheduledTask_Locked(t *testing.T) {
+	SetupAndRun(func() {
+		lastRun := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+		executed := 0
+		task := &scheduledTask{
+			identifier:    "report",
+			schedule:      "@hourly",
+			catchUpPolicy: CatchUpAll,
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				executed++
+				return nil, nil
+			},
+		}
+		locker := NewMemoryTaskLocker()
+		r := &runner{
+			config:     &Config{TaskHistorySize: 3, TaskLockTTL: time.Hour},
+			alerters:   &alerters{},
+			taskLocker: locker,
+			taskRunStore: &DummyTaskRunStore{
+				LastRunFunc: func(_ context.Context, _ BotType, _ string) (time.Time, error) {
+					return lastRun, nil
+				},
+				SetLastRunFunc: func(_ context.Context, _ BotType, _ string, _ time.Time) error {
+					return nil
+				},
+			},
+		}
+
+		// Another replica already caught up the first missed occurrence.
+		firstMissed := lastRun.Add(time.Hour)
+		_, _ = locker.TryLock(context.TODO(), occurrenceLockKey(TaskLockKey("dummy", "report"), firstMissed), time.Hour)
+
+		r.catchUpScheduledTask(context.TODO(), &DummyBot{BotTypeValue: "dummy"}, task)
+
+		if executed != 1 {
+			t.Errorf("Only the occurrence without the lock should be caught up: %d.", executed)
+		}
+	})
+}
+
+func Test_runner_catchUpOnResume(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		executed := 0
+		task := &scheduledTask{
+			identifier:    "report",
+			schedule:      "@hourly",
+			catchUpPolicy: CatchUpOnce,
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				executed++
+				return nil, nil
+			},
+		}
+		r := &runner{
+			config:   &Config{TaskHistorySize: 3},
+			alerters: &alerters{},
+			taskRunStore: &DummyTaskRunStore{
+				LastRunFunc: func(_ context.Context, _ BotType, _ string) (time.Time, error) {
+					return time.Now().Add(-3 * time.Hour), nil
+				},
+				SetLastRunFunc: func(_ context.Context, _ BotType, _ string, _ time.Time) error {
+					return nil
+				},
+			},
+		}
+		bot := &DummyBot{BotTypeValue: botType}
+		caughtUp := make(chan struct{}, 10)
+		catchUp := r.catchUpOnResume(context.TODO(), bot, task)
+		triggerableTasks.add(botType, task.identifier, r.triggerScheduledTask(context.TODO(), bot, task), func() {
+			catchUp()
+			caughtUp <- struct{}{}
+		})
+
+		// Resuming the task that is not paused does not catch up.
+		_ = ResumeTask(botType, task.identifier)
+		_ = PauseTask(botType, task.identifier)
+		_ = ResumeTask(botType, task.identifier)
+
+		select {
+		case <-caughtUp:
+			// O.K.
+
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("Missed execution is not caught up on resume.")
+
+		}
+
+		select {
+		case <-caughtUp:
+			t.Error("Missed execution should be caught up only once.")
+
+		case <-time.After(10 * time.Millisecond):
+			// O.K.
+
+		}
+
+		if executed != 1 {
+			t.Errorf("Unexpected number of executions: %d.", executed)
+		}
+	})
+}
+
+func Test_runner_catchUpScheduledTask_WithError(t *testing.T) {
+	executed := false
+	task := &scheduledTask{
+		identifier:    "report",
+		schedule:      "@hourly",
+		catchUpPolicy: CatchUpAll,
+		taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+			executed = true
+			return nil, nil
+		},
+	}
+	r := &runner{
+		config: &Config{TaskHistorySize: 3},
+		taskRunStore: &DummyTaskRunStore{
+			LastRunFunc: func(_ context.Context, _ BotType, _ string) (time.Time, error) {
+				return time.Time{}, errors.New("dummy")
+			},
+		},
+	}
+
+	r.catchUpScheduledTask(context.TODO(), &DummyBot{BotTypeValue: "dummy"}, task)
+
+	if executed {
+		t.Error("Task should not be executed when the last run is unknown.")
+	}
+}
+
+func Test_runner_runScheduledTask_RecordLastRun(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		var lastRun time.Time
+		stored := 0
+		r := &runner{
+			config:   &Config{TaskHistorySize: 3},
+			alerters: &alerters{},
+			taskRunStore: &DummyTaskRunStore{
+				LastRunFunc: func(_ context.Context, _ BotType, _ string) (time.Time, error) {
+					return lastRun, nil
+				},
+				SetLastRunFunc: func(_ context.Context, _ BotType, _ string, ranAt time.Time) error {
+					stored++
+					lastRun = ranAt
+					return nil
+				},
+			},
+		}
+		task := &scheduledTask{
+			identifier: "report",
+			schedule:   "@hourly",
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				return nil, nil
+			},
+		}
+		bot := &DummyBot{BotTypeValue: botType}
+		occurrence := time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC)
+
+		// The scheduled occurrence is stored instead of the start time.
+		_, _ = r.runScheduledTask(context.TODO(), bot, task, occurrence)
+		if !lastRun.Equal(occurrence) {
+			t.Fatalf("Scheduled occurrence is not stored: %s.", lastRun)
+		}
+
+		// Neither manual nor event-triggered executions are stored.
+		_, _ = r.runScheduledTask(withTriggeredRun(context.TODO()), bot, task, occurrence.Add(time.Hour))
+		_, _ = r.runScheduledTask(WithEvent(context.TODO(), &Event{Name: "dummy"}), bot, task, time.Time{})
+		if stored != 1 {
+			t.Errorf("Execution out of the schedule should not be stored: %d.", stored)
+		}
+
+		// An older occurrence does not rewind the stored time.
+		_, _ = r.runScheduledTask(context.TODO(), bot, task, occurrence.Add(-time.Hour))
+		if !lastRun.Equal(occurrence) {
+			t.Errorf("Stored time is rewound: %s.", lastRun)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package sarah
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"strings"
+	"time"
+)
+
+// ZonedTask is an optional interface that ScheduledTask implementation may satisfy to interpret its schedule in a specific time zone.
+// ScheduledTask built by ScheduledTaskPropsBuilder satisfies this with the value given to ScheduledTaskPropsBuilder.TimeZone or ZonedConfig.
+//
+// The time zone is applied in the following order of priority:
+//
+//  1. CRON_TZ= or TZ= prefix of the schedule such as "CRON_TZ=Asia/Tokyo 0 9 * * *"
+//  2. ZonedTask.Location
+//  3. Config.BotTimeZones for the Bot's BotType
+//  4. Config.TimeZone
+type ZonedTask interface {
+	// Location returns the time zone to interpret the schedule.
+	// Nil means the default time zone for the Bot.
+	Location() *time.Location
+}
+
+// loadTaskLocation returns the time zone for the given name.
+// Nil is returned for an empty name so the Bot's default time zone is used.
+func loadTaskLocation(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf(`given timezone "%s" cannot be converted to time.Location: %w`, timeZone, err)
+	}
+	return loc, nil
+}
+
+// hasScheduleTimeZone tells if the given schedule has its own time zone prefix.
+func hasScheduleTimeZone(schedule string) bool {
+	return strings.HasPrefix(schedule, "CRON_TZ=") || strings.HasPrefix(schedule, "TZ=")
+}
+
+// taskLocation returns the time zone to interpret the given task's schedule.
+// defaultLocation is returned unless the task has its own time zone.
+func taskLocation(task ScheduledTask, defaultLocation *time.Location) *time.Location {
+	if zoned, ok := task.(ZonedTask); ok {
+		if loc := zoned.Location(); loc != nil {
+			return loc
+		}
+	}
+	return defaultLocation
+}
+
+// taskScheduleSpec returns the given task's schedule with the time zone prefix so the schedule is interpreted in the task's time zone.
+// The schedule is returned as-is when it already has its own time zone prefix.
+func taskScheduleSpec(task ScheduledTask, defaultLocation *time.Location) string {
+	schedule := task.Schedule()
+	if hasScheduleTimeZone(schedule) {
+		return schedule
+	}
+
+	loc := taskLocation(task, defaultLocation)
+	if loc == nil {
+		return schedule
+	}
+	return fmt.Sprintf("CRON_TZ=%s %s", loc.String(), schedule)
+}
+
+// parseTaskSchedule parses the given task's schedule in the task's time zone.
+func parseTaskSchedule(task ScheduledTask, defaultLocation *time.Location) (cron.Schedule, error) {
+	return cron.ParseStandard(taskScheduleSpec(task, defaultLocation))
+}
+
+// botLocation returns the default time zone for the given BotType's scheduled tasks.
+func (r *runner) botLocation(botType BotType) *time.Location {
+	if loc, ok := r.botLocations[botType]; ok {
+		return loc
+	}
+	return r.location
+}
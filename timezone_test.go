@@ -0,0 +1,127 @@
+package sarah
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_loadTaskLocation(t *testing.T) {
+	loc, err := loadTaskLocation("")
+	if err != nil || loc != nil {
+		t.Errorf("Nil should be returned for empty time zone: %#v, %#v.", loc, err)
+	}
+
+	loc, err = loadTaskLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if loc.String() != "Asia/Tokyo" {
+		t.Errorf("Unexpected location is returned: %s.", loc)
+	}
+
+	_, err = loadTaskLocation("Invalid/Zone")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func Test_taskScheduleSpec(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	testSets := []struct {
+		task            ScheduledTask
+		defaultLocation *time.Location
+		expected        string
+	}{
+		{
+			task:            &DummyScheduledTask{ScheduleValue: "0 9 * * *"},
+			defaultLocation: nil,
+			expected:        "0 9 * * *",
+		},
+		{
+			task:            &DummyScheduledTask{ScheduleValue: "0 9 * * *"},
+			defaultLocation: berlin,
+			expected:        "CRON_TZ=Europe/Berlin 0 9 * * *",
+		},
+		{
+			task:            &scheduledTask{schedule: "0 9 * * *", location: tokyo},
+			defaultLocation: berlin,
+			expected:        "CRON_TZ=Asia/Tokyo 0 9 * * *",
+		},
+		{
+			task:            &scheduledTask{schedule: "CRON_TZ=UTC 0 9 * * *", location: tokyo},
+			defaultLocation: berlin,
+			expected:        "CRON_TZ=UTC 0 9 * * *",
+		},
+		{
+			task:            &scheduledTask{schedule: "TZ=UTC 0 9 * * *"},
+			defaultLocation: berlin,
+			expected:        "TZ=UTC 0 9 * * *",
+		},
+	}
+
+	for i, testSet := range testSets {
+		spec := taskScheduleSpec(testSet.task, testSet.defaultLocation)
+		if spec != testSet.expected {
+			t.Errorf("Unexpected spec is returned on test %d: %s.", i, spec)
+		}
+	}
+}
+
+func Test_parseTaskSchedule(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	task := &scheduledTask{schedule: "0 9 * * *", location: tokyo}
+
+	schedule, err := parseTaskSchedule(task, time.UTC)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// 9 AM in Tokyo is 0 AM in UTC.
+	next := schedule.Next(time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC))
+	if !next.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next time is returned: %s.", next)
+	}
+}
+
+func Test_runner_botLocation(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	r := &runner{
+		location: time.UTC,
+		botLocations: map[BotType]*time.Location{
+			"tokyo": tokyo,
+		},
+	}
+
+	if loc := r.botLocation("tokyo"); loc != tokyo {
+		t.Errorf("Location for the BotType is not returned: %s.", loc)
+	}
+
+	if loc := r.botLocation("other"); loc != time.UTC {
+		t.Errorf("Default location is not returned: %s.", loc)
+	}
+}
+
+func Test_taskHistory_snapshot_WithSchedule(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	task := &scheduledTask{schedule: "@hourly", location: tokyo}
+	schedule, _ := parseTaskSchedule(task, time.UTC)
+
+	history := newTaskHistory(task.identifier, 1)
+	if !history.snapshot().NextRun.IsZero() {
+		t.Error("NextRun should be zero when the task is not scheduled.")
+	}
+
+	history.setSchedule(schedule, tokyo)
+	next := history.snapshot().NextRun
+	if next.IsZero() || next.Location().String() != "Asia/Tokyo" {
+		t.Errorf("NextRun should be returned in the task's time zone: %s.", next)
+	}
+
+	prefixed, _ := parseTaskSchedule(&scheduledTask{schedule: "CRON_TZ=Europe/Berlin 0 9 * * *"}, time.UTC)
+	history.setSchedule(prefixed, time.UTC)
+	next = history.snapshot().NextRun
+	if next.Location().String() != "Europe/Berlin" {
+		t.Errorf("NextRun should be returned in the time zone of CRON_TZ: %s.", next)
+	}
+}
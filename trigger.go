@@ -0,0 +1,229 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrTaskNotFound is returned by TriggerTask, PauseTask and ResumeTask when no scheduled task is registered with the given BotType and identifier.
+var ErrTaskNotFound = errors.New("scheduled task is not found")
+
+// ErrTaskRunning is returned by TriggerTask when the task's OverlapPolicy skips the execution since the previous one is still running.
+var ErrTaskRunning = errors.New("previous execution of scheduled task is still running")
+
+// ErrTaskLocked is returned by TriggerTask when the lock of the execution is held by another process via TaskLocker.
+var ErrTaskLocked = errors.New("scheduled task is locked by another process")
+
+var triggerableTasks = &taskRegistry{}
+
+// TriggerOption defines a function signature that TriggerTask's functional option must satisfy.
+type TriggerOption func(*triggerOptions)
+
+type triggerOptions struct {
+	dryRun bool
+}
+
+// TriggerWithDryRun tells TriggerTask to return the execution results without sending them.
+// The execution is not recorded to the task history either.
+func TriggerWithDryRun() TriggerOption {
+	return func(o *triggerOptions) {
+		o.dryRun = true
+	}
+}
+
+// TriggerTask executes the registered scheduled task immediately regardless of its schedule.
+// The task runs in the same way as the scheduled execution: the configuration is locked during the execution,
+// the results are sent to the destination or to the default destination, and the execution is recorded to the task history.
+// The execution is also guarded by the task's OverlapPolicy and TaskLocker.
+// Since the execution does not run any scheduled occurrence, it is not stored as the last run via TaskRunStore.
+// The returned results have their destinations filled with the default destination if necessary.
+//
+// Pass TriggerWithDryRun to receive the results without sending them.
+// ErrTaskNotFound is returned when the task is not registered or the Bot is not running.
+// ErrTaskRunning is returned when the task's OverlapPolicy is not OverlapAllow and the previous execution is still running,
+// and ErrTaskLocked is returned when another process holds the lock of the execution.
+func TriggerTask(botType BotType, taskID string, options ...TriggerOption) ([]*ScheduledTaskResult, error) {
+	opts := &triggerOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	trigger := triggerableTasks.find(botType, taskID)
+	if trigger == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	return trigger(opts.dryRun)
+}
+
+type triggeredRunKey struct{}
+
+// withTriggeredRun marks the given context as the one for the execution triggered via TriggerTask.
+func withTriggeredRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, triggeredRunKey{}, true)
+}
+
+// isTriggeredRun tells if the given context is for the execution triggered via TriggerTask.
+func isTriggeredRun(ctx context.Context) bool {
+	triggered, _ := ctx.Value(triggeredRunKey{}).(bool)
+	return triggered
+}
+
+// taskRegistry holds the scheduled tasks that are currently registered to the running Bots.
+type taskRegistry struct {
+	tasks map[BotType]map[string]*registeredTask
+	mutex sync.RWMutex
+}
+
+// registeredTask holds the functions to execute a registered scheduled task out of its schedule.
+type registeredTask struct {
+	// trigger executes the task on demand via TriggerTask.
+	trigger func(bool) ([]*ScheduledTaskResult, error)
+
+	// catchUp executes the missed executions on ResumeTask.
+	catchUp func()
+}
+
+func (r *taskRegistry) add(botType BotType, taskID string, trigger func(bool) ([]*ScheduledTaskResult, error), catchUp func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.tasks == nil {
+		r.tasks = map[BotType]map[string]*registeredTask{}
+	}
+
+	tasks, ok := r.tasks[botType]
+	if !ok {
+		tasks = map[string]*registeredTask{}
+		r.tasks[botType] = tasks
+	}
+	tasks[taskID] = &registeredTask{
+		trigger: trigger,
+		catchUp: catchUp,
+	}
+}
+
+func (r *taskRegistry) remove(botType BotType, taskID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.tasks[botType], taskID)
+}
+
+func (r *taskRegistry) removeBot(botType BotType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.tasks, botType)
+}
+
+func (r *taskRegistry) find(botType BotType, taskID string) func(bool) ([]*ScheduledTaskResult, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	task, ok := r.tasks[botType][taskID]
+	if !ok {
+		return nil
+	}
+	return task.trigger
+}
+
+func (r *taskRegistry) findCatchUp(botType BotType, taskID string) func() {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	task, ok := r.tasks[botType][taskID]
+	if !ok {
+		return nil
+	}
+	return task.catchUp
+}
+
+// identifiers returns the identifiers of the scheduled tasks registered to the given BotType.
+func (r *taskRegistry) identifiers(botType BotType) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var ids []string
+	for id := range r.tasks[botType] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+var triggerTaskPattern = regexp.MustCompile(`^\.task\s+(run|dryrun)\s+(\S+)\s*$`)
+
+// NewTriggerTaskCommandProps creates and returns CommandProps of an administrative command that triggers a scheduled task on demand.
+// ".task run {taskID}" executes the task and sends the results as usual,
+// while ".task dryrun {taskID}" replies with the results without sending them to their destinations.
+//
+// Since this command affects the bot's behavior, authorize must judge if the sender is allowed to use this command.
+// The command and its instruction are hidden from any user that authorize rejects.
+// Once the command is registered to the Bot, the authorized sender's input jumps the queue of ordinary messages regardless of the input classifier.
+//
+//  props, err := sarah.NewTriggerTaskCommandProps(slack.SLACK, func(input sarah.Input) bool {
+//    return input.SenderKey() == adminKey
+//  })
+//  sarah.RegisterCommandProps(props)
+func NewTriggerTaskCommandProps(botType BotType, authorize func(Input) bool) (*CommandProps, error) {
+	if authorize == nil {
+		return nil, ErrCommandInsufficientArgument
+	}
+
+	match := func(input Input) bool {
+		return triggerTaskPattern.MatchString(strings.TrimSpace(input.Message())) && authorize(input)
+	}
+	props, err := NewCommandPropsBuilder().
+		BotType(botType).
+		Identifier("trigger_task").
+		MatchFunc(match).
+		InstructionFunc(func(input *HelpInput) string {
+			if !authorize(input) {
+				return ""
+			}
+			return "Input .task run {task id} to execute a scheduled task, or .task dryrun {task id} to see the results without sending them."
+		}).
+		Func(func(_ context.Context, input Input) (*CommandResponse, error) {
+			submatches := triggerTaskPattern.FindStringSubmatch(strings.TrimSpace(input.Message()))
+			dryRun := submatches[1] == "dryrun"
+			taskID := submatches[2]
+
+			var options []TriggerOption
+			if dryRun {
+				options = append(options, TriggerWithDryRun())
+			}
+
+			results, err := TriggerTask(botType, taskID, options...)
+			if err != nil {
+				return &CommandResponse{
+					Content: fmt.Sprintf("Failed to execute %s: %s", taskID, err.Error()),
+				}, nil
+			}
+
+			if !dryRun {
+				return &CommandResponse{
+					Content: fmt.Sprintf("Executed %s and sent %d result(s).", taskID, len(results)),
+				}, nil
+			}
+
+			lines := []string{fmt.Sprintf("Executed %s in dry-run mode and got %d result(s).", taskID, len(results))}
+			for _, res := range results {
+				lines = append(lines, fmt.Sprintf("%v: %v", res.Destination, res.Content))
+			}
+			return &CommandResponse{
+				Content: strings.Join(lines, "\n"),
+			}, nil
+		}).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// Let the authorized sender's input jump the queue of ordinary messages once this command is registered to the Bot.
+	props.priorityFunc = match
+	return props, nil
+}
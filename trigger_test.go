@@ -0,0 +1,242 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTriggerTask(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		var sent []Output
+		bot := &DummyBot{
+			BotTypeValue: botType,
+			SendMessageFunc: func(_ context.Context, output Output) {
+				sent = append(sent, output)
+			},
+		}
+		task := &DummyScheduledTask{
+			IdentifierValue:         "report",
+			DefaultDestinationValue: "#general",
+			ExecuteFunc: func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				return []*ScheduledTaskResult{
+					{Content: "default"},
+					{Content: "given", Destination: "#random"},
+				}, nil
+			},
+		}
+		r := &runner{
+			config:   &Config{TaskHistorySize: 3},
+			alerters: &alerters{},
+		}
+		triggerableTasks.add(botType, task.IdentifierValue, r.triggerScheduledTask(context.Background(), bot, task), nil)
+
+		_, err := TriggerTask(botType, "unknown")
+		if !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		results, err := TriggerTask(botType, task.IdentifierValue, TriggerWithDryRun())
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+		if len(results) != 2 || results[0].Destination != "#general" || results[1].Destination != "#random" {
+			t.Errorf("Unexpected results are returned: %#v.", results)
+		}
+		if len(sent) != 0 {
+			t.Errorf("Results must not be sent on dry-run: %#v.", sent)
+		}
+		if history := ScheduledTaskHistory(botType, task.IdentifierValue); history != nil {
+			t.Errorf("Dry-run must not be recorded: %#v.", history)
+		}
+
+		results, err = TriggerTask(botType, task.IdentifierValue)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+		if len(results) != 2 || len(sent) != 2 {
+			t.Errorf("Results must be sent: %#v.", sent)
+		}
+		if history := ScheduledTaskHistory(botType, task.IdentifierValue); len(history) != 1 {
+			t.Errorf("Execution must be recorded: %#v.", history)
+		}
+	})
+}
+
+func TestTriggerTask_BotStopped(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		task := &DummyScheduledTask{IdentifierValue: "report"}
+		r := &runner{
+			config:   &Config{},
+			alerters: &alerters{},
+		}
+		triggerableTasks.add(botType, task.IdentifierValue, r.triggerScheduledTask(ctx, &DummyBot{}, task), nil)
+
+		_, err := TriggerTask(botType, task.IdentifierValue)
+		if !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestTriggerTask_Guarded(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		executed := 0
+		task := &scheduledTask{
+			identifier: "report",
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				executed++
+				return nil, nil
+			},
+			overlapPolicy: OverlapQueueOne,
+		}
+		locked := true
+		r := &runner{
+			config:   &Config{TaskHistorySize: 3},
+			alerters: &alerters{},
+			taskLocker: &DummyTaskLocker{
+				TryLockFunc: func(_ context.Context, _ string, _ time.Duration) (bool, error) {
+					return locked, nil
+				},
+				RenewFunc: func(_ context.Context, _ string, _ time.Duration) (bool, error) {
+					return true, nil
+				},
+			},
+		}
+		triggerableTasks.add(botType, task.identifier, r.triggerScheduledTask(context.Background(), &DummyBot{BotTypeValue: botType}, task), nil)
+
+		// Run the scheduled execution and trigger the task while it is running.
+		r.taskGuards.get(botType, task.identifier).run(OverlapSkip, func() {
+			_, err := TriggerTask(botType, task.identifier)
+			if !errors.Is(err, ErrTaskRunning) {
+				t.Errorf("Expected error is not returned: %#v.", err)
+			}
+		})
+		if executed != 0 {
+			t.Error("Triggered execution should not be queued.")
+		}
+
+		locked = false
+		_, err := TriggerTask(botType, task.identifier)
+		if !errors.Is(err, ErrTaskLocked) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+		if executed != 0 {
+			t.Error("Task should not be executed without the lock.")
+		}
+
+		locked = true
+		_, err = TriggerTask(botType, task.identifier)
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %+v.", err)
+		}
+		if executed != 1 {
+			t.Error("Task should be executed with the lock.")
+		}
+	})
+}
+
+func Test_taskRegistry(t *testing.T) {
+	registry := &taskRegistry{}
+	trigger := func(_ bool) ([]*ScheduledTaskResult, error) {
+		return nil, nil
+	}
+
+	if registry.find("dummy", "task") != nil {
+		t.Error("Nil should be returned on empty registry.")
+	}
+
+	registry.add("dummy", "task", trigger, func() {})
+	registry.add("dummy", "another", trigger, nil)
+	if registry.find("dummy", "task") == nil {
+		t.Error("Registered task is not found.")
+	}
+	if registry.findCatchUp("dummy", "task") == nil {
+		t.Error("Registered catch-up is not found.")
+	}
+	if registry.findCatchUp("dummy", "unknown") != nil {
+		t.Error("Nil should be returned for unknown task.")
+	}
+
+	registry.remove("dummy", "task")
+	if registry.find("dummy", "task") != nil {
+		t.Error("Removed task is found.")
+	}
+
+	registry.removeBot("dummy")
+	if registry.find("dummy", "another") != nil {
+		t.Error("Tasks of the removed Bot are found.")
+	}
+}
+
+func TestNewTriggerTaskCommandProps(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		_, err := NewTriggerTaskCommandProps(botType, nil)
+		if err == nil {
+			t.Error("Expected error is not returned for nil authorize function.")
+		}
+
+		props, err := NewTriggerTaskCommandProps(botType, func(input Input) bool {
+			return input.SenderKey() == "admin"
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+
+		command, err := buildCommand(context.TODO(), props, &nullConfigWatcher{})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+
+		admin := &DummyInput{SenderKeyValue: "admin", MessageValue: ".task dryrun report"}
+		user := &DummyInput{SenderKeyValue: "user", MessageValue: ".task dryrun report"}
+		if !command.Match(admin) {
+			t.Error("Admin's input should match.")
+		}
+		if command.Match(user) {
+			t.Error("Unauthorized input should not match.")
+		}
+		if command.Match(&DummyInput{SenderKeyValue: "admin", MessageValue: ".task report"}) {
+			t.Error("Input without sub-command should not match.")
+		}
+
+		if command.Instruction(NewHelpInput(user)) != "" {
+			t.Error("Instruction should be hidden from unauthorized user.")
+		}
+		if command.Instruction(NewHelpInput(admin)) == "" {
+			t.Error("Instruction should be returned to admin.")
+		}
+
+		res, err := command.Execute(context.TODO(), admin)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+		if !strings.HasPrefix(res.Content.(string), "Failed to execute report") {
+			t.Errorf("Unexpected response is returned: %s.", res.Content)
+		}
+
+		triggerableTasks.add(botType, "report", func(dryRun bool) ([]*ScheduledTaskResult, error) {
+			if !dryRun {
+				t.Error("Task should be triggered in dry-run mode.")
+			}
+			return []*ScheduledTaskResult{{Content: "report content", Destination: "#general"}}, nil
+		}, nil)
+
+		res, err = command.Execute(context.TODO(), admin)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %+v.", err)
+		}
+		if !strings.Contains(res.Content.(string), "#general: report content") {
+			t.Errorf("Unexpected response is returned: %s.", res.Content)
+		}
+	})
+}
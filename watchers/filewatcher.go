@@ -9,6 +9,7 @@ import (
 	"github.com/oklahomer/go-sarah/v3"
 	"github.com/oklahomer/go-sarah/v3/log"
 	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -82,6 +83,18 @@ func (w *fileWatcher) Read(ctx context.Context, botType sarah.BotType, id string
 	case jsonFile:
 		return json.NewDecoder(f).Decode(configPtr)
 
+	case icsFile:
+		unmarshaler, ok := configPtr.(sarah.ICalendarUnmarshaler)
+		if !ok {
+			return fmt.Errorf("configuration struct for %s does not support iCalendar file: %s", id, file.absPath)
+		}
+
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return fmt.Errorf("failed to read configuration file at %s: %w", file.absPath, err)
+		}
+		return unmarshaler.UnmarshalICalendar(data)
+
 	default:
 		// Should never come. findPluginConfigFile guarantees that.
 		return fmt.Errorf("unsupported file type: %s", file.absPath)
@@ -233,6 +246,7 @@ const (
 	_ fileType = iota
 	yamlFile
 	jsonFile
+	icsFile
 )
 
 var (
@@ -254,6 +268,10 @@ var (
 			ext:      ".json",
 			fileType: jsonFile,
 		},
+		{
+			ext:      ".ics",
+			fileType: icsFile,
+		},
 	}
 )
 
@@ -116,6 +116,39 @@ func TestFileWatcher_Read(t *testing.T) {
 	}
 }
 
+type dummyCalendar struct {
+	data []byte
+}
+
+func (c *dummyCalendar) UnmarshalICalendar(data []byte) error {
+	c.data = data
+	return nil
+}
+
+func TestFileWatcher_Read_ICalendar(t *testing.T) {
+	dirName, err := filepath.Abs(filepath.Join("..", "testdata", "config"))
+	if err != nil {
+		t.Fatalf("Unexpected error returned: %s.", err.Error())
+	}
+	w := &fileWatcher{
+		baseDir: dirName,
+	}
+
+	calendar := &dummyCalendar{}
+	err = w.Read(context.TODO(), "dummy", "holidays", calendar)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %s.", err.Error())
+	}
+	if len(calendar.data) == 0 {
+		t.Error("Configuration file content is not passed to the struct.")
+	}
+
+	err = w.Read(context.TODO(), "dummy", "holidays", &struct{}{})
+	if err == nil {
+		t.Error("Expected error is not returned for the struct without iCalendar support.")
+	}
+}
+
 func TestFileWatcher_Watch(t *testing.T) {
 	tests := []struct {
 		err error
@@ -459,6 +492,11 @@ func Test_plainPathToFile(t *testing.T) {
 			hasErr:   false,
 			fileType: yamlFile,
 		},
+		{
+			path:     "/path/to/ics/file.ics",
+			hasErr:   false,
+			fileType: icsFile,
+		},
 		{
 			path:   "/path/to/yaml/file.html",
 			hasErr: true,
@@ -0,0 +1,276 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v3/log"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ScalingEvent represents a change of the number of child workers in autoscaling mode.
+type ScalingEvent struct {
+	// From is the number of child workers before the change.
+	From int
+
+	// To is the number of child workers after the change.
+	To int
+
+	// Reason describes why the number is changed.
+	Reason string
+
+	// OccurredAt is the time the change occurred.
+	OccurredAt time.Time
+}
+
+// ScalingReporter is an optional interface that Reporter implementation may satisfy to be notified of each ScalingEvent.
+type ScalingReporter interface {
+	Reporter
+
+	// ReportScaling is called when the number of child workers changes in autoscaling mode.
+	ReportScaling(context.Context, *ScalingEvent)
+}
+
+// pool manages the child workers that work on the jobs passed by lanes.dispatch.
+type pool struct {
+	ready    chan struct{}
+	incoming chan func()
+
+	// The number of child workers is kept between min and max.
+	// The values are the same when autoscaling is disabled.
+	min int64
+	max int64
+
+	// idleTime is the duration after which an idle child worker retires.
+	// Zero means the child worker never retires.
+	idleTime time.Duration
+
+	num        int64
+	lastID     uint64
+	maxWait    int64
+	scaleUps   int64
+	scaleDowns int64
+
+	notify func(*ScalingEvent)
+}
+
+func newPool(config *Config, notify func(*ScalingEvent)) *pool {
+	p := &pool{
+		ready:    make(chan struct{}),
+		incoming: make(chan func()),
+		min:      int64(config.WorkerNum),
+		max:      int64(config.WorkerNum),
+		notify:   notify,
+	}
+
+	if autoscaling(config) {
+		p.min = int64(config.MinWorkerNum)
+		if p.min == 0 {
+			// At least one child worker must stay to take jobs.
+			p.min = 1
+		}
+		p.max = int64(config.MaxWorkerNum)
+		if p.max < p.min {
+			p.max = p.min
+		}
+		p.idleTime = config.ScaleDownIdleTime
+	}
+
+	return p
+}
+
+// autoscaling tells if the given config enables autoscaling mode.
+func autoscaling(config *Config) bool {
+	return config.MaxWorkerNum > 0
+}
+
+// size returns the current number of child workers.
+func (p *pool) size() int {
+	return int(atomic.LoadInt64(&p.num))
+}
+
+// spawn starts child workers as many as given, but not more than the maximum number.
+// This returns the number of the started child workers.
+func (p *pool) spawn(ctx context.Context, n int64) int64 {
+	var spawned int64
+	for ; spawned < n; spawned++ {
+		num := atomic.LoadInt64(&p.num)
+		if num >= p.max {
+			break
+		}
+		if !atomic.CompareAndSwapInt64(&p.num, num, num+1) {
+			spawned--
+			continue
+		}
+
+		go p.runChild(ctx, uint(atomic.AddUint64(&p.lastID, 1)))
+	}
+	return spawned
+}
+
+// retire decrements the number of child workers unless the number is already at its minimum.
+// This returns true when the calling child worker should stop.
+func (p *pool) retire() bool {
+	for {
+		num := atomic.LoadInt64(&p.num)
+		if num <= p.min {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&p.num, num, num-1) {
+			atomic.AddInt64(&p.scaleDowns, 1)
+			p.notify(&ScalingEvent{
+				From:       int(num),
+				To:         int(num - 1),
+				Reason:     fmt.Sprintf("worker is idle for %s", p.idleTime),
+				OccurredAt: time.Now(),
+			})
+			return true
+		}
+	}
+}
+
+// observeWait records the time a job waited in the queue.
+// The longest one is referred on the next autoscaling check.
+func (p *pool) observeWait(wait time.Duration) {
+	for {
+		current := atomic.LoadInt64(&p.maxWait)
+		if int64(wait) <= current || atomic.CompareAndSwapInt64(&p.maxWait, current, int64(wait)) {
+			return
+		}
+	}
+}
+
+// autoscale periodically checks the queue and spawns child workers when the queued jobs or their wait time exceed the thresholds.
+func (p *pool) autoscale(ctx context.Context, config *Config, queues *lanes) {
+	if config.ScaleInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(config.ScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			queued := 0
+			for _, size := range queues.sizes() {
+				queued += size
+			}
+			wait := time.Duration(atomic.SwapInt64(&p.maxWait, 0))
+
+			var reason string
+			switch {
+			case config.ScaleUpQueueSize > 0 && queued > int(config.ScaleUpQueueSize):
+				reason = fmt.Sprintf("%d jobs are queued", queued)
+
+			case config.ScaleUpWaitTime > 0 && wait > config.ScaleUpWaitTime:
+				reason = fmt.Sprintf("job waited for %s", wait)
+
+			default:
+				continue
+
+			}
+
+			// Spawn as many child workers as the queued jobs so the queue is drained at once.
+			n := int64(queued)
+			if n == 0 {
+				n = 1
+			}
+			from := p.size()
+			spawned := p.spawn(ctx, n)
+			if spawned == 0 {
+				log.Debugf("Worker is not scaled up since the number of workers is at its maximum: %d.", from)
+				continue
+			}
+
+			atomic.AddInt64(&p.scaleUps, 1)
+			p.notify(&ScalingEvent{
+				From:       from,
+				To:         from + int(spawned),
+				Reason:     reason,
+				OccurredAt: time.Now(),
+			})
+
+		}
+	}
+}
+
+// waitReady tells the dispatcher that the child worker is ready to take a job.
+// This returns false when the child worker should stop due to context cancellation or idleness.
+func (p *pool) waitReady(ctx context.Context) bool {
+	for {
+		var idle <-chan time.Time
+		var timer *time.Timer
+		if p.idleTime > 0 {
+			timer = time.NewTimer(p.idleTime)
+			idle = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return false
+
+		case <-idle:
+			if p.retire() {
+				return false
+			}
+			// Stay as one of the minimum workers.
+
+		case p.ready <- struct{}{}:
+			if timer != nil {
+				timer.Stop()
+			}
+			return true
+
+		}
+	}
+}
+
+func (p *pool) runChild(ctx context.Context, workerID uint) {
+	log.Debugf("Start worker id: %d.", workerID)
+
+	for {
+		if !p.waitReady(ctx) {
+			log.Debugf("Stop worker id: %d.", workerID)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debugf("Stop worker id: %d.", workerID)
+			return
+
+		case job := <-p.incoming:
+			log.Debugf("Receive job on worker: %d.", workerID)
+			// To avoid given job's panic affect later jobs, wrap them with recover.
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						stack := []string{fmt.Sprintf("panic in given job. recovered: %#v", r)}
+
+						// Display stack trace
+						for depth := 0; ; depth++ {
+							_, src, line, ok := runtime.Caller(depth)
+							if !ok {
+								break
+							}
+							stack = append(stack, fmt.Sprintf(" -> depth:%d. file:%s. line:%d.", depth, src, line))
+						}
+
+						log.Warn(strings.Join(stack, "\n"))
+					}
+				}()
+
+				job()
+			}()
+		}
+	}
+}
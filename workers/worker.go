@@ -8,8 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/oklahomer/go-sarah/v3/log"
-	"runtime"
-	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,6 +26,33 @@ type Config struct {
 	WorkerNum         uint          `json:"worker_num" yaml:"worker_num"`
 	QueueSize         uint          `json:"queue_size" yaml:"queue_size"`
 	SuperviseInterval time.Duration `json:"supervise_interval" yaml:"supervise_interval"`
+
+	// PriorityWeights is the number of jobs dequeued from each priority lane in one round.
+	// Each lane has its own queue of QueueSize, and a lane without weight is treated as weighing 1.
+	PriorityWeights map[Priority]uint `json:"priority_weights" yaml:"priority_weights"`
+
+	// MaxWorkerNum enables autoscaling mode when this is greater than zero.
+	// In autoscaling mode, WorkerNum is ignored and the number of child workers changes between MinWorkerNum and MaxWorkerNum.
+	MaxWorkerNum uint `json:"max_worker_num" yaml:"max_worker_num"`
+
+	// MinWorkerNum is the number of child workers that start and always stay in autoscaling mode.
+	// At least one child worker stays even when this is zero.
+	MinWorkerNum uint `json:"min_worker_num" yaml:"min_worker_num"`
+
+	// ScaleInterval is the interval to check if child workers should be added in autoscaling mode.
+	ScaleInterval time.Duration `json:"scale_interval" yaml:"scale_interval"`
+
+	// ScaleUpQueueSize is the number of queued jobs over which child workers are added in autoscaling mode.
+	// Zero disables this threshold.
+	ScaleUpQueueSize uint `json:"scale_up_queue_size" yaml:"scale_up_queue_size"`
+
+	// ScaleUpWaitTime is the time a job waits in the queue over which child workers are added in autoscaling mode.
+	// Zero disables this threshold.
+	ScaleUpWaitTime time.Duration `json:"scale_up_wait_time" yaml:"scale_up_wait_time"`
+
+	// ScaleDownIdleTime is the cool-down duration after which an idle child worker stops in autoscaling mode.
+	// Zero keeps the added child workers running.
+	ScaleDownIdleTime time.Duration `json:"scale_down_idle_time" yaml:"scale_down_idle_time"`
 }
 
 // NewConfig returns Config instance with default configuration values.
@@ -36,15 +62,95 @@ func NewConfig() *Config {
 		WorkerNum:         100,
 		QueueSize:         10,
 		SuperviseInterval: 60 * time.Second,
+		PriorityWeights: map[Priority]uint{
+			PriorityHigh:   6,
+			PriorityNormal: 3,
+			PriorityLow:    1,
+		},
+		MaxWorkerNum:      0,
+		MinWorkerNum:      10,
+		ScaleInterval:     1 * time.Second,
+		ScaleUpQueueSize:  5,
+		ScaleUpWaitTime:   1 * time.Second,
+		ScaleDownIdleTime: 5 * time.Minute,
+	}
+}
+
+// Priority represents the lane that a job is enqueued to.
+type Priority int
+
+const (
+	// PriorityNormal is the lane for ordinary jobs.
+	// Jobs given via Worker.Enqueue are enqueued to this lane.
+	PriorityNormal Priority = iota
+
+	// PriorityHigh is the lane for jobs that should jump the queue such as aborting a conversation.
+	PriorityHigh
+
+	// PriorityLow is the lane for jobs that may wait behind others.
+	PriorityLow
+)
+
+// priorities lists the priorities in the order that jobs are dequeued when every lane has jobs equally.
+var priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// String returns the stringified form of the priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+
+	case PriorityNormal:
+		return "normal"
+
+	case PriorityLow:
+		return "low"
+
+	default:
+		return "unknown"
+
 	}
 }
 
+// MarshalText marshals the priority to its stringified form so it can be used as a key of JSON or YAML map.
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText unmarshals the stringified form such as "high" to the priority.
+func (p *Priority) UnmarshalText(text []byte) error {
+	for _, priority := range priorities {
+		if priority.String() == string(text) {
+			*p = priority
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown priority is given: %s", text)
+}
+
 // Stats represents a group of statistical data.
 // This can be passed to Reporter.Report() to report current state.
 type Stats struct {
 	// QueueSize is the size of queued task to work.
 	// Use this value to adjust Config.QueueSize.
 	QueueSize int
+
+	// PriorityQueueSizes is the size of queued task for each priority lane.
+	// The sum of the values equals to QueueSize.
+	PriorityQueueSizes map[Priority]int
+
+	// WorkerNum is the current number of child workers.
+	WorkerNum int
+
+	// ScaleUps is the number of times child workers are added in autoscaling mode.
+	ScaleUps int
+
+	// ScaleDowns is the number of child workers stopped due to their idleness in autoscaling mode.
+	ScaleDowns int
+
+	// QueueGauges is the values of the gauges given via WithQueueGauge keyed by their names.
+	// Use this to report the number of queued jobs of a specific kind such as scheduled executions.
+	QueueGauges map[string]int
 }
 
 // Reporter is an interface to report statistics such as queue length to outer service.
@@ -71,13 +177,30 @@ func WithReporter(reporter Reporter) WorkerOption {
 	}
 }
 
+// WithQueueGauge creates and returns WorkerOption to report the value of the given gauge via Stats.QueueGauges with the given name.
+func WithQueueGauge(name string, gauge func() int) WorkerOption {
+	return func(w *worker) {
+		if w.gauges == nil {
+			w.gauges = map[string]func() int{}
+		}
+		w.gauges[name] = gauge
+	}
+}
+
 type worker struct {
 	reporter   Reporter
-	enqueueFnc func(func()) error
+	gauges     map[string]func() int
+	enqueueFnc func(func(), Priority) error
 }
 
+var _ PriorityWorker = (*worker)(nil)
+
 func (w *worker) Enqueue(fnc func()) error {
-	return w.enqueueFnc(fnc)
+	return w.enqueueFnc(fnc, PriorityNormal)
+}
+
+func (w *worker) EnqueueWithPriority(fnc func(), priority Priority) error {
+	return w.enqueueFnc(fnc, priority)
 }
 
 // Worker is an interface that all Worker implementation must satisfy.
@@ -90,23 +213,63 @@ type Worker interface {
 	Enqueue(func()) error
 }
 
+// PriorityWorker is an optional interface that Worker implementation may satisfy to let urgent jobs jump the queue.
+// The Worker returned by Run satisfies this, and go-sarah's core enqueues incoming messages with the priority given by its classifier.
+type PriorityWorker interface {
+	Worker
+
+	// EnqueueWithPriority enqueues the job to the lane of the given priority.
+	EnqueueWithPriority(func(), Priority) error
+}
+
 // Run creates as many child workers as specified by *Config and start them.
 // When Run completes, Worker is returned so jobs can be enqueued.
 // Multiple calls to Run() creates multiple Worker with separate context, queue and child workers.
+// An error is returned when config is nil or Config.WorkerNum is zero without autoscaling mode.
+//
+// Jobs are enqueued to the lanes of their priorities, and are dequeued in weighted round-robin manner with Config.PriorityWeights
+// so a busy lane does not starve the others.
+//
+// When Config.MaxWorkerNum is set, the Worker runs in autoscaling mode.
+// Child workers are added when the queued jobs or their wait time exceed the thresholds, and idle ones stop after the cool-down.
+// Each change is passed to the Reporter when it satisfies ScalingReporter.
 func Run(ctx context.Context, config *Config, options ...WorkerOption) (Worker, error) {
-	incoming := make(chan func(), config.QueueSize)
+	if config == nil {
+		return nil, errors.New("config must be given")
+	}
+	if !autoscaling(config) && config.WorkerNum == 0 {
+		return nil, errors.New("WorkerNum must be greater than zero unless autoscaling mode is enabled")
+	}
+
+	queues := newLanes(config)
+	var children *pool
 
 	w := &worker{
-		enqueueFnc: func(job func()) error {
+		enqueueFnc: func(job func(), priority Priority) error {
 			if err := ctx.Err(); err != nil {
 				// Context is canceled.
 				return ErrEnqueueAfterWorkerShutdown
 			}
 
+			queue, ok := queues.queues[priority]
+			if !ok {
+				return fmt.Errorf("unknown priority is given: %d", priority)
+			}
+
+			if autoscaling(config) {
+				// Record the wait time to judge if child workers should be added.
+				enqueuedAt := time.Now()
+				fnc := job
+				job = func() {
+					children.observeWait(time.Since(enqueuedAt))
+					fnc()
+				}
+			}
+
 			// There is a chance that context is cancelled right after above ctx.Err() check.
 			// That however should not be a major problem.
 			select {
-			case incoming <- job:
+			case queue <- job:
 				return nil
 
 			default:
@@ -120,60 +283,123 @@ func Run(ctx context.Context, config *Config, options ...WorkerOption) (Worker,
 		opt(w)
 	}
 
-	log.Infof("Start spawning %d workers.", config.WorkerNum)
-	var i uint
-	for i = 1; i <= config.WorkerNum; i++ {
-		go runChild(ctx, incoming, i)
+	if w.reporter == nil {
+		w.reporter = &reporter{}
+	}
+	children = newPool(config, func(event *ScalingEvent) {
+		log.Infof("Scaled workers from %d to %d: %s.", event.From, event.To, event.Reason)
+		if scalingReporter, ok := w.reporter.(ScalingReporter); ok {
+			scalingReporter.ReportScaling(ctx, event)
+		}
+	})
+
+	// Each idle child tells its readiness so a job is taken out of the lanes only when a child can work on it.
+	// This keeps the queues' capacity as configured.
+	go queues.dispatch(ctx, children.ready, children.incoming)
+
+	log.Infof("Start spawning %d workers.", children.min)
+	children.spawn(ctx, children.min)
+	log.Infof("End spawning %d workers.", children.min)
+
+	if autoscaling(config) {
+		go children.autoscale(ctx, config, queues)
 	}
-	log.Infof("End spawning %d workers.", config.WorkerNum)
 
 	if config.SuperviseInterval > 0 {
-		if w.reporter == nil {
-			w.reporter = &reporter{}
-		}
-		go supervise(ctx, w.reporter, incoming, config.SuperviseInterval)
+		go supervise(ctx, w.reporter, queues, children, w.gauges, config.SuperviseInterval)
 	}
 
 	return w, nil
 }
 
-func runChild(ctx context.Context, job <-chan func(), workerID uint) {
-	log.Debugf("Start worker id: %d.", workerID)
+// lanes holds the queues of the priority lanes.
+type lanes struct {
+	queues map[Priority]chan func()
 
+	// order is the sequence of the lanes to dequeue from in one round.
+	// Each lane appears as many times as its weight.
+	order []Priority
+}
+
+func newLanes(config *Config) *lanes {
+	l := &lanes{
+		queues: map[Priority]chan func(){},
+	}
+	for _, priority := range priorities {
+		l.queues[priority] = make(chan func(), config.QueueSize)
+
+		weight := config.PriorityWeights[priority]
+		if weight == 0 {
+			weight = 1
+		}
+		for i := uint(0); i < weight; i++ {
+			l.order = append(l.order, priority)
+		}
+	}
+	return l
+}
+
+// dispatch passes the queued jobs to the children in weighted round-robin manner.
+// A job is dequeued only after a child gets ready.
+func (l *lanes) dispatch(ctx context.Context, ready <-chan struct{}, incoming chan<- func()) {
+	cursor := 0
 	for {
 		select {
 		case <-ctx.Done():
-			log.Debugf("Stop worker id: %d.", workerID)
 			return
 
-		case job := <-job:
-			log.Debugf("Receive job on worker: %d.", workerID)
-			// To avoid given job's panic affect later jobs, wrap them with recover.
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						stack := []string{fmt.Sprintf("panic in given job. recovered: %#v", r)}
-
-						// Display stack trace
-						for depth := 0; ; depth++ {
-							_, src, line, ok := runtime.Caller(depth)
-							if !ok {
-								break
-							}
-							stack = append(stack, fmt.Sprintf(" -> depth:%d. file:%s. line:%d.", depth, src, line))
-						}
-
-						log.Warn(strings.Join(stack, "\n"))
-					}
-				}()
-
-				job()
-			}()
+		case <-ready:
+			// Proceed to pass a job
+
+		}
+
+		var job func()
+		for i := 0; i < len(l.order) && job == nil; i++ {
+			priority := l.order[(cursor+i)%len(l.order)]
+			select {
+			case job = <-l.queues[priority]:
+				cursor = (cursor + i + 1) % len(l.order)
+
+			default:
+				// Try next lane
+
+			}
+		}
+
+		if job == nil {
+			// All lanes are empty; pass whichever comes first.
+			select {
+			case <-ctx.Done():
+				return
+
+			case job = <-l.queues[PriorityHigh]:
+			case job = <-l.queues[PriorityNormal]:
+			case job = <-l.queues[PriorityLow]:
+
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case incoming <- job:
+			// Passed to the ready child
+
 		}
 	}
 }
 
-func supervise(ctx context.Context, reporter Reporter, job chan<- func(), interval time.Duration) {
+// sizes returns the number of the queued jobs for each lane.
+func (l *lanes) sizes() map[Priority]int {
+	sizes := map[Priority]int{}
+	for priority, queue := range l.queues {
+		sizes[priority] = len(queue)
+	}
+	return sizes
+}
+
+func supervise(ctx context.Context, reporter Reporter, queues *lanes, children *pool, gauges map[string]func() int, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -183,8 +409,23 @@ func supervise(ctx context.Context, reporter Reporter, job chan<- func(), interv
 			return
 
 		case <-ticker.C:
+			sizes := queues.sizes()
+			total := 0
+			for _, size := range sizes {
+				total += size
+			}
 			stats := &Stats{
-				QueueSize: len(job),
+				QueueSize:          total,
+				PriorityQueueSizes: sizes,
+				WorkerNum:          children.size(),
+				ScaleUps:           int(atomic.LoadInt64(&children.scaleUps)),
+				ScaleDowns:         int(atomic.LoadInt64(&children.scaleDowns)),
+			}
+			if len(gauges) > 0 {
+				stats.QueueGauges = map[string]int{}
+				for name, gauge := range gauges {
+					stats.QueueGauges[name] = gauge()
+				}
 			}
 			reporter.Report(ctx, stats)
 
@@ -198,6 +198,35 @@ func TestRun_ErrQueueOverflow(t *testing.T) {
 	}
 }
 
+func TestRun_InvalidConfig(t *testing.T) {
+	workerCtx, cancelWorker := context.WithCancel(context.Background())
+	defer cancelWorker()
+
+	for i, config := range []*Config{nil, {}} {
+		_, err := Run(workerCtx, config)
+		if err == nil {
+			t.Errorf("Expected error is not returned on test %d.", i)
+		}
+	}
+
+	_, err := Run(workerCtx, &Config{MaxWorkerNum: 1})
+	if err != nil {
+		t.Errorf("Zero WorkerNum should be allowed in autoscaling mode: %s.", err.Error())
+	}
+}
+
+func TestWithQueueGauge(t *testing.T) {
+	w := &worker{}
+	WithQueueGauge("tasks", func() int {
+		return 1
+	})(w)
+
+	gauge, ok := w.gauges["tasks"]
+	if !ok || gauge() != 1 {
+		t.Error("Given gauge is not set.")
+	}
+}
+
 func TestRun_WorkerOption(t *testing.T) {
 	rootCtx := context.Background()
 	workerCtx, cancelWorker := context.WithCancel(rootCtx)
@@ -209,7 +238,7 @@ func TestRun_WorkerOption(t *testing.T) {
 			cnt++
 		},
 	}
-	_, err := Run(workerCtx, &Config{}, opts...)
+	_, err := Run(workerCtx, &Config{WorkerNum: 1}, opts...)
 
 	if cnt != len(opts) {
 		t.Fatalf("%d WorkerOptions are given, but executed %d time(s).", len(opts), cnt)
@@ -221,27 +250,48 @@ func TestRun_WorkerOption(t *testing.T) {
 }
 
 func Test_superviseQueueLength(t *testing.T) {
-	job := make(chan func(), 10)
-	for i := 0; i < cap(job); i++ {
-		job <- func() {}
+	config := NewConfig()
+	queues := newLanes(config)
+	for i := 0; i < int(config.QueueSize); i++ {
+		queues.queues[PriorityNormal] <- func() {}
 	}
+	queues.queues[PriorityHigh] <- func() {}
 
-	reportedSize := make(chan int, 1)
+	reportedStats := make(chan *Stats, 1)
 	reporter := &DummyReporter{
 		ReportFunc: func(_ context.Context, stats *Stats) {
-			reportedSize <- stats.QueueSize
+			select {
+			case reportedStats <- stats:
+			default:
+			}
 		},
 	}
 
 	rootCtx := context.Background()
 	ctx, cancel := context.WithCancel(rootCtx)
 	defer cancel()
-	go supervise(ctx, reporter, job, 1*time.Millisecond)
+	children := newPool(config, func(_ *ScalingEvent) {})
+	children.num = 3
+	gauges := map[string]func() int{
+		"tasks": func() int {
+			return 2
+		},
+	}
+	go supervise(ctx, reporter, queues, children, gauges, 1*time.Millisecond)
 
 	select {
-	case size := <-reportedSize:
-		if size != cap(job) {
-			t.Errorf("Expected report size to be %d, but was %d.", cap(job), size)
+	case stats := <-reportedStats:
+		if stats.QueueSize != int(config.QueueSize)+1 {
+			t.Errorf("Expected report size to be %d, but was %d.", config.QueueSize+1, stats.QueueSize)
+		}
+		if stats.PriorityQueueSizes[PriorityHigh] != 1 || stats.PriorityQueueSizes[PriorityLow] != 0 {
+			t.Errorf("Unexpected sizes are reported: %#v.", stats.PriorityQueueSizes)
+		}
+		if stats.WorkerNum != 3 {
+			t.Errorf("Unexpected worker number is reported: %d.", stats.WorkerNum)
+		}
+		if stats.QueueGauges["tasks"] != 2 {
+			t.Errorf("Unexpected gauges are reported: %#v.", stats.QueueGauges)
 		}
 
 	case <-time.NewTimer(1 * time.Second).C:
@@ -249,3 +299,208 @@ func Test_superviseQueueLength(t *testing.T) {
 
 	}
 }
+
+func TestRun_WithPriority(t *testing.T) {
+	rootCtx := context.Background()
+	workerCtx, cancelWorker := context.WithCancel(rootCtx)
+	defer cancelWorker()
+
+	config := NewConfig()
+	config.WorkerNum = 1
+	config.QueueSize = 10
+	config.PriorityWeights = map[Priority]uint{
+		PriorityHigh:   2,
+		PriorityNormal: 1,
+	}
+	w, err := Run(workerCtx, config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s.", err.Error())
+	}
+	worker := w.(PriorityWorker)
+
+	// Block the only available worker while the jobs are enqueued.
+	// This job is taken from the last lane of the round so the next round starts from the beginning.
+	blocker := make(chan struct{})
+	_ = worker.EnqueueWithPriority(func() {
+		<-blocker
+	}, PriorityLow)
+	time.Sleep(10 * time.Millisecond)
+
+	executed := make(chan string, 10)
+	job := func(name string) func() {
+		return func() {
+			executed <- name
+		}
+	}
+	for _, name := range []string{"normal1", "normal2"} {
+		if err := worker.Enqueue(job(name)); err != nil {
+			t.Fatalf("Unexpected error: %s.", err.Error())
+		}
+	}
+	if err := worker.EnqueueWithPriority(job("low1"), PriorityLow); err != nil {
+		t.Fatalf("Unexpected error: %s.", err.Error())
+	}
+	for _, name := range []string{"high1", "high2", "high3"} {
+		if err := worker.EnqueueWithPriority(job(name), PriorityHigh); err != nil {
+			t.Fatalf("Unexpected error: %s.", err.Error())
+		}
+	}
+	close(blocker)
+
+	expected := []string{"high1", "high2", "normal1", "low1", "high3", "normal2"}
+	for i, name := range expected {
+		select {
+		case e := <-executed:
+			if e != name {
+				t.Errorf("Unexpected job is executed at %d: %s.", i, e)
+			}
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("Job is not executed.")
+
+		}
+	}
+
+	if err := worker.EnqueueWithPriority(func() {}, 100); err == nil {
+		t.Error("Expected error is not returned for unknown priority.")
+	}
+}
+
+func TestPriority_UnmarshalText(t *testing.T) {
+	config := NewConfig()
+	yamlBytes := []byte("priority_weights:\n  high: 10\n  low: 2")
+	if err := yaml.Unmarshal(yamlBytes, config); err != nil {
+		t.Fatalf("Error on parsing given YAML structure: %s. %s.", string(yamlBytes), err.Error())
+	}
+	if config.PriorityWeights[PriorityHigh] != 10 || config.PriorityWeights[PriorityLow] != 2 {
+		t.Errorf("PriorityWeights is not overridden with YAML value: %#v.", config.PriorityWeights)
+	}
+
+	jsonBytes := []byte(`{"priority_weights": {"normal": 5}}`)
+	if err := json.Unmarshal(jsonBytes, config); err != nil {
+		t.Fatalf("Error on parsing given JSON structure: %s. %s.", string(jsonBytes), err.Error())
+	}
+	if config.PriorityWeights[PriorityNormal] != 5 {
+		t.Errorf("PriorityWeights is not overridden with JSON value: %#v.", config.PriorityWeights)
+	}
+
+	var p Priority
+	if err := p.UnmarshalText([]byte("urgent")); err == nil {
+		t.Error("Expected error is not returned for unknown priority.")
+	}
+}
+
+type DummyScalingReporter struct {
+	DummyReporter
+	ReportScalingFunc func(context.Context, *ScalingEvent)
+}
+
+func (r *DummyScalingReporter) ReportScaling(ctx context.Context, event *ScalingEvent) {
+	r.ReportScalingFunc(ctx, event)
+}
+
+func TestRun_WithAutoscaling(t *testing.T) {
+	rootCtx := context.Background()
+	workerCtx, cancelWorker := context.WithCancel(rootCtx)
+	defer cancelWorker()
+
+	events := make(chan *ScalingEvent, 10)
+	reporter := &DummyScalingReporter{
+		ReportScalingFunc: func(_ context.Context, event *ScalingEvent) {
+			events <- event
+		},
+	}
+
+	config := NewConfig()
+	config.SuperviseInterval = 0
+	config.QueueSize = 10
+	config.MinWorkerNum = 1
+	config.MaxWorkerNum = 3
+	config.ScaleInterval = 10 * time.Millisecond
+	config.ScaleUpQueueSize = 1
+	config.ScaleUpWaitTime = 0
+	config.ScaleDownIdleTime = 50 * time.Millisecond
+	w, err := Run(workerCtx, config, WithReporter(reporter))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s.", err.Error())
+	}
+
+	// Block workers so the jobs are queued.
+	blocker := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		if err := w.Enqueue(func() { <-blocker }); err != nil {
+			t.Fatalf("Unexpected error: %s.", err.Error())
+		}
+	}
+
+	select {
+	case event := <-events:
+		if event.From != 1 || event.To != 3 {
+			t.Errorf("Unexpected scaling event is reported: %#v.", event)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Worker is not scaled up.")
+
+	}
+
+	close(blocker)
+
+	// Added workers stop after the cool-down, while the minimum number of worker stays.
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-events:
+			if event.To != event.From-1 {
+				t.Errorf("Unexpected scaling event is reported: %#v.", event)
+			}
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("Worker is not scaled down.")
+
+		}
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected scaling event is reported: %#v.", event)
+
+	case <-time.NewTimer(100 * time.Millisecond).C:
+		// O.K.
+
+	}
+}
+
+func Test_pool_observeWait(t *testing.T) {
+	config := NewConfig()
+	config.MaxWorkerNum = 2
+	config.MinWorkerNum = 0
+	config.ScaleUpQueueSize = 0
+	config.ScaleUpWaitTime = 10 * time.Millisecond
+	config.ScaleInterval = 5 * time.Millisecond
+
+	events := make(chan *ScalingEvent, 1)
+	p := newPool(config, func(event *ScalingEvent) {
+		events <- event
+	})
+	if p.min != 1 || p.max != 2 {
+		t.Fatalf("Unexpected range is set: %d-%d.", p.min, p.max)
+	}
+
+	p.observeWait(20 * time.Millisecond)
+	p.observeWait(5 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.autoscale(ctx, config, newLanes(config))
+
+	select {
+	case event := <-events:
+		if event.From != 0 || event.To != 1 {
+			t.Errorf("Unexpected scaling event is reported: %#v.", event)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Worker is not scaled up by the wait time.")
+
+	}
+}